@@ -9,6 +9,8 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/unsuman/greeter/pkg/cmd"
+	"github.com/unsuman/greeter/pkg/config"
+	"github.com/unsuman/greeter/pkg/greetings"
 	"github.com/unsuman/greeter/pkg/plugin"
 	"github.com/unsuman/greeter/pkg/plugin/registry"
 
@@ -19,9 +21,8 @@ import (
 )
 
 var (
-	log        *logrus.Logger
-	pluginMgr  *plugin.PluginManager
-	pluginsDir string
+	log       *logrus.Logger
+	pluginMgr *plugin.PluginManager
 )
 
 func init() {
@@ -29,12 +30,56 @@ func init() {
 	log.SetFormatter(&logrus.TextFormatter{
 		FullTimestamp: true,
 	})
-
-	// Setup plugins
-	pluginsDir, pluginMgr = cmd.SetupPlugins(log)
 }
 
 func main() {
+	// Plugins are limited and sandboxed through this executable
+	plugin.RunTrampolines()
+
+	if len(os.Args) < 2 {
+		cmd.PrintUsage()
+		os.Exit(1)
+	}
+
+	command := strings.ToLower(os.Args[1])
+
+	// Plugin maintenance commands take their own arguments
+	if command == "plugin" {
+		if err := cmd.Plugin(log, os.Args[2:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if command == "config" {
+		if err := cmd.Config(os.Args[2:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	opts, err := cmd.ParseOptions(os.Args[2:])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		cmd.PrintUsage()
+		os.Exit(1)
+	}
+
+	// Flags take precedence over the system, user and --config configuration files
+	cfg, err := config.Load(opts.ConfigFile)
+	cmd.SetupLogging(log, cfg, opts.Debug)
+	if err != nil {
+		log.Warnf("Ignoring invalid configuration: %v", err)
+	}
+	opts.ApplyConfig(cfg)
+
+	// Setup plugins, --plugin-dir takes precedence over the other plugin directories
+	pluginMgr = cmd.SetupPlugins(log, cfg, opts.PluginDirs)
+	if !opts.NoDaemon {
+		cmd.UseDaemon(log, pluginMgr, cfg)
+	}
+
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
@@ -45,17 +90,9 @@ func main() {
 		os.Exit(0)
 	}()
 
-	if len(os.Args) < 2 {
-		cmd.PrintUsage()
-		os.Exit(1)
-	}
-
-	command := strings.ToLower(os.Args[1])
-	language := "english" // Default language
-
-	// Check if language is specified
-	if len(os.Args) > 2 && strings.HasPrefix(os.Args[2], "--lang=") {
-		language = strings.TrimPrefix(os.Args[2], "--lang=")
+	language := opts.Language
+	if language == "" {
+		language = cmd.DefaultLanguage(log, pluginMgr, cfg)
 	}
 
 	// Process commands
@@ -63,22 +100,48 @@ func main() {
 	case "list-languages":
 		cmd.ListAvailableLanguages(log, pluginMgr)
 		return
+	case "shutdown-plugin":
+		err := cmd.ShutdownPlugin(log, pluginMgr, language)
+		pluginMgr.CleanupPlugins()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	case "occasions":
+		err := cmd.ListOccasions(log, pluginMgr, language, opts)
+		pluginMgr.CleanupPlugins()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	case "export":
+		err := cmd.Export(log, pluginMgr, opts)
+		pluginMgr.CleanupPlugins()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
 
 	// Get greeting
-	message, err := cmd.GetGreeting(log, pluginMgr, pluginsDir, command, language)
+	var greeting greetings.Greeting
+	if command == "greet" {
+		greeting, err = cmd.GetTimedGreeting(log, pluginMgr, language, opts)
+	} else {
+		greeting, err = cmd.PickGreeting(log, pluginMgr, language, opts, command)
+	}
 	if err != nil {
 		log.Errorf("Failed to get greeting: %v", err)
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	green := "\033[92m"
-	reset := "\033[0m"
-
-	fmt.Println(green + message + reset)
+	fmt.Println(opts.Colorize(opts.Format(greeting)))
 
-	pluginMgr.StopPlugin("lang", language)
+	pluginMgr.CleanupPlugins()
 	registry.DefaultRegistry.Close()
 
 	log.Info("Exiting...")
@@ -0,0 +1,99 @@
+// greeterd keeps the external plugins of greeter running between
+// invocations of the CLI, serving them on a Unix socket.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+
+	"github.com/unsuman/greeter/pkg/cmd"
+	"github.com/unsuman/greeter/pkg/config"
+	"github.com/unsuman/greeter/pkg/daemon"
+	"github.com/unsuman/greeter/pkg/plugin"
+	"github.com/unsuman/greeter/pkg/plugin/registry"
+)
+
+var log *logrus.Logger
+
+func init() {
+	log = logrus.New()
+	log.SetFormatter(&logrus.TextFormatter{
+		FullTimestamp: true,
+	})
+}
+
+func main() {
+	// Plugins are limited and sandboxed through this executable
+	plugin.RunTrampolines()
+
+	var pluginDirs []string
+	flags := flag.NewFlagSet("greeterd", flag.ExitOnError)
+	socket := flags.String("socket", "", "Unix socket to listen on, defaults to the daemon.socket setting or "+daemon.SocketPath())
+	configFile := flags.String("config", "", "configuration file overriding the system and user ones")
+	preload := flags.String("preload", "", "comma-separated language plugins to start right away")
+	debug := flags.Bool("debug", false, "enable debug logging")
+	flags.Func("plugin-dir", "directory to search for plugins first, may be repeated", func(dir string) error {
+		pluginDirs = append(pluginDirs, dir)
+		return nil
+	})
+	flags.Parse(os.Args[1:])
+
+	cfg, err := config.Load(*configFile)
+	cmd.SetupLogging(log, cfg, *debug)
+	if err != nil {
+		log.Warnf("Ignoring invalid configuration: %v", err)
+	}
+
+	// The CLI only uses the daemon if both search plugins in the same directories
+	pluginMgr := cmd.SetupPlugins(log, cfg, pluginDirs)
+
+	path := *socket
+	if path == "" {
+		path = cmd.DaemonSocket(cfg)
+	}
+
+	// Plugins kept warm are started right away too
+	names := strings.Split(*preload, ",")
+	for name, p := range cfg.Plugins {
+		if p.KeepWarm {
+			names = append(names, name)
+		}
+	}
+	started := make(map[string]bool)
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name == "" || started[name] {
+			continue
+		}
+		started[name] = true
+		if err := pluginMgr.StartPlugin("lang", name); err != nil {
+			log.Warnf("Failed to preload plugin %s: %v", name, err)
+		}
+	}
+
+	grpcServer := grpc.NewServer()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		log.Info("Shutting down...")
+		grpcServer.Stop()
+	}()
+
+	err = daemon.NewServer(log, pluginMgr).Serve(grpcServer, path)
+	pluginMgr.CleanupPlugins()
+	registry.DefaultRegistry.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	log.Info("Exiting...")
+}
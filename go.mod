@@ -3,14 +3,14 @@ module github.com/unsuman/greeter
 go 1.23.5
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/sirupsen/logrus v1.9.3
+	golang.org/x/sys v0.29.0
+	golang.org/x/text v0.21.0
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250115164207-1a7da9e5054f
 	google.golang.org/grpc v1.71.0
 	google.golang.org/protobuf v1.36.4
+	gopkg.in/yaml.v3 v3.0.1
 )
 
-require (
-	golang.org/x/net v0.34.0 // indirect
-	golang.org/x/sys v0.29.0 // indirect
-	golang.org/x/text v0.21.0 // indirect
-	google.golang.org/genproto/googleapis/rpc v0.0.0-20250115164207-1a7da9e5054f // indirect
-)
+require golang.org/x/net v0.34.0 // indirect
@@ -0,0 +1,108 @@
+package calendar
+
+import (
+	"math"
+	"time"
+)
+
+// Phase is a phase of the Moon
+type Phase int
+
+const (
+	NewMoon Phase = iota
+	FullMoon
+)
+
+func (p Phase) String() string {
+	if p == FullMoon {
+		return "full moon"
+	}
+	return "new moon"
+}
+
+const (
+	// unixEpochJD is the Julian day of 1970-01-01 00:00 UTC
+	unixEpochJD = 2440587.5
+	// j2000 is the Julian day of 2000-01-01 12:00 TT
+	j2000 = 2451545.0
+	// deltaT approximates TT - UTC over the years greeter cares about
+	deltaT = 69 * time.Second
+)
+
+func julianDay(t time.Time) float64 {
+	return float64(t.Unix())/86400 + unixEpochJD
+}
+
+func fromJulianDay(jd float64) time.Time {
+	seconds := (jd - unixEpochJD) * 86400
+	return time.Unix(0, int64(seconds*1e9)).UTC()
+}
+
+// PhaseBefore returns the moment of the last phase of the Moon strictly
+// before t, accurate to a few minutes
+func PhaseBefore(phase Phase, t time.Time) time.Time {
+	offset := 0.0
+	if phase == FullMoon {
+		offset = 0.5
+	}
+
+	// Lunations since the new moon of 2000-01-06, see phaseMoment
+	k := math.Floor((julianDay(t)-j2000)/365.25*12.3685) + offset
+	for !phaseMoment(k).Before(t) {
+		k--
+	}
+	for phaseMoment(k + 1).Before(t) {
+		k++
+	}
+	return phaseMoment(k)
+}
+
+// phaseMoment computes the moment of the lunation k, integer for new moons
+// and half-integer for full moons, after Jean Meeus, Astronomical
+// Algorithms, chapter 49
+func phaseMoment(k float64) time.Time {
+	T := k / 1236.85
+	T2, T3, T4 := T*T, T*T*T, T*T*T*T
+
+	jde := 2451550.09766 + 29.530588861*k + 0.00015437*T2 - 0.000000150*T3 + 0.00000000073*T4
+
+	rad := math.Pi / 180
+	E := 1 - 0.002516*T - 0.0000074*T2
+	M := (2.5534 + 29.10535670*k - 0.0000014*T2 - 0.00000011*T3) * rad
+	Mp := (201.5643 + 385.81693528*k + 0.0107582*T2 + 0.00001238*T3 - 0.000000058*T4) * rad
+	F := (160.7108 + 390.67050284*k - 0.0016118*T2 - 0.00000227*T3 + 0.000000011*T4) * rad
+	Omega := (124.7746 - 1.56375588*k + 0.0020672*T2 + 0.00000215*T3) * rad
+
+	sin := math.Sin
+	var c float64
+	if k == math.Floor(k) {
+		c = -0.40720*sin(Mp) + 0.17241*E*sin(M) + 0.01608*sin(2*Mp) + 0.01039*sin(2*F) +
+			0.00739*E*sin(Mp-M) - 0.00514*E*sin(Mp+M) + 0.00208*E*E*sin(2*M)
+	} else {
+		c = -0.40614*sin(Mp) + 0.17302*E*sin(M) + 0.01614*sin(2*Mp) + 0.01043*sin(2*F) +
+			0.00734*E*sin(Mp-M) - 0.00515*E*sin(Mp+M) + 0.00209*E*E*sin(2*M)
+	}
+	c += -0.00111*sin(Mp-2*F) - 0.00057*sin(Mp+2*F) + 0.00056*E*sin(2*Mp+M) - 0.00042*sin(3*Mp) +
+		0.00042*E*sin(M+2*F) + 0.00038*E*sin(M-2*F) - 0.00024*E*sin(2*Mp-M) - 0.00017*sin(Omega) -
+		0.00007*sin(Mp+2*M) + 0.00004*sin(2*Mp-2*F) + 0.00004*sin(3*M) + 0.00003*sin(Mp+M-2*F) +
+		0.00003*sin(2*Mp+2*F) - 0.00003*sin(Mp+M+2*F) + 0.00003*sin(Mp-M+2*F) - 0.00002*sin(Mp-M-2*F) -
+		0.00002*sin(3*Mp+M) + 0.00002*sin(4*Mp)
+
+	// Planetary arguments
+	planetary := [][3]float64{
+		{299.77, 0.107408, 0.000325}, {251.88, 0.016321, 0.000165}, {251.83, 26.651886, 0.000164},
+		{349.42, 36.412478, 0.000126}, {84.66, 18.206239, 0.000110}, {141.74, 53.303771, 0.000062},
+		{207.14, 2.453732, 0.000060}, {154.84, 7.306860, 0.000056}, {34.52, 27.261239, 0.000047},
+		{207.19, 0.121824, 0.000042}, {291.34, 1.844379, 0.000040}, {161.72, 24.198154, 0.000037},
+		{239.56, 25.513099, 0.000035}, {331.55, 3.592518, 0.000023},
+	}
+	for i, p := range planetary {
+		a := p[0] + p[1]*k
+		if i == 0 {
+			a -= 0.009173 * T2
+		}
+		c += p[2] * sin(a*rad)
+	}
+
+	return fromJulianDay(jde + c).Add(-deltaT)
+}
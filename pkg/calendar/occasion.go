@@ -0,0 +1,80 @@
+package calendar
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/unsuman/greeter/pkg/greetings"
+)
+
+// Observance is an occurrence of an occasion
+type Observance struct {
+	greetings.Occasion
+	// Start is the first day of the occurrence, at midnight UTC
+	Start time.Time
+}
+
+// Includes reports whether the observance covers the date of t
+func (o Observance) Includes(t time.Time) bool {
+	day := Day(t)
+	return !day.Before(o.Start) && day.Before(o.Start.AddDate(0, 0, o.Duration()))
+}
+
+// Duration returns how many days the occasion lasts
+func (o Observance) Duration() int {
+	if o.Days < 1 {
+		return 1
+	}
+	return o.Days
+}
+
+// Day returns the date of t in its own location, at midnight UTC
+func Day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Observances returns the occurrences of the occasions overlapping the days
+// from and to, sorted by date. Occasions with an invalid rule are skipped
+// and reported in the returned error.
+func Observances(occasions []greetings.Occasion, from, to time.Time) ([]Observance, error) {
+	from, to = Day(from), Day(to)
+
+	var observances []Observance
+	var errs []error
+	for _, occasion := range occasions {
+		rule, err := ParseRule(occasion.Rule)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		// Start a year early for occasions spanning the new year
+		for year := from.Year() - 1; year <= to.Year(); year++ {
+			start, ok := rule.Date(year)
+			if !ok {
+				continue
+			}
+			o := Observance{Occasion: occasion, Start: start}
+			end := o.Start.AddDate(0, 0, o.Duration()-1)
+			if !end.Before(from) && !o.Start.After(to) {
+				observances = append(observances, o)
+			}
+		}
+	}
+
+	sort.SliceStable(observances, func(i, j int) bool {
+		return observances[i].Start.Before(observances[j].Start)
+	})
+	return observances, errors.Join(errs...)
+}
+
+// On returns the occasion observed on the date of t, if any. When several
+// overlap, the one that started last wins, e.g. a festival during a season.
+func On(occasions []greetings.Occasion, t time.Time) (Observance, bool, error) {
+	observances, err := Observances(occasions, t, t)
+	if len(observances) == 0 {
+		return Observance{}, false, err
+	}
+	return observances[len(observances)-1], true, err
+}
@@ -0,0 +1,237 @@
+// Package calendar computes the dates of festivals and holidays offline, so
+// languages can greet appropriately on occasions such as Diwali or the New
+// Year.
+//
+// Occasions are declared with rules, small textual specs that travel
+// unchanged through language packs and the plugin protocol:
+//
+//	12-25                                   a fixed Gregorian date
+//	easter                                  Western Easter Sunday
+//	fourth thursday of november             the n-th (or last) weekday of a month
+//	new moon before 11-16                   the last new moon before a date
+//	full moon before new moon before 04-14  lunar rules can be nested
+//
+// followed by optional comma separated modifiers:
+//
+//	in Asia/Kolkata   time zone turning moments into dates, UTC by default
+//	shifted -19h      shift of the moment before taking its date
+//	offset -2         days added to the date, e.g. "easter, offset -2" for Good Friday
+//
+// The shift encodes at which time of day a lunisolar observance switches
+// dates, e.g. Diwali is celebrated on the evening the new moon tithi
+// prevails: "new moon before 11-16, shifted -19h, in Asia/Kolkata".
+//
+// Rules only fall in the years their date exists: "02-29" is skipped in
+// common years and "fifth monday of february" in most years.
+package calendar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rule tells on which day an occasion falls each year
+type Rule struct {
+	spec     string
+	base     base
+	shift    time.Duration
+	location *time.Location
+	offset   int
+}
+
+// base is the part of a rule before its modifiers
+type base interface {
+	// moment returns when the base falls in a year, dates starting at midnight
+	// in loc, and false when it does not fall in that year
+	moment(year int, loc *time.Location) (time.Time, bool)
+}
+
+// ParseRule parses a rule spec, see the package documentation
+func ParseRule(spec string) (*Rule, error) {
+	parts := strings.Split(spec, ",")
+	b, err := parseBase(strings.Fields(strings.ToLower(parts[0])))
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule %q: %w", spec, err)
+	}
+
+	r := &Rule{spec: spec, base: b, location: time.UTC}
+	for _, modifier := range parts[1:] {
+		name, value, _ := strings.Cut(strings.TrimSpace(modifier), " ")
+		value = strings.TrimSpace(value)
+
+		switch name {
+		case "in":
+			loc, err := time.LoadLocation(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rule %q: %w", spec, err)
+			}
+			r.location = loc
+		case "shifted":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rule %q: %w", spec, err)
+			}
+			r.shift = d
+		case "offset":
+			days, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSuffix(value, " days"), " day"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid rule %q: offset must be a number of days", spec)
+			}
+			r.offset = days
+		default:
+			return nil, fmt.Errorf("invalid rule %q: unknown modifier %q (expected in, shifted or offset)", spec, name)
+		}
+	}
+
+	return r, nil
+}
+
+// String returns the spec the rule was parsed from
+func (r *Rule) String() string {
+	return r.spec
+}
+
+// Date returns the day the rule falls on in a year, at midnight UTC, and
+// false when the rule does not fall in that year
+func (r *Rule) Date(year int) (time.Time, bool) {
+	m, ok := r.base.moment(year, r.location)
+	if !ok {
+		return time.Time{}, false
+	}
+	m = m.Add(r.shift).In(r.location)
+	return time.Date(m.Year(), m.Month(), m.Day()+r.offset, 0, 0, 0, 0, time.UTC), true
+}
+
+func parseBase(fields []string) (base, error) {
+	switch {
+	case len(fields) == 0:
+		return nil, fmt.Errorf("empty rule")
+
+	case len(fields) > 3 && fields[1] == "moon" && fields[2] == "before":
+		var phase Phase
+		switch fields[0] {
+		case "new":
+			phase = NewMoon
+		case "full":
+			phase = FullMoon
+		default:
+			return nil, fmt.Errorf("unknown moon phase %q (expected new or full)", fields[0])
+		}
+		anchor, err := parseBase(fields[3:])
+		if err != nil {
+			return nil, err
+		}
+		return lunar{phase: phase, before: anchor}, nil
+
+	case len(fields) == 1 && fields[0] == "easter":
+		return easter{}, nil
+
+	case len(fields) == 4 && fields[2] == "of":
+		return parseNthWeekday(fields)
+
+	case len(fields) == 1:
+		t, err := time.Parse("01-02", fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a MM-DD date", fields[0])
+		}
+		return fixed{month: t.Month(), day: t.Day()}, nil
+
+	default:
+		return nil, fmt.Errorf("unrecognised rule %q", strings.Join(fields, " "))
+	}
+}
+
+// fixed is a fixed Gregorian date
+type fixed struct {
+	month time.Month
+	day   int
+}
+
+func (f fixed) moment(year int, loc *time.Location) (time.Time, bool) {
+	t := time.Date(year, f.month, f.day, 0, 0, 0, 0, loc)
+	return t, t.Month() == f.month
+}
+
+// easter is Western Easter Sunday, after the anonymous Gregorian algorithm
+type easter struct{}
+
+func (easter) moment(year int, loc *time.Location) (time.Time, bool) {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc), true
+}
+
+// nthWeekday is the n-th weekday of a month, counted from the end when n is negative
+type nthWeekday struct {
+	n       int
+	weekday time.Weekday
+	month   time.Month
+}
+
+var ordinals = map[string]int{"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5, "last": -1}
+
+func parseNthWeekday(fields []string) (base, error) {
+	n, ok := ordinals[fields[0]]
+	if !ok {
+		return nil, fmt.Errorf("unknown ordinal %q (expected first to fifth or last)", fields[0])
+	}
+
+	weekday, month := -1, -1
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.ToLower(d.String()) == fields[1] {
+			weekday = int(d)
+		}
+	}
+	for m := time.January; m <= time.December; m++ {
+		if strings.ToLower(m.String()) == fields[3] {
+			month = int(m)
+		}
+	}
+	if weekday < 0 {
+		return nil, fmt.Errorf("unknown weekday %q", fields[1])
+	}
+	if month < 0 {
+		return nil, fmt.Errorf("unknown month %q", fields[3])
+	}
+
+	return nthWeekday{n: n, weekday: time.Weekday(weekday), month: time.Month(month)}, nil
+}
+
+func (w nthWeekday) moment(year int, loc *time.Location) (time.Time, bool) {
+	if w.n < 0 {
+		last := time.Date(year, w.month+1, 0, 0, 0, 0, 0, loc)
+		back := (int(last.Weekday()) - int(w.weekday) + 7) % 7
+		return last.AddDate(0, 0, -back), true
+	}
+
+	// Fifth weekdays only exist in some months of some years
+	first := time.Date(year, w.month, 1, 0, 0, 0, 0, loc)
+	ahead := (int(w.weekday) - int(first.Weekday()) + 7) % 7
+	t := first.AddDate(0, 0, ahead+7*(w.n-1))
+	return t, t.Month() == w.month
+}
+
+// lunar is the last phase of the Moon before another rule
+type lunar struct {
+	phase  Phase
+	before base
+}
+
+func (l lunar) moment(year int, loc *time.Location) (time.Time, bool) {
+	anchor, ok := l.before.moment(year, loc)
+	if !ok {
+		return time.Time{}, false
+	}
+	return PhaseBefore(l.phase, anchor), true
+}
@@ -0,0 +1,154 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRuleDate(t *testing.T) {
+	tests := []struct {
+		spec string
+		year int
+		want time.Time
+	}{
+		{"12-25", 2026, date(2026, time.December, 25)},
+		{"02-29", 2024, date(2024, time.February, 29)},
+		{"02-29", 2000, date(2000, time.February, 29)},
+		{"fourth thursday of november", 2026, date(2026, time.November, 26)},
+		{"first monday of september", 2026, date(2026, time.September, 7)},
+		{"last monday of may", 2026, date(2026, time.May, 25)},
+		{"last sunday of may", 2026, date(2026, time.May, 31)},
+		{"fifth monday of february", 2016, date(2016, time.February, 29)},
+		{"fifth friday of january", 2027, date(2027, time.January, 29)},
+		{"easter, offset -2", 2026, date(2026, time.April, 3)},
+		{"easter, offset 1 day", 2027, date(2027, time.March, 29)},
+		{"12-31, offset 1", 2026, date(2027, time.January, 1)},
+		{"12-31, in Asia/Kolkata, shifted +25h", 2026, date(2027, time.January, 1)},
+		{"01-01, shifted -1h", 2026, date(2025, time.December, 31)},
+		{"new moon before 11-16", 2026, date(2026, time.November, 9)},
+	}
+	for _, tt := range tests {
+		r, err := ParseRule(tt.spec)
+		if err != nil {
+			t.Errorf("ParseRule(%q): %v", tt.spec, err)
+			continue
+		}
+		got, ok := r.Date(tt.year)
+		if !ok || !got.Equal(tt.want) {
+			t.Errorf("%q in %d = %s, %v, want %s", tt.spec, tt.year, got.Format(time.DateOnly), ok, tt.want.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestRuleDateMissing(t *testing.T) {
+	tests := []struct {
+		spec string
+		year int
+	}{
+		{"02-29", 2026},
+		{"02-29", 2100},
+		{"fifth monday of february", 2021},
+		{"fifth monday of february", 2026},
+		{"fifth friday of april", 2026},
+		{"new moon before 02-29", 2023},
+		{"02-29, offset 1", 2025},
+	}
+	for _, tt := range tests {
+		r, err := ParseRule(tt.spec)
+		if err != nil {
+			t.Errorf("ParseRule(%q): %v", tt.spec, err)
+			continue
+		}
+		if got, ok := r.Date(tt.year); ok {
+			t.Errorf("%q in %d = %s, want no date", tt.spec, tt.year, got.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestRuleDateYears(t *testing.T) {
+	tests := []struct {
+		spec string
+		// want holds the dates of 2020 to 2028 as MM-DD
+		want []string
+	}{
+		{"easter", []string{"04-12", "04-04", "04-17", "04-09", "03-31", "04-20", "04-05", "03-28", "04-16"}},
+		// Diwali (Lakshmi Puja) and Holi (Rangwali) as observed in India
+		{"new moon before 11-16, shifted -19h, in Asia/Kolkata", []string{"11-14", "11-04", "10-24", "11-12", "10-31", "10-20", "11-08", "10-29", "10-17"}},
+		{"full moon before new moon before 04-14, shifted +7h30m, in Asia/Kolkata", []string{"03-10", "03-29", "03-18", "03-08", "03-25", "03-14", "03-04", "03-22", "03-11"}},
+	}
+	for _, tt := range tests {
+		r, err := ParseRule(tt.spec)
+		if err != nil {
+			t.Errorf("ParseRule(%q): %v", tt.spec, err)
+			continue
+		}
+		for i, want := range tt.want {
+			year := 2020 + i
+			got, ok := r.Date(year)
+			if !ok || got.Format("01-02") != want {
+				t.Errorf("%q in %d = %s, %v, want %d-%s", tt.spec, year, got.Format(time.DateOnly), ok, year, want)
+			}
+		}
+	}
+}
+
+func TestEasterExtremes(t *testing.T) {
+	r, err := ParseRule("easter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []time.Time{
+		date(1818, time.March, 22),
+		date(2285, time.March, 22),
+		date(1943, time.April, 25),
+		date(2038, time.April, 25),
+	} {
+		if got, _ := r.Date(want.Year()); !got.Equal(want) {
+			t.Errorf("easter in %d = %s, want %s", want.Year(), got.Format(time.DateOnly), want.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"", "empty rule"},
+		{"13-01", "not a MM-DD date"},
+		{"02-30", "not a MM-DD date"},
+		{"christmas", "not a MM-DD date"},
+		{"half moon before 11-16", "unknown moon phase"},
+		{"new moon before", "unrecognised rule"},
+		{"new moon before 11-32", "not a MM-DD date"},
+		{"sixth monday of may", "unknown ordinal"},
+		{"first moonday of may", "unknown weekday"},
+		{"first monday of maytime", "unknown month"},
+		{"12-25, on Mars", "unknown modifier"},
+		{"12-25, in Nowhere/City", "invalid rule"},
+		{"12-25, shifted 3 hours", "invalid rule"},
+		{"12-25, offset two", "offset must be a number of days"},
+	}
+	for _, tt := range tests {
+		_, err := ParseRule(tt.spec)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseRule(%q): got %v, want an error containing %q", tt.spec, err, tt.want)
+		}
+	}
+}
+
+func TestRuleString(t *testing.T) {
+	spec := "Easter, offset -2"
+	r, err := ParseRule(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.String() != spec {
+		t.Errorf("String() = %q, want %q", r.String(), spec)
+	}
+}
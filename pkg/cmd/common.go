@@ -2,113 +2,331 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/unsuman/greeter/pkg/config"
+	"github.com/unsuman/greeter/pkg/gettext"
 	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/langpack"
 	"github.com/unsuman/greeter/pkg/plugin"
 	"github.com/unsuman/greeter/pkg/plugin/registry"
+	"github.com/unsuman/greeter/pkg/plugin/sandbox"
+	"github.com/unsuman/greeter/pkg/plugin/searchpath"
+	"github.com/unsuman/greeter/pkg/plugin/signing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-// SetupPlugins initializes the plugin system
-func SetupPlugins(logger *logrus.Logger) (string, *plugin.PluginManager) {
+// SetupPlugins initializes the plugin system, searching for plugins in the
+// --plugin-dir directories first, see searchpath.Build for the other ones
+func SetupPlugins(logger *logrus.Logger, cfg *config.Config, pluginDirs []string) *plugin.PluginManager {
 	execPath, err := os.Executable()
 	if err != nil {
 		logger.Fatal("Failed to get executable path:", err)
 	}
 
-	var pluginsDir string
-	pluginsDir = filepath.Dir(execPath)
+	searchPath := searchpath.Build(searchpath.Options{
+		Flags:      pluginDirs,
+		Config:     cfg.PluginDirs,
+		Executable: execPath,
+	})
+	logger.Infof("Plugin search path: %s", searchPath)
 
-	logger.Infof("Using plugins directory: %s", pluginsDir)
+	pluginMgr := plugin.NewPluginManager(logger, searchPath)
+	setupSignatureVerification(logger, pluginMgr, cfg.Signing, cfg.SigningErr)
+	for name, p := range cfg.Plugins {
+		pluginMgr.SetPluginConfig(name, plugin.PluginConfig{
+			StartTimeout: p.StartTimeoutDuration(),
+			Timeout:      p.TimeoutDuration(),
+			Env:          p.Env,
+			Restart:      restartPolicy(p),
+			IdleTimeout:  p.IdleTimeoutDuration(),
+			KeepWarm:     p.KeepWarm,
 
-	pluginMgr := plugin.NewPluginManager(logger, pluginsDir)
+			MinInstances:   p.MinInstances,
+			MaxInstances:   p.MaxInstances,
+			ScaleDownAfter: p.ScaleDownAfterDuration(),
 
-	return pluginsDir, pluginMgr
+			Limits: p.Limits.Limits(),
+			Sandbox: sandbox.Config{
+				Enabled:   p.Sandbox.Enabled,
+				ReadPaths: p.Sandbox.ReadPaths,
+				Required:  p.Sandbox.Required,
+			},
+		})
+	}
+	pluginMgr.SetIdleTimeout(cfg.Daemon.IdleTimeoutDuration())
+	pluginMgr.SetCgroupRoot(cfg.PluginCgroup)
+
+	// Crashes are remembered across invocations, so that plugins crashing on
+	// every run are quarantined too
+	if path, err := statePath(breakerFile); err == nil {
+		pluginMgr.SetBreakerStore(plugin.NewBreakerStore(path, logger))
+	} else {
+		logger.Warnf("Keeping plugin crashes in memory: %v", err)
+	}
+
+	// Language packs are served in-process like the embedded plugins, the
+	// first directory providing a language wins
+	for _, dir := range searchPath {
+		langpack.RegisterDir(registry.DefaultRegistry, filepath.Join(dir.Path, "packs"), logger)
+		gettext.RegisterDir(registry.DefaultRegistry, filepath.Join(dir.Path, "locale"), logger)
+	}
+
+	return pluginMgr
+}
+
+// restartPolicy returns the supervision policy configured for a plugin
+func restartPolicy(p config.Plugin) plugin.RestartPolicy {
+	backoff, maxBackoff, window, quarantine := p.RestartDurations()
+	return plugin.RestartPolicy{
+		Never:       p.Restart == "never",
+		Backoff:     backoff,
+		MaxBackoff:  maxBackoff,
+		MaxRestarts: p.MaxRestarts,
+		Window:      window,
+		Quarantine:  quarantine,
+	}
+}
+
+// SetupLogging applies the log settings of the configuration, --debug
+// taking precedence over the configured level
+func SetupLogging(logger *logrus.Logger, cfg *config.Config, debug bool) {
+	if cfg.Log.Format == "json" {
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	}
+	if level, err := logrus.ParseLevel(cfg.Log.Level); err == nil {
+		logger.SetLevel(level)
+	}
+	if debug {
+		logger.SetLevel(logrus.DebugLevel)
+	}
+	registry.DefaultRegistry.SetLogger(logger)
+}
+
+// setupSignatureVerification configures the plugin signature checks. A
+// keyring that cannot be loaded refuses every plugin unless the mode is off,
+// and so does a signing configuration that could not be read in full.
+func setupSignatureVerification(logger *logrus.Logger, pluginMgr *plugin.PluginManager, cfg config.Signing, cfgErr error) {
+	if cfgErr != nil {
+		logger.Errorf("Refusing every plugin, the signing configuration is invalid: %v", cfgErr)
+		pluginMgr.SetSignatureVerification(signing.ModeEnforce, nil)
+		return
+	}
+
+	def := signing.ModeOff
+	if len(cfg.TrustedKeys) > 0 {
+		def = signing.ModeEnforce
+	}
+	mode, err := signing.ParseMode(cfg.Mode, def)
+	if err != nil {
+		logger.Warnf("%v, enforcing signatures", err)
+		mode = signing.ModeEnforce
+	}
+
+	keyring, err := signing.LoadKeyring(cfg.TrustedKeys)
+	if err != nil && mode != signing.ModeOff {
+		logger.Errorf("Failed to load the plugin keyring: %v", err)
+	}
+
+	logger.Debugf("Plugin signature verification: %s with %d trusted keys", mode, len(keyring))
+	pluginMgr.SetSignatureVerification(mode, keyring)
 }
 
 // GetGreeting gets a greeting from either an internal or external plugin
-func GetGreeting(logger *logrus.Logger, pluginMgr *plugin.PluginManager, pluginsDir, command, language string) (string, error) {
+func GetGreeting(logger *logrus.Logger, pluginMgr *plugin.PluginManager, language string, req greetings.Request) (greetings.Greeting, error) {
+	language, err := ResolveLanguage(logger, pluginMgr, language)
+	if err != nil {
+		return greetings.Greeting{}, err
+	}
+
 	if plugin, exists := registry.DefaultRegistry.Get(language); exists {
 		logger.Debugf("Using embedded plugin for language: %s", language)
-		return GetGreetingFromInternalPlugin(command, plugin)
+		return GetGreetingFromInternalPlugin(plugin, req)
 	}
 
 	// If not found as embedded, try external plugin
-	return GetGreetingFromExternalPlugin(logger, pluginMgr, pluginsDir, command, language)
+	return GetGreetingFromExternalPlugin(logger, pluginMgr, language, req)
 }
 
-// GetGreetingFromInternalPlugin gets a greeting from an internal plugin
-func GetGreetingFromInternalPlugin(command string, plugin greetings.Plugin) (string, error) {
-	switch command {
-	case "hello":
-		return plugin.Hello(), nil
-	case "goodmorning":
-		return plugin.GoodMorning(), nil
-	case "goodafternoon":
-		return plugin.GoodAfternoon(), nil
-	case "goodnight":
-		return plugin.GoodNight(), nil
-	case "goodbye":
-		return plugin.GoodBye(), nil
-	default:
-		return "", fmt.Errorf("unknown greeting command: %s", command)
+// GetTimedGreeting gets the greeting matching the occasion or the time of day
+// given by the options, using the occasions and time windows declared by the language
+func GetTimedGreeting(logger *logrus.Logger, pluginMgr *plugin.PluginManager, language string, opts *Options) (greetings.Greeting, error) {
+	at, err := opts.Time()
+	if err != nil {
+		return greetings.Greeting{}, err
 	}
+
+	language, err = ResolveLanguage(logger, pluginMgr, language)
+	if err != nil {
+		return greetings.Greeting{}, err
+	}
+
+	info, err := getLanguageInfo(logger, pluginMgr, language)
+	if err != nil {
+		return greetings.Greeting{}, err
+	}
+
+	// Festivals and holidays take precedence over the time of day
+	if observance, ok := occasionOn(logger, info, at); ok {
+		logger.Debugf("Picked greeting %s for %s on %s in %s", observance.Kind, observance.Name, at.Format(time.DateOnly), language)
+		return pickGreeting(logger, pluginMgr, language, opts, observance.Kind)
+	}
+
+	kind := greetings.KindAt(info.DayParts, info.Kinds, at)
+	logger.Debugf("Picked greeting %s for %s in %s", kind, at.Format("15:04 MST"), language)
+
+	return pickGreeting(logger, pluginMgr, language, opts, kind)
+}
+
+// getLanguageInfo returns the metadata of an internal or external language plugin
+func getLanguageInfo(logger *logrus.Logger, pluginMgr *plugin.PluginManager, language string) (*greetings.Info, error) {
+	if plugin, exists := registry.DefaultRegistry.Get(language); exists {
+		info := greetings.Describe(plugin)
+		return &info, nil
+	}
+
+	pluginPath, dir, err := pluginMgr.Locate("lang", language)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debugf("Querying external plugin for language: %s at %s (%s)", language, pluginPath, dir.Source)
+	return pluginMgr.PluginInfo(context.Background(), "lang", language)
+}
+
+// GetGreetingFromInternalPlugin gets a greeting from an internal plugin
+func GetGreetingFromInternalPlugin(plugin greetings.Plugin, req greetings.Request) (greetings.Greeting, error) {
+	return plugin.Greet(req)
 }
 
 // GetGreetingFromExternalPlugin gets a greeting from an external plugin
-func GetGreetingFromExternalPlugin(logger *logrus.Logger, pluginMgr *plugin.PluginManager, pluginsDir, command, language string) (string, error) {
-	pluginPath := filepath.Join(pluginsDir, "lang", language)
-	if _, err := os.Stat(pluginPath); os.IsNotExist(err) {
-		return "", fmt.Errorf("language plugin '%s' not found", language)
+func GetGreetingFromExternalPlugin(logger *logrus.Logger, pluginMgr *plugin.PluginManager, language string, req greetings.Request) (greetings.Greeting, error) {
+	pluginPath, dir, err := pluginMgr.Locate("lang", language)
+	if err != nil {
+		return greetings.Greeting{}, err
 	}
 
-	logger.Debugf("Found external plugin for language: %s at %s", language, pluginPath)
+	logger.Debugf("Found external plugin for language: %s at %s (%s)", language, pluginPath, dir.Source)
 
 	if err := pluginMgr.StartPlugin("lang", language); err != nil {
-		return "", fmt.Errorf("failed to start %s plugin: %w", language, err)
+		// A refusal is the answer, not a failure to start
+		var refused *signing.VerificationError
+		if errors.As(err, &refused) {
+			return greetings.Greeting{}, refused
+		}
+		return greetings.Greeting{}, fmt.Errorf("failed to start %s plugin: %w", language, err)
 	}
 
 	// Execute greeting command via gRPC
 	ctx := context.Background()
-	result, err := pluginMgr.GetGreeting(ctx, "lang", language, command)
+	result, err := pluginMgr.GetGreeting(ctx, "lang", language, req)
 
 	if err != nil {
-		pluginMgr.StopPlugin("lang", language)
-		return "", err
+		stopBrokenPlugin(logger, pluginMgr, language, err)
+		return greetings.Greeting{}, err
 	}
 
 	return result, nil
 }
 
+// stopBrokenPlugin stops a plugin started by this process after a call that
+// failed because the plugin hung or lost its connection, so that the next
+// call starts it afresh. Plugins answering with an error, e.g. for a kind
+// they do not support, keep running, and so do the plugins of greeterd,
+// which other clients share.
+func stopBrokenPlugin(logger *logrus.Logger, pluginMgr *plugin.PluginManager, language string, err error) {
+	if pluginMgr.Remote() != nil {
+		return
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+	default:
+		return
+	}
+
+	logger.Debugf("Stopping %s plugin after a failed call: %v", language, err)
+	if err := pluginMgr.StopPlugin("lang", language); err != nil {
+		logger.Warnf("Failed to stop %s plugin: %v", language, err)
+	}
+}
+
 // ListAvailableLanguages lists all available languages
 func ListAvailableLanguages(logger *logrus.Logger, pluginMgr *plugin.PluginManager) {
 	fmt.Println("Available languages:")
 
+	// Show the locale each language is resolved from by --lang
+	locales := make(map[string]string)
+	for _, c := range NewLocaleResolver(logger, pluginMgr).Candidates() {
+		locales[c.Name] = c.Tag.String()
+	}
+
 	// List embedded languages first
 	for _, lang := range registry.DefaultRegistry.List() {
-		fmt.Printf("- %s (built-in)\n", lang)
+		plugin, _ := registry.DefaultRegistry.Get(lang)
+		source := "built-in"
+		switch p := plugin.(type) {
+		case *langpack.Pack:
+			source = "language pack " + filepath.Base(p.Path)
+		case *gettext.Plugin:
+			source = "gettext catalog " + filepath.Base(p.Path)
+		}
+		printLanguage(greetings.Describe(plugin), locales[lang], source)
 	}
 
 	// List external languages
-	languages, err := pluginMgr.DiscoverPlugins("lang")
+	descriptions, err := pluginMgr.DescribePlugins(context.Background(), "lang")
 	if err != nil {
 		logger.Errorf("Failed to discover language plugins: %v", err)
 		return
 	}
 
 	// Don't show languages that are already listed as built-in
-	for _, lang := range languages {
-		if _, exists := registry.DefaultRegistry.Get(lang); !exists {
-			fmt.Printf("- %s (plugin)\n", lang)
+	for _, desc := range descriptions {
+		if _, exists := registry.DefaultRegistry.Get(desc.Name); !exists {
+			printLanguage(desc.Info, locales[desc.Name], fmt.Sprintf("plugin, protocol v%d, %s", desc.ProtocolVersion, strings.ToLower(desc.Health.String())))
+			fmt.Printf("    path:      %s (from %s)\n", desc.Path, desc.Dir.Source)
 		}
 	}
 }
 
+// printLanguage prints the list-languages entry of a single language
+func printLanguage(info greetings.Info, tag, source string) {
+	fmt.Printf("- %s [%s] (%s)\n", info.Name, tag, source)
+	fmt.Printf("    name:      %s\n", info.DisplayName)
+	if info.Version != "" {
+		fmt.Printf("    version:   %s\n", info.Version)
+	}
+	if info.Author != "" {
+		fmt.Printf("    author:    %s\n", info.Author)
+	}
+	if info.Description != "" {
+		fmt.Printf("    about:     %s\n", info.Description)
+	}
+	fmt.Printf("    greetings: %s\n", strings.Join(info.Kinds, ", "))
+}
+
 func PrintUsage() {
-	fmt.Println("Usage: greeter <command> [--lang=language]")
-	fmt.Println("Available commands: hello, goodmorning, goodafternoon, goodnight, goodbye, list-languages, shutdown-plugin")
-	fmt.Println("Example: greeter hello --lang=hindi")
+	fmt.Println("Usage: greeter <command> [--lang=language] [--to=name] [--formality=casual|neutral|formal|honorific]")
+	fmt.Println("       greeter greet [--lang=language] [--to=name] [--tz=zone] [--at=HH:MM] [--date=YYYY-MM-DD]")
+	fmt.Println("       greeter occasions [--lang=language] [--date=YYYY-MM-DD]")
+	fmt.Println("       greeter export [--format=po|xliff|arb|json|csv] [--out=dir] [--lang=language]")
+	fmt.Println("       greeter plugin sign --key=private.pem <plugin binary>...")
+	fmt.Println("       greeter plugin status|release <plugin>...")
+	fmt.Println("       greeter config list|get <key>|set <key> <value> [--system|--config=file]")
+	fmt.Println("Output flags: --native-only, --romanized-only, --details, --variant=first|random|rotate, --seed=N")
+	fmt.Println("Plugin flags: --plugin-dir=dir, searched before GREETER_PLUGIN_PATH, the config file, the executable directory and the XDG data dirs")
+	fmt.Println("Configuration: /etc/greeter/config.toml, then ~/.config/greeter/config.toml, then --config=file")
+	fmt.Println("Plugins are served by greeterd when it is running, unless --no-daemon is given; shutdown-plugin stops the one of --lang")
+	fmt.Println("Available commands: <greeting kind>, greet, occasions, list-languages, export, plugin, config, shutdown-plugin")
+	fmt.Println("Greeting kinds are declared by each language, e.g. hello, goodmorning, goodafternoon, goodevening, goodnight, goodbye, welcome")
+	fmt.Println("Languages can be given by name or BCP 47 tag, e.g. --lang=hindi, --lang=hi or --lang=hi-IN")
+	fmt.Println("Without --lang the language comes from LANGUAGE, LC_ALL, LC_MESSAGES or LANG, then the config file, then english")
+	fmt.Println("Example: greeter hello --lang=hindi --to=Priya")
 }
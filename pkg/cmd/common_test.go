@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin"
+	"github.com/unsuman/greeter/pkg/plugin/searchpath"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// fakeRemote is a plugin.Remote recording the plugins it is asked to stop
+type fakeRemote struct {
+	stopped []string
+}
+
+func (r *fakeRemote) GetGreeting(context.Context, string, string, greetings.Request) (greetings.Greeting, error) {
+	return greetings.Greeting{}, nil
+}
+
+func (r *fakeRemote) GetVariants(context.Context, string, string, greetings.Request) ([]greetings.Variant, error) {
+	return nil, nil
+}
+
+func (r *fakeRemote) ListKinds(context.Context, string, string) ([]string, error) {
+	return nil, nil
+}
+
+func (r *fakeRemote) PluginInfo(context.Context, string, string) (*greetings.Info, error) {
+	return &greetings.Info{}, nil
+}
+
+func (r *fakeRemote) Health(context.Context, string, string) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	return healthpb.HealthCheckResponse_SERVING, nil
+}
+
+func (r *fakeRemote) StopPlugin(_ context.Context, category, name string) error {
+	r.stopped = append(r.stopped, category+"/"+name)
+	return nil
+}
+
+func (r *fakeRemote) Close() error {
+	return nil
+}
+
+func TestStopBrokenPlugin(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		stop bool
+	}{
+		{"connection lost", status.Error(codes.Unavailable, "transport is closing"), true},
+		{"timeout", status.Error(codes.DeadlineExceeded, "context deadline exceeded"), true},
+		{"unsupported kind", &greetings.UnsupportedKindError{Kind: "xyz", Language: "hindi"}, false},
+		{"plugin error", status.Error(codes.Unknown, "no such greeting"), false},
+		{"invalid argument", status.Error(codes.InvalidArgument, "bad register"), false},
+		{"start failure", errors.New("failed to start hindi plugin"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, hook := logtest.NewNullLogger()
+			logger.SetLevel(logrus.DebugLevel)
+			pluginMgr := plugin.NewPluginManager(logger, searchpath.Path{})
+
+			stopBrokenPlugin(logger, pluginMgr, "hindi", tt.err)
+			stopped := false
+			for _, entry := range hook.AllEntries() {
+				if strings.HasPrefix(entry.Message, "Stopping hindi plugin") {
+					stopped = true
+				}
+			}
+			if stopped != tt.stop {
+				t.Errorf("stopped = %v, want %v", stopped, tt.stop)
+			}
+
+			// Plugins of greeterd are shared with other clients
+			remote := &fakeRemote{}
+			pluginMgr.SetRemote(remote)
+			stopBrokenPlugin(logger, pluginMgr, "hindi", tt.err)
+			if len(remote.stopped) > 0 {
+				t.Errorf("stopped %v through greeterd", remote.stopped)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/unsuman/greeter/pkg/config"
+)
+
+// Config runs the configuration subcommands:
+//
+//	greeter config list [--config=file]
+//	greeter config get [--config=file] <key>
+//	greeter config set [--system|--config=file] <key> <value>
+//
+// list and get show the settings merged from every configuration file,
+// leaving out and reporting the invalid ones, set changes the user
+// configuration file unless another one is selected.
+func Config(args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing config subcommand, expected list, get or set")
+	}
+
+	flags := flag.NewFlagSet("greeter config", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	file := flags.String("config", "", "configuration file overriding the system and user ones")
+	system := flags.Bool("system", false, "change the system configuration file")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		if flags.NArg() != 0 {
+			return errors.New("usage: greeter config list [--config=file]")
+		}
+		cfg, err := config.Load(*file)
+		warnInvalid(err)
+		printSettings(cfg.Settings())
+		return nil
+
+	case "get":
+		if flags.NArg() != 1 {
+			return errors.New("usage: greeter config get [--config=file] <key>")
+		}
+		cfg, err := config.Load(*file)
+		warnInvalid(err)
+		settings, ok := cfg.Get(flags.Arg(0))
+		if !ok {
+			return fmt.Errorf("%s is not set", flags.Arg(0))
+		}
+		if len(settings) == 1 && settings[0].Key == flags.Arg(0) {
+			// A single value is printed bare for scripts
+			if s, ok := settings[0].Value.(string); ok {
+				fmt.Println(s)
+			} else {
+				fmt.Println(config.FormatValue(settings[0].Value))
+			}
+			return nil
+		}
+		printSettings(settings)
+		return nil
+
+	case "set":
+		if flags.NArg() != 2 {
+			return errors.New("usage: greeter config set [--system|--config=file] <key> <value>")
+		}
+		path := *file
+		switch {
+		case *system && path != "":
+			return errors.New("--system and --config are mutually exclusive")
+		case *system:
+			path = config.SystemPath
+		case path == "":
+			var err error
+			if path, err = config.UserPath(); err != nil {
+				return err
+			}
+		}
+		if err := config.Set(path, flags.Arg(0), flags.Arg(1)); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Set %s in %s\n", flags.Arg(0), path)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown config subcommand %q, expected list, get or set", args[0])
+	}
+}
+
+// printSettings prints settings as TOML assignments along with the file setting them
+func printSettings(settings []config.Setting) {
+	for _, s := range settings {
+		origin := s.Origin
+		if origin == "" {
+			origin = "default"
+		}
+		fmt.Printf("%s = %s  # %s\n", s.Key, config.FormatValue(s.Value), origin)
+	}
+}
+
+// warnInvalid reports on the standard error every invalid setting or file
+// config.Load left out, joined in err
+func warnInvalid(err error) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			warnInvalid(err)
+		}
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring invalid configuration: %v\n", err)
+	}
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	user := filepath.Join(home, "greeter", "config.toml")
+
+	steps := []struct {
+		args []string
+		// want is part of the output, err part of the error expected
+		want string
+		err  string
+	}{
+		{args: []string{"get", "log.level"}, want: "info\n"},
+		{args: []string{"get", "language"}, err: "language is not set"},
+		{args: []string{"set", "log.level", "debug"}},
+		{args: []string{"set", "plugins.hindi.timeout", "2s"}},
+		{args: []string{"set", "plugins.hindi.start_timeout", "30s"}},
+		{args: []string{"set", "log.level", "loud"}, err: "unknown log level"},
+		{args: []string{"get", "log.level"}, want: "debug\n"},
+		{args: []string{"get", "plugins.hindi.timeout"}, want: "2s\n"},
+		{args: []string{"get", "plugins.hindi"}, want: "plugins.hindi.start_timeout = \"30s\"  # " + user + "\nplugins.hindi.timeout = \"2s\"  # " + user + "\n"},
+		{args: []string{"list"}, want: "log.level = \"debug\"  # " + user + "\nplugins.hindi.start_timeout = \"30s\""},
+		{args: []string{"set", "--system", "--config=x.toml", "language", "hindi"}, err: "mutually exclusive"},
+		{args: []string{"set", "language"}, err: "usage"},
+		{args: []string{"remove", "language"}, err: "unknown config subcommand"},
+	}
+	for _, step := range steps {
+		var err error
+		out := captureStdout(t, func() {
+			err = Config(step.args)
+		})
+		if step.err != "" {
+			if err == nil || !strings.Contains(err.Error(), step.err) {
+				t.Errorf("config %v: got %v, want an error containing %q", step.args, err, step.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("config %v: %v", step.args, err)
+		}
+		if !strings.Contains(out, step.want) {
+			t.Errorf("config %v printed %q, want %q", step.args, out, step.want)
+		}
+	}
+
+	data, err := os.ReadFile(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "[log]\nlevel = \"debug\"\n\n[plugins.hindi]\ntimeout = \"2s\"\nstart_timeout = \"30s\"\n"
+	if string(data) != want {
+		t.Errorf("user configuration:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestConfigSetFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "extra.toml")
+
+	if err := Config([]string{"set", "--config=" + path, "language", "japanese"}); err != nil {
+		t.Fatal(err)
+	}
+	out := captureStdout(t, func() {
+		if err := Config([]string{"get", "--config=" + path, "language"}); err != nil {
+			t.Error(err)
+		}
+	})
+	if out != "japanese\n" {
+		t.Errorf("language = %q, want japanese", out)
+	}
+}
+
+func TestConfigInvalidSetting(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "extra.toml")
+	content := "language = \"japanese\"\n\n[log]\nlevel = \"loud\"\n\n[output]\ndetails = true\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"list", "--config=" + path}, "language = \"japanese\"  # " + path + "\noutput.details = true  # " + path + "\n"},
+		{[]string{"get", "--config=" + path, "language"}, "japanese\n"},
+		// The invalid setting falls back to its default
+		{[]string{"get", "--config=" + path, "log.level"}, "info\n"},
+	}
+	for _, tt := range tests {
+		var out string
+		warnings := captureStderr(t, func() {
+			out = captureStdout(t, func() {
+				if err := Config(tt.args); err != nil {
+					t.Errorf("config %v: %v", tt.args, err)
+				}
+			})
+		})
+		if out != tt.want {
+			t.Errorf("config %v printed %q, want %q", tt.args, out, tt.want)
+		}
+		if want := path + ":4: log.level: "; !strings.Contains(warnings, want) {
+			t.Errorf("config %v warned %q, want %q reported", tt.args, warnings, want)
+		}
+	}
+}
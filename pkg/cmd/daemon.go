@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/unsuman/greeter/pkg/config"
+	"github.com/unsuman/greeter/pkg/daemon"
+	"github.com/unsuman/greeter/pkg/plugin"
+	"github.com/unsuman/greeter/pkg/version"
+)
+
+// daemonDialTimeout bounds the wait for greeterd to answer before plugins are started in-process
+const daemonDialTimeout = time.Second
+
+// DaemonSocket returns the socket of greeterd, the configured one or daemon.SocketPath
+func DaemonSocket(cfg *config.Config) string {
+	if cfg.Daemon.Socket != "" {
+		return cfg.Daemon.Socket
+	}
+	return daemon.SocketPath()
+}
+
+// UseDaemon makes the plugin manager use the plugins kept running by
+// greeterd. The daemon is skipped when it is disabled, not running, of
+// another release, searching plugins in other directories or not enforcing
+// the signatures, limits and sandboxes the manager would, the plugins being
+// started in-process instead.
+func UseDaemon(logger *logrus.Logger, pluginMgr *plugin.PluginManager, cfg *config.Config) {
+	if cfg.Daemon.Disabled {
+		logger.Debug("greeterd is disabled by the configuration")
+		return
+	}
+
+	socket := DaemonSocket(cfg)
+	client, err := daemon.Dial(socket, daemonDialTimeout)
+	var unsafe *daemon.UnsafeDirError
+	switch {
+	case errors.As(err, &unsafe):
+		logger.Warnf("Not using greeterd: %v", err)
+		return
+	case err != nil:
+		logger.Debugf("Not using greeterd: %v", err)
+		return
+	}
+
+	status := client.Status
+	var searchPath []string
+	for _, dir := range pluginMgr.SearchPath() {
+		searchPath = append(searchPath, dir.Path)
+	}
+	switch {
+	case status.GetVersion() != version.Version:
+		logger.Debugf("Not using greeterd %s at %s, this is greeter %s", status.GetVersion(), socket, version.Version)
+	case !slices.Equal(status.GetSearchPath(), searchPath):
+		logger.Debugf("Not using greeterd at %s, it searches plugins in %v", socket, status.GetSearchPath())
+	case !client.Policy().Equal(pluginMgr.Policy()):
+		logger.Warnf("Not using greeterd at %s, it checks signatures or confines plugins differently, see its configuration", socket)
+	default:
+		logger.Infof("Using greeterd at %s (pid %d)", socket, status.GetPid())
+		pluginMgr.SetRemote(client)
+		return
+	}
+	client.Close()
+}
+
+// ShutdownPlugin stops the plugin of a language kept running by greeterd
+func ShutdownPlugin(logger *logrus.Logger, pluginMgr *plugin.PluginManager, language string) error {
+	if pluginMgr.Remote() == nil {
+		return errors.New("greeterd is not running, plugins only run while greeter does")
+	}
+
+	language, err := ResolveLanguage(logger, pluginMgr, language)
+	if err != nil {
+		return err
+	}
+	if _, _, err := pluginMgr.Locate("lang", language); err != nil {
+		return err
+	}
+
+	logger.Infof("Stopping plugin %s of greeterd", language)
+	return pluginMgr.StopPlugin("lang", language)
+}
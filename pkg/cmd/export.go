@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"github.com/unsuman/greeter/pkg/export"
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin"
+	"github.com/unsuman/greeter/pkg/plugin/registry"
+)
+
+// Export writes the greetings of every language, or of --lang only, in the
+// --format format: one catalog per language in the --out directory, or a
+// single translation matrix on stdout
+func Export(logger *logrus.Logger, pluginMgr *plugin.PluginManager, opts *Options) error {
+	if err := export.CheckFormat(opts.ExportFormat); err != nil {
+		return err
+	}
+
+	var catalogs []*export.Catalog
+	if opts.Language != "" {
+		language, err := ResolveLanguage(logger, pluginMgr, opts.Language)
+		if err != nil {
+			return err
+		}
+		c, err := collectCatalog(pluginMgr, language)
+		if err != nil {
+			return err
+		}
+		catalogs = append(catalogs, c)
+	} else {
+		catalogs = collectCatalogs(logger, pluginMgr)
+	}
+
+	// Translations are exported against the English text
+	source, err := collectCatalog(pluginMgr, FallbackLanguage)
+	if err != nil {
+		logger.Warnf("Exporting without source text: %v", err)
+		source = nil
+	}
+
+	if opts.OutputDir == "" {
+		if len(catalogs) == 1 {
+			return export.WriteCatalog(os.Stdout, opts.ExportFormat, catalogs[0], source)
+		}
+		return export.WriteMatrix(os.Stdout, opts.ExportFormat, catalogs, source)
+	}
+
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+	for _, c := range catalogs {
+		path := filepath.Join(opts.OutputDir, c.Info.Name+export.Extension(opts.ExportFormat))
+		if err := writeCatalogFile(path, opts.ExportFormat, c, source); err != nil {
+			return err
+		}
+		fmt.Printf("Exported %s to %s\n", c.Info.Name, path)
+	}
+
+	return nil
+}
+
+func writeCatalogFile(path, format string, c, source *export.Catalog) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+
+	if err := export.WriteCatalog(f, format, c, source); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// collectCatalogs collects the greetings of the built-in languages followed
+// by the external plugins. Languages that fail are logged and skipped.
+func collectCatalogs(logger *logrus.Logger, pluginMgr *plugin.PluginManager) []*export.Catalog {
+	names := registry.DefaultRegistry.List()
+
+	external, err := pluginMgr.DiscoverPlugins("lang")
+	if err != nil {
+		logger.Errorf("Failed to discover language plugins: %v", err)
+	}
+	for _, name := range external {
+		// Built-in languages take precedence, as for greetings
+		if _, exists := registry.DefaultRegistry.Get(name); !exists {
+			names = append(names, name)
+		}
+	}
+
+	var catalogs []*export.Catalog
+	for _, name := range names {
+		c, err := collectCatalog(pluginMgr, name)
+		if err != nil {
+			logger.Warnf("Skipping %s: %v", name, err)
+			continue
+		}
+		catalogs = append(catalogs, c)
+	}
+	return catalogs
+}
+
+// collectCatalog collects the greetings of a built-in or external language
+func collectCatalog(pluginMgr *plugin.PluginManager, language string) (*export.Catalog, error) {
+	if plugin, exists := registry.DefaultRegistry.Get(language); exists {
+		return export.Collect(greetings.Describe(plugin), func(req greetings.Request) ([]greetings.Variant, error) {
+			return greetings.VariantsOf(plugin, req)
+		})
+	}
+
+	ctx := context.Background()
+	info, err := pluginMgr.PluginInfo(ctx, "lang", language)
+	if err != nil {
+		return nil, err
+	}
+	return export.Collect(*info, func(req greetings.Request) ([]greetings.Variant, error) {
+		return pluginMgr.GetVariants(ctx, "lang", language, req)
+	})
+}
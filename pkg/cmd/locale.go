@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/unsuman/greeter/pkg/config"
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/locale"
+	"github.com/unsuman/greeter/pkg/plugin"
+	"github.com/unsuman/greeter/pkg/plugin/registry"
+)
+
+// FallbackLanguage is used when neither the environment nor the configuration select a language
+const FallbackLanguage = "english"
+
+// NewLocaleResolver builds a resolver over the built-in and external language plugins.
+// Built-in plugins use the locale they declare, external ones are tagged by name
+// so that discovering them does not require starting them.
+func NewLocaleResolver(logger *logrus.Logger, pluginMgr *plugin.PluginManager) *locale.Resolver {
+	var candidates []locale.Candidate
+
+	for _, name := range registry.DefaultRegistry.List() {
+		p, _ := registry.DefaultRegistry.Get(name)
+		tag, ok := locale.TagFor(name, greetings.Describe(p).Locale)
+		if !ok {
+			logger.Debugf("No locale known for built-in language %s", name)
+		}
+		candidates = append(candidates, locale.Candidate{Name: name, Tag: tag})
+	}
+
+	external, err := pluginMgr.DiscoverPlugins("lang")
+	if err != nil {
+		logger.Warnf("Failed to discover language plugins: %v", err)
+	}
+	for _, name := range external {
+		if _, exists := registry.DefaultRegistry.Get(name); exists {
+			continue
+		}
+		tag, ok := locale.TagFor(name, "")
+		if !ok {
+			logger.Debugf("No locale known for language plugin %s", name)
+		}
+		candidates = append(candidates, locale.Candidate{Name: name, Tag: tag})
+	}
+
+	return locale.NewResolver(candidates)
+}
+
+// ResolveLanguage maps a requested language name or BCP 47 tag to an available language plugin
+func ResolveLanguage(logger *logrus.Logger, pluginMgr *plugin.PluginManager, requested string) (string, error) {
+	name, err := NewLocaleResolver(logger, pluginMgr).Resolve(requested)
+	if err != nil {
+		return "", err
+	}
+
+	if name != requested {
+		logger.Debugf("Resolved language %q to %s", requested, name)
+	}
+	return name, nil
+}
+
+// DefaultLanguage picks the language used when --lang is not given: the first
+// available language among the locale environment variables (see
+// locale.FromEnvironment), then the language configuration setting, then
+// FallbackLanguage. Every step is logged at debug level.
+func DefaultLanguage(logger *logrus.Logger, pluginMgr *plugin.PluginManager, cfg *config.Config) string {
+	resolver := NewLocaleResolver(logger, pluginMgr)
+
+	prefs := locale.FromEnvironment(os.Getenv)
+	if len(prefs) == 0 {
+		logger.Debug("No locale set in LANGUAGE, LC_ALL, LC_MESSAGES or LANG")
+	}
+	for _, pref := range prefs {
+		name, err := resolver.Resolve(pref.Locale)
+		if err != nil {
+			logger.Debugf("Ignoring %s=%s: %v", pref.Source, pref.Locale, err)
+			continue
+		}
+		logger.Debugf("Using language %s from %s=%s", name, pref.Source, pref.Locale)
+		return name
+	}
+
+	if cfg != nil && cfg.Language != "" {
+		name, err := resolver.Resolve(cfg.Language)
+		if err == nil {
+			logger.Debugf("Using language %s from the language configuration setting", name)
+			return name
+		}
+		logger.Debugf("Ignoring language configuration setting %q: %v", cfg.Language, err)
+	}
+
+	logger.Debugf("No language selected by the environment or configuration, using %s", FallbackLanguage)
+	return FallbackLanguage
+}
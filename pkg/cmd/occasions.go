@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/unsuman/greeter/pkg/calendar"
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin"
+)
+
+// occasionOn returns the occasion of the language observed on the date of t, if any
+func occasionOn(logger *logrus.Logger, info *greetings.Info, t time.Time) (calendar.Observance, bool) {
+	observance, ok, err := calendar.On(info.Occasions, t)
+	if err != nil {
+		logger.Warnf("Ignoring invalid occasions of %s: %v", info.Name, err)
+	}
+	return observance, ok
+}
+
+// ListOccasions lists the occasions of a language in the year starting on
+// the --date day, or today, with their greetings
+func ListOccasions(logger *logrus.Logger, pluginMgr *plugin.PluginManager, language string, opts *Options) error {
+	from, err := opts.Time()
+	if err != nil {
+		return err
+	}
+
+	language, err = ResolveLanguage(logger, pluginMgr, language)
+	if err != nil {
+		return err
+	}
+
+	info, err := getLanguageInfo(logger, pluginMgr, language)
+	if err != nil {
+		return err
+	}
+
+	observances, err := calendar.Observances(info.Occasions, from, from.AddDate(1, 0, -1))
+	if err != nil {
+		logger.Warnf("Ignoring invalid occasions of %s: %v", language, err)
+	}
+	if len(observances) == 0 {
+		fmt.Printf("No occasions known for %s\n", info.DisplayName)
+		return nil
+	}
+
+	fmt.Printf("Upcoming occasions for %s:\n", info.DisplayName)
+	for _, o := range observances {
+		when := o.Start.Format(time.DateOnly)
+		if o.Duration() > 1 {
+			when += " to " + o.Start.AddDate(0, 0, o.Duration()-1).Format(time.DateOnly)
+		}
+		if o.Includes(from) {
+			when += " (today)"
+		}
+
+		fmt.Printf("- %s: %s (%s)\n", when, o.Name, o.Kind)
+
+		greeting, err := GetGreeting(logger, pluginMgr, language, opts.Request(o.Kind))
+		if err != nil {
+			logger.Warnf("Failed to get the %s greeting: %v", o.Kind, err)
+			continue
+		}
+		fmt.Printf("    %s\n", opts.Format(greeting))
+	}
+
+	return nil
+}
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	_ "time/tzdata" // --tz must work on systems without a zoneinfo database
+
+	"github.com/unsuman/greeter/pkg/config"
+	"github.com/unsuman/greeter/pkg/greetings"
+)
+
+// Options holds the flags accepted by the greeting commands
+type Options struct {
+	// Language is empty when --lang is not given, see DefaultLanguage
+	Language  string
+	Recipient string
+	Formality greetings.Formality
+	// TimeZone is the IANA name of the recipient's time zone, used by greet
+	TimeZone string
+	// At overrides the current time used by greet, as HH:MM or RFC 3339
+	At string
+	// Date overrides the current date used by greet and occasions, as YYYY-MM-DD
+	Date string
+	// NativeOnly prints the greeting in its native script only
+	NativeOnly bool
+	// RomanizedOnly prints the greeting in the Latin alphabet only
+	RomanizedOnly bool
+	// Details prints the pronunciation and literal meaning along with the greeting
+	Details bool
+	// Variant selects how greetings with several variants are picked:
+	// VariantFirst, VariantRandom or VariantRotate
+	Variant string
+	// Seed makes VariantRandom reproducible, Seeded tells whether it was given
+	Seed   int64
+	Seeded bool
+	// ExportFormat is the file format written by export
+	ExportFormat string
+	// OutputDir is the directory export writes one catalog per language to,
+	// empty to print a single catalog or translation matrix
+	OutputDir string
+	// PluginDirs are the --plugin-dir flags, searched for plugins before any other directory
+	PluginDirs []string
+	// ConfigFile is a configuration file overriding the system and user ones
+	ConfigFile string
+	// Color is auto, always or never, see the output.color setting
+	Color string
+	// NoDaemon starts plugins in-process even when greeterd is running
+	NoDaemon bool
+	// Debug enables debug logging
+	Debug bool
+
+	// given records the flags given on the command line
+	given map[string]bool
+}
+
+// stringList is a flag that may be given several times
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// ParseOptions parses the flags following the command name
+func ParseOptions(args []string) (*Options, error) {
+	opts := &Options{Color: "auto", given: make(map[string]bool)}
+	var formality string
+
+	flags := flag.NewFlagSet("greeter", flag.ContinueOnError)
+	flags.SetOutput(io.Discard) // errors are reported by the caller along with the usage
+	flags.StringVar(&opts.Language, "lang", "", "language of the greeting, defaults to the one of the environment")
+	flags.StringVar(&opts.Recipient, "to", "", "name of the person to greet")
+	flags.StringVar(&formality, "formality", "neutral", "politeness register: casual, neutral, formal or honorific")
+	flags.StringVar(&opts.TimeZone, "tz", "", "time zone of the person to greet, e.g. Asia/Tokyo")
+	flags.StringVar(&opts.At, "at", "", "time of the greeting as HH:MM or RFC 3339 instead of now")
+	flags.StringVar(&opts.Date, "date", "", "date of the greeting as YYYY-MM-DD instead of today")
+	flags.StringVar(&opts.Variant, "variant", "", "how to pick among greeting variants: first, random or rotate")
+	flags.Int64Var(&opts.Seed, "seed", 0, "seed making --variant=random reproducible, implies it")
+	flags.StringVar(&opts.ExportFormat, "format", "json", "export format: po, xliff, arb, json or csv")
+	flags.StringVar(&opts.OutputDir, "out", "", "directory to export one catalog per language to")
+	flags.Var((*stringList)(&opts.PluginDirs), "plugin-dir", "directory to search for plugins first, may be repeated")
+	flags.StringVar(&opts.ConfigFile, "config", "", "configuration file overriding the system and user ones")
+
+	flags.BoolVar(&opts.NativeOnly, "native-only", false, "print the greeting in its native script only")
+	flags.BoolVar(&opts.RomanizedOnly, "romanized-only", false, "print the greeting in the Latin alphabet only")
+	flags.BoolVar(&opts.Details, "details", false, "print pronunciation and literal meaning")
+	flags.BoolVar(&opts.NoDaemon, "no-daemon", false, "start plugins instead of using greeterd")
+	flags.BoolVar(&opts.Debug, "debug", false, "enable debug logging")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		opts.given[f.Name] = true
+	})
+	opts.Seeded = opts.given["seed"]
+
+	switch opts.Variant {
+	case "":
+		opts.Variant = VariantFirst
+		if opts.Seeded {
+			opts.Variant = VariantRandom
+		}
+	case VariantFirst, VariantRandom, VariantRotate:
+		if opts.Seeded && opts.Variant != VariantRandom {
+			return nil, fmt.Errorf("--seed only applies to --variant=random")
+		}
+	default:
+		return nil, fmt.Errorf("unknown variant selection %q (expected first, random or rotate)", opts.Variant)
+	}
+
+	if opts.NativeOnly && opts.RomanizedOnly {
+		return nil, fmt.Errorf("--native-only and --romanized-only are mutually exclusive")
+	}
+
+	f, err := greetings.ParseFormality(formality)
+	if err != nil {
+		return nil, err
+	}
+	opts.Formality = f
+
+	return opts, nil
+}
+
+// ApplyConfig fills the output options not given on the command line from the configuration
+func (o *Options) ApplyConfig(cfg *config.Config) {
+	if !o.NativeOnly && !o.RomanizedOnly {
+		switch cfg.Output.Format {
+		case "native":
+			o.NativeOnly = true
+		case "romanized":
+			o.RomanizedOnly = true
+		}
+	}
+	if !o.given["details"] {
+		o.Details = cfg.Output.Details
+	}
+	if cfg.Output.Color != "" {
+		o.Color = cfg.Output.Color
+	}
+}
+
+// Colorize highlights a greeting for the terminal as selected by Color.
+// auto colors the output of terminals unless NO_COLOR is set.
+func (o *Options) Colorize(text string) string {
+	switch o.Color {
+	case "never":
+		return text
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return text
+		}
+		if fi, err := os.Stdout.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+			return text
+		}
+	}
+	return "\033[92m" + text + "\033[0m"
+}
+
+// Request builds the request for a greeting of the given kind
+func (o *Options) Request(kind string) greetings.Request {
+	return greetings.Request{
+		Kind:      kind,
+		Recipient: o.Recipient,
+		Formality: o.Formality,
+	}
+}
+
+// Time returns the moment to greet for: --at if given, otherwise now,
+// expressed in the --tz time zone or the local one, on the --date day if given
+func (o *Options) Time() (time.Time, error) {
+	location := time.Local
+	if o.TimeZone != "" {
+		loc, err := time.LoadLocation(o.TimeZone)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time zone %q: %w", o.TimeZone, err)
+		}
+		location = loc
+	}
+
+	t := time.Now().In(location)
+	if o.At != "" {
+		if at, err := time.Parse(time.RFC3339, o.At); err == nil {
+			// A full timestamp names its own instant, convert it to the recipient's zone
+			t = at.In(location)
+		} else {
+			// A wall clock time is taken as today's time in the recipient's zone
+			clock, err := time.Parse("15:04", o.At)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid time %q, expected HH:MM or RFC 3339", o.At)
+			}
+			t = time.Date(t.Year(), t.Month(), t.Day(), clock.Hour(), clock.Minute(), 0, 0, location)
+		}
+	}
+
+	if o.Date != "" {
+		day, err := time.Parse(time.DateOnly, o.Date)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", o.Date)
+		}
+		t = time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, location)
+	}
+
+	return t, nil
+}
+
+// Format renders a greeting for display according to the output flags
+func (o *Options) Format(g greetings.Greeting) string {
+	var text string
+	switch {
+	case o.NativeOnly:
+		text = g.Text
+	case o.RomanizedOnly:
+		text = g.Romanized()
+	default:
+		text = g.String()
+	}
+
+	if !o.Details {
+		return text
+	}
+
+	if g.Script != "" {
+		text += "\n  script:        " + g.Script
+	}
+	if g.Pronunciation != "" {
+		text += "\n  pronunciation: /" + g.Pronunciation + "/"
+	}
+	if g.Meaning != "" {
+		text += "\n  meaning:       \"" + g.Meaning + "\""
+	}
+	return text
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/unsuman/greeter/pkg/config"
+	"github.com/unsuman/greeter/pkg/daemon"
+	"github.com/unsuman/greeter/pkg/plugin"
+	"github.com/unsuman/greeter/pkg/plugin/signing"
+)
+
+// breakerFile is the state file keeping the crash history and quarantine of plugins
+const breakerFile = "plugins.json"
+
+// Plugin runs the plugin maintenance subcommands:
+//
+//	greeter plugin sign --key=release.pem <plugin binary>...
+//	greeter plugin status
+//	greeter plugin release <plugin>...
+func Plugin(logger *logrus.Logger, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing plugin subcommand, expected sign, status or release")
+	}
+
+	switch args[0] {
+	case "sign":
+		return signPlugins(logger, args[1:])
+	case "status":
+		return pluginStatus(logger, args[1:])
+	case "release":
+		return releasePlugins(logger, args[1:])
+	default:
+		return fmt.Errorf("unknown plugin subcommand %q, expected sign, status or release", args[0])
+	}
+}
+
+// breakerStore opens the crash history shared by greeter and greeterd
+func breakerStore(logger *logrus.Logger) (*plugin.BreakerStore, error) {
+	path, err := statePath(breakerFile)
+	if err != nil {
+		return nil, err
+	}
+	return plugin.NewBreakerStore(path, logger), nil
+}
+
+// pluginStatus prints the instance pools of the plugins greeterd runs, and
+// the plugins that crashed recently or are quarantined
+func pluginStatus(logger *logrus.Logger, args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: greeter plugin status")
+	}
+	cfg, _ := config.Load("")
+	printPools(cfg)
+
+	store, err := breakerStore(logger)
+	if err != nil {
+		return err
+	}
+
+	states := store.States()
+	keys := make([]string, 0, len(states))
+	for key := range states {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	now := time.Now()
+	listed := 0
+	for _, key := range keys {
+		state := states[key]
+		_, name, _ := strings.Cut(key, "/")
+		recent := state.Recent(now, restartPolicy(cfg.Plugin(name)))
+
+		switch {
+		case state.Quarantined(now):
+			fmt.Printf("%s: quarantined until %s, %d crashes", key, state.QuarantinedUntil.Format(time.DateTime), len(state.Crashes))
+		case len(recent.Crashes) > 0:
+			fmt.Printf("%s: %d crashes, last at %s", key, len(recent.Crashes), recent.Crashes[len(recent.Crashes)-1].Format(time.DateTime))
+		default:
+			// Crashes older than the window no longer count
+			continue
+		}
+		fmt.Printf(" (last: %s)\n", state.LastError)
+		listed++
+	}
+	if listed == 0 {
+		fmt.Println("No plugin crashed recently")
+	}
+	return nil
+}
+
+// printPools prints the instance pools of the plugins of greeterd, if it is running
+func printPools(cfg *config.Config) {
+	client, err := daemon.Dial(DaemonSocket(cfg), daemonDialTimeout)
+	if err != nil {
+		return
+	}
+	defer client.Close()
+
+	for _, pool := range client.Status.GetPools() {
+		fmt.Printf("%s/%s: %d of %d-%d instances", pool.GetPlugin().GetCategory(), pool.GetPlugin().GetName(), pool.GetInstances(), pool.GetMin(), pool.GetMax())
+		if pool.GetStarting() > 0 {
+			fmt.Printf(" (+%d starting)", pool.GetStarting())
+		}
+		fmt.Printf(", %d busy, %d calls in progress, %d served, %d queued, scaled up %d and down %d times\n",
+			pool.GetBusy(), pool.GetInFlight(), pool.GetCalls(), pool.GetQueued(), pool.GetScaleUps(), pool.GetScaleDowns())
+	}
+}
+
+// releasePlugins forgets the crashes of plugins, lifting their quarantine.
+// Plugins are given by name for language plugins, or as category/name.
+func releasePlugins(logger *logrus.Logger, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: greeter plugin release <plugin>...")
+	}
+	store, err := breakerStore(logger)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range args {
+		key := name
+		if !strings.Contains(key, "/") {
+			key = "lang/" + name
+		}
+		released, err := store.Release(key)
+		if err != nil {
+			return fmt.Errorf("failed to release %s: %w", name, err)
+		}
+		if !released {
+			logger.Warnf("Plugin %s has no recorded crash", key)
+			continue
+		}
+		logger.Infof("Released plugin %s", key)
+	}
+	return nil
+}
+
+// signPlugins writes the signature of every plugin binary given on the command line
+func signPlugins(logger *logrus.Logger, args []string) error {
+	flags := flag.NewFlagSet("greeter plugin sign", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	keyPath := flags.String("key", "", "PEM ed25519 private key to sign with")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *keyPath == "" {
+		return errors.New("--key is required")
+	}
+	if flags.NArg() == 0 {
+		return errors.New("no plugin binary to sign")
+	}
+
+	data, err := os.ReadFile(*keyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read signing key: %w", err)
+	}
+	key, err := signing.ParsePrivateKey(data)
+	if err != nil {
+		return fmt.Errorf("signing key %s: %w", *keyPath, err)
+	}
+
+	for _, binary := range flags.Args() {
+		if err := signing.Sign(key, binary); err != nil {
+			return fmt.Errorf("failed to sign %s: %w", binary, err)
+		}
+		logger.Infof("Signed %s into %s", binary, signing.Path(binary))
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/unsuman/greeter/pkg/plugin"
+)
+
+// captureStdout returns what fn prints on the standard output
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	return capture(t, &os.Stdout, fn)
+}
+
+// captureStderr returns what fn prints on the standard error
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	return capture(t, &os.Stderr, fn)
+}
+
+// capture returns what fn writes to file
+func capture(t *testing.T, file **os.File, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := *file
+	*file = w
+	defer func() { *file = saved }()
+
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+	fn()
+	w.Close()
+	return <-out
+}
+
+func TestPluginStatus(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", state)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	now := time.Now()
+	states := map[string]plugin.BreakerState{
+		// Edited by hand, or written by another version
+		"lang/edited": {QuarantinedUntil: now.Add(time.Hour), LastError: "edited"},
+		"lang/empty":  {LastError: "empty"},
+		"lang/old":    {Crashes: []time.Time{now.Add(-2 * time.Hour)}, LastError: "old"},
+		"lang/recent": {Crashes: []time.Time{now.Add(-2 * time.Hour), now.Add(-time.Second)}, LastError: "recent"},
+	}
+	if err := writeStates(filepath.Join(state, "greeter", breakerFile), states); err != nil {
+		t.Fatal(err)
+	}
+
+	logger, _ := logtest.NewNullLogger()
+	out := captureStdout(t, func() {
+		if err := pluginStatus(logger, nil); err != nil {
+			t.Errorf("pluginStatus: %v", err)
+		}
+	})
+
+	for _, want := range []string{"lang/edited: quarantined until", "lang/recent: 1 crashes"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("status lacks %q:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"lang/empty", "lang/old", "No plugin crashed recently"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("status lists %q:\n%s", unwanted, out)
+		}
+	}
+}
+
+func TestPluginStatusNoCrash(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	logger, _ := logtest.NewNullLogger()
+	out := captureStdout(t, func() {
+		if err := pluginStatus(logger, nil); err != nil {
+			t.Errorf("pluginStatus: %v", err)
+		}
+	})
+	if !strings.Contains(out, "No plugin crashed recently") {
+		t.Errorf("status = %q", out)
+	}
+}
+
+// writeStates replaces the crash history at path
+func writeStates(path string, states map[string]plugin.BreakerState) error {
+	data, err := json.Marshal(states)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
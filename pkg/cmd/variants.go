@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin"
+	"github.com/unsuman/greeter/pkg/plugin/registry"
+	"github.com/unsuman/greeter/pkg/statefile"
+)
+
+// Variant selection modes of the --variant flag
+const (
+	// VariantFirst always picks the default variant, the one Greet returns
+	VariantFirst = "first"
+	// VariantRandom picks a variant at random in proportion to the weights,
+	// reproducibly when --seed is given
+	VariantRandom = "random"
+	// VariantRotate cycles through the variants from one run to the next
+	VariantRotate = "rotate"
+)
+
+// GetVariants gets the variants of a greeting from either an internal or external plugin
+func GetVariants(logger *logrus.Logger, pluginMgr *plugin.PluginManager, language string, req greetings.Request) ([]greetings.Variant, error) {
+	language, err := ResolveLanguage(logger, pluginMgr, language)
+	if err != nil {
+		return nil, err
+	}
+	return getVariants(logger, pluginMgr, language, req)
+}
+
+// getVariants gets the variants of a greeting in a resolved language
+func getVariants(logger *logrus.Logger, pluginMgr *plugin.PluginManager, language string, req greetings.Request) ([]greetings.Variant, error) {
+	if plugin, exists := registry.DefaultRegistry.Get(language); exists {
+		logger.Debugf("Using embedded plugin for language: %s", language)
+		return greetings.VariantsOf(plugin, req)
+	}
+
+	if _, _, err := pluginMgr.Locate("lang", language); err != nil {
+		return nil, err
+	}
+
+	variants, err := pluginMgr.GetVariants(context.Background(), "lang", language, req)
+	if err != nil {
+		stopBrokenPlugin(logger, pluginMgr, language, err)
+		return nil, err
+	}
+	return variants, nil
+}
+
+// PickGreeting gets a greeting and picks one of its variants as selected by
+// the --variant and --seed options
+func PickGreeting(logger *logrus.Logger, pluginMgr *plugin.PluginManager, language string, opts *Options, kind string) (greetings.Greeting, error) {
+	language, err := ResolveLanguage(logger, pluginMgr, language)
+	if err != nil {
+		return greetings.Greeting{}, err
+	}
+	return pickGreeting(logger, pluginMgr, language, opts, kind)
+}
+
+// pickGreeting picks a variant of a greeting in a resolved language, see PickGreeting
+func pickGreeting(logger *logrus.Logger, pluginMgr *plugin.PluginManager, language string, opts *Options, kind string) (greetings.Greeting, error) {
+	req := opts.Request(kind)
+	variants, err := getVariants(logger, pluginMgr, language, req)
+	if err != nil {
+		return greetings.Greeting{}, err
+	}
+	if len(variants) == 0 {
+		return greetings.Greeting{}, fmt.Errorf("%s greeting of %s: %w", kind, language, greetings.ErrNoVariants)
+	}
+	if len(variants) == 1 {
+		return variants[0].Greeting, nil
+	}
+
+	switch opts.Variant {
+	case VariantRandom:
+		seed := rand.Uint64()
+		if opts.Seeded {
+			seed = uint64(opts.Seed)
+		}
+		logger.Debugf("Picking one of %d variants with seed %d", len(variants), seed)
+		picked, err := greetings.PickRandom(variants, rand.New(rand.NewPCG(seed, seed)))
+		return picked.Greeting, err
+
+	case VariantRotate:
+		key := fmt.Sprintf("%s/%s/%s", language, kind, req.Formality)
+		n, err := nextRotation(key)
+		if err != nil {
+			logger.Warnf("Failed to record variant rotation: %v", err)
+		}
+		logger.Debugf("Picking variant %d of %s in rotation", n, key)
+		picked, err := greetings.PickRotating(variants, n)
+		return picked.Greeting, err
+
+	default:
+		return variants[0].Greeting, nil
+	}
+}
+
+// rotationPath returns the file keeping the rotation counters,
+// $XDG_STATE_HOME/greeter/rotation.json or ~/.local/state/greeter/rotation.json
+func rotationPath() (string, error) {
+	return statePath("rotation.json")
+}
+
+// statePath returns the path of a state file, in $XDG_STATE_HOME/greeter
+// or ~/.local/state/greeter
+func statePath(name string) (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "greeter", name), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "state", "greeter", name), nil
+}
+
+// nextRotation returns how many greetings were already picked in rotation
+// for key and records one more. The count is 0 when it cannot be read.
+func nextRotation(key string) (int, error) {
+	path, err := rotationPath()
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	record := func() error {
+		counters := make(map[string]int)
+		return statefile.Update(path, &counters, func() error {
+			n = counters[key]
+			counters[key] = n + 1
+			return nil
+		})
+	}
+
+	err = record()
+	if errors.Is(err, statefile.ErrInvalid) {
+		// A corrupt file restarts every rotation
+		if err = os.Remove(path); err == nil {
+			err = record()
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,561 @@
+// Package config loads the greeter configuration files.
+//
+// Settings are read from up to three TOML files, each overriding the
+// settings of the previous ones:
+//
+//  1. the system configuration, /etc/greeter/config.toml
+//  2. the user configuration, see UserPath
+//  3. the file given with --config
+//
+// A file that is missing is skipped; the invalid settings of a file are
+// ignored and reported with their line, and a file that is not valid TOML
+// is ignored as a whole. Either way, a file configuring signing that is not
+// read in full is reported in SigningErr, so that plugins are refused rather
+// than run unverified.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/unsuman/greeter/pkg/plugin/limits"
+)
+
+// SystemPath is the path of the system configuration file
+const SystemPath = "/etc/greeter/config.toml"
+
+// Config holds the settings read from the configuration files
+type Config struct {
+	// Language is the default language, used when neither --lang nor the
+	// locale environment variables select an available one
+	Language string `toml:"language"`
+
+	// PluginDirs lists directories searched for plugins, see searchpath.Build.
+	// Relative paths are resolved against the directory of the configuration file.
+	PluginDirs []string `toml:"plugin_dirs"`
+
+	// PluginCgroup is the cgroup v2 directory delegated to greeter, in which
+	// plugins with memory or cpu limits get a cgroup per process
+	PluginCgroup string `toml:"plugin_cgroup"`
+
+	// Log configures the diagnostics written to stderr
+	Log Log `toml:"log"`
+
+	// Output configures how greetings are printed
+	Output Output `toml:"output"`
+
+	// Plugins holds the settings of external plugins by plugin name
+	Plugins map[string]Plugin `toml:"plugins"`
+
+	// Signing configures the verification of plugin binaries before they are run
+	Signing Signing `toml:"signing"`
+
+	// Daemon configures greeterd and how the CLI reaches it
+	Daemon Daemon `toml:"daemon"`
+
+	// Files lists the configuration files that were read, lowest precedence first
+	Files []string `toml:"-"`
+	// SigningErr reports the files configuring signing with settings that
+	// were left out, nil if there are none
+	SigningErr error `toml:"-"`
+
+	// settings holds the merged settings, origins the file each one comes from
+	settings map[string]any
+	origins  map[string]string
+}
+
+// Log holds the logging settings:
+//
+//	[log]
+//	level = "warn"
+//	format = "json"
+type Log struct {
+	// Level is a logrus level: trace, debug, info, warn, error, fatal or panic
+	Level string `toml:"level"`
+	// Format is text or json
+	Format string `toml:"format"`
+}
+
+// Output holds the settings of the printed greetings, overridden by the
+// corresponding flags:
+//
+//	[output]
+//	format = "native"
+//	details = true
+//	color = "never"
+type Output struct {
+	// Format is both, native or romanized, see --native-only and --romanized-only
+	Format string `toml:"format"`
+	// Details prints the pronunciation and literal meaning, see --details
+	Details bool `toml:"details"`
+	// Color is auto, always or never. auto colors terminals unless NO_COLOR is set.
+	Color string `toml:"color"`
+}
+
+// Plugin holds the settings of an external plugin:
+//
+//	[plugins.hindi]
+//	start_timeout = "30s"
+//	timeout = "2s"
+//	env = { HINDI_DIALECT = "awadhi" }
+//	restart = "on-crash"
+//	max_restarts = 3
+//	keep_warm = true
+//	max_instances = 4
+type Plugin struct {
+	// StartTimeout bounds the time the plugin may take to become ready, as a Go duration
+	StartTimeout string `toml:"start_timeout"`
+	// Timeout bounds every call to the plugin, as a Go duration
+	Timeout string `toml:"timeout"`
+	// Env is added to the environment the plugin is started with
+	Env map[string]string `toml:"env"`
+
+	// Restart is on-crash, the default, or never
+	Restart string `toml:"restart"`
+	// RestartBackoff is the delay before the first restart, doubled on every
+	// further crash up to RestartMaxBackoff, as Go durations
+	RestartBackoff    string `toml:"restart_backoff"`
+	RestartMaxBackoff string `toml:"restart_max_backoff"`
+	// MaxRestarts crashes are tolerated within RestartWindow, the next one
+	// quarantines the plugin for Quarantine
+	MaxRestarts   int    `toml:"max_restarts"`
+	RestartWindow string `toml:"restart_window"`
+	Quarantine    string `toml:"quarantine"`
+
+	// IdleTimeout is how long the plugin may stay unused before it is
+	// stopped, as a Go duration, overriding daemon.idle_timeout
+	IdleTimeout string `toml:"idle_timeout"`
+	// KeepWarm keeps the plugin running however long it stays unused, and
+	// makes greeterd start it right away
+	KeepWarm bool `toml:"keep_warm"`
+
+	// MinInstances and MaxInstances bound the number of instances of the
+	// plugin run at once, 1 by default. Instances are added while every one
+	// is busy, and the ones beyond MinInstances are stopped once unused for
+	// ScaleDownAfter, as a Go duration.
+	MinInstances   int    `toml:"min_instances"`
+	MaxInstances   int    `toml:"max_instances"`
+	ScaleDownAfter string `toml:"scale_down_after"`
+
+	// Limits bounds the resources of every process of the plugin
+	Limits Limits `toml:"limits"`
+	// Sandbox confines every process of the plugin
+	Sandbox Sandbox `toml:"sandbox"`
+}
+
+// Sandbox holds the sandbox settings of a plugin, applied on Linux:
+//
+//	[plugins.hindi.sandbox]
+//	enabled = true
+//	read_paths = ["/usr/share/greeter/hindi"]
+//	required = true
+type Sandbox struct {
+	// Enabled runs the plugin in new namespaces, restricted by Landlock and seccomp
+	Enabled bool `toml:"enabled"`
+	// ReadPaths are the files and directories the plugin may read besides
+	// its binary. Relative paths are resolved against the directory of the
+	// configuration file.
+	ReadPaths []string `toml:"read_paths"`
+	// Required refuses to start the plugin when the kernel lacks a feature of
+	// the sandbox, rather than running it less confined
+	Required bool `toml:"required"`
+}
+
+// Limits holds the resource limits of the processes of a plugin, enforced
+// on Linux, unlimited when not set:
+//
+//	[plugins.hindi.limits]
+//	address_space = "4G"
+//	cpu_time = "10m"
+//	open_files = 256
+//	processes = 512
+//	memory = "256M"
+//	cpu = "50%"
+type Limits struct {
+	// AddressSpace bounds the virtual memory, as a size such as "4G"
+	AddressSpace string `toml:"address_space"`
+	// CPUTime bounds the CPU time, as a Go duration
+	CPUTime string `toml:"cpu_time"`
+	// OpenFiles bounds the file descriptors
+	OpenFiles int `toml:"open_files"`
+	// Processes bounds the processes and threads of the user running the plugin
+	Processes int `toml:"processes"`
+	// Memory and CPU are quotas enforced by a cgroup, see Config.PluginCgroup:
+	// a size, and a number of CPUs or a percentage of one
+	Memory string `toml:"memory"`
+	CPU    string `toml:"cpu"`
+}
+
+// Limits converts the settings to the limits of plugin processes. Invalid
+// settings, rejected when the configuration is loaded, are left unlimited.
+func (l Limits) Limits() limits.Limits {
+	addressSpace, _ := limits.ParseSize(l.AddressSpace)
+	memory, _ := limits.ParseSize(l.Memory)
+	cpu, _ := limits.ParseCPU(l.CPU)
+	return limits.Limits{
+		AddressSpace: addressSpace,
+		CPUTime:      duration(l.CPUTime),
+		OpenFiles:    uint64(max(l.OpenFiles, 0)),
+		Processes:    uint64(max(l.Processes, 0)),
+		Memory:       memory,
+		CPU:          cpu,
+	}
+}
+
+// StartTimeoutDuration returns the start timeout, 0 when not set
+func (p Plugin) StartTimeoutDuration() time.Duration {
+	return duration(p.StartTimeout)
+}
+
+// TimeoutDuration returns the call timeout, 0 when not set
+func (p Plugin) TimeoutDuration() time.Duration {
+	return duration(p.Timeout)
+}
+
+// IdleTimeoutDuration returns the idle timeout, 0 when not set
+func (p Plugin) IdleTimeoutDuration() time.Duration {
+	return duration(p.IdleTimeout)
+}
+
+// ScaleDownAfterDuration returns the scale down delay, 0 when not set
+func (p Plugin) ScaleDownAfterDuration() time.Duration {
+	return duration(p.ScaleDownAfter)
+}
+
+// RestartDurations returns the restart backoff, maximum backoff, window and
+// quarantine durations, 0 for the ones not set
+func (p Plugin) RestartDurations() (backoff, maxBackoff, window, quarantine time.Duration) {
+	return duration(p.RestartBackoff), duration(p.RestartMaxBackoff), duration(p.RestartWindow), duration(p.Quarantine)
+}
+
+// Signing holds the plugin signature verification settings:
+//
+//	[signing]
+//	mode = "enforce"
+//	trusted_keys = ["keys/release.pub"]
+type Signing struct {
+	// Mode is enforce, warn or off. It defaults to enforce when trusted keys
+	// are configured and to off otherwise.
+	Mode string `toml:"mode"`
+	// TrustedKeys lists the PEM public key files plugins may be signed with,
+	// relative paths are resolved against the directory of the configuration file
+	TrustedKeys []string `toml:"trusted_keys"`
+}
+
+// Daemon holds the settings of greeterd:
+//
+//	[daemon]
+//	socket = "/run/greeter/greeterd.sock"
+//	disabled = true
+//	idle_timeout = "30m"
+type Daemon struct {
+	// Socket is the Unix socket greeterd listens on, see daemon.SocketPath for
+	// the default. A relative path is resolved against the directory of the
+	// configuration file.
+	Socket string `toml:"socket"`
+	// Disabled makes the CLI start plugins itself even when greeterd is running
+	Disabled bool `toml:"disabled"`
+	// IdleTimeout is how long plugins may stay unused before they are
+	// stopped, as a Go duration, unless their own settings say otherwise
+	IdleTimeout string `toml:"idle_timeout"`
+}
+
+// DefaultIdleTimeout is the idle timeout of plugins when daemon.idle_timeout is not set
+const DefaultIdleTimeout = 10 * time.Minute
+
+// IdleTimeoutDuration returns the idle timeout of plugins, DefaultIdleTimeout when not set
+func (d Daemon) IdleTimeoutDuration() time.Duration {
+	if d.IdleTimeout == "" {
+		return DefaultIdleTimeout
+	}
+	return duration(d.IdleTimeout)
+}
+
+// duration returns the value of a duration setting, 0 when not set
+func duration(value string) time.Duration {
+	d, _ := time.ParseDuration(value)
+	return d
+}
+
+// Setting is a single configuration value along with the file setting it
+type Setting struct {
+	// Key is the dotted name of the setting, e.g. "log.level"
+	Key   string
+	Value any
+	// Origin is the configuration file the value comes from, empty for defaults
+	Origin string
+}
+
+// Defaults holds the values of the settings that are not set in any file
+var Defaults = map[string]any{
+	"log.level":           "info",
+	"log.format":          "text",
+	"output.format":       "both",
+	"output.details":      false,
+	"output.color":        "auto",
+	"daemon.disabled":     false,
+	"daemon.idle_timeout": "10m",
+}
+
+// UserPath returns the path of the user configuration file,
+// $XDG_CONFIG_HOME/greeter/config.toml or ~/.config/greeter/config.toml
+func UserPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "greeter", "config.toml"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "greeter", "config.toml"), nil
+}
+
+// Paths returns the configuration files in precedence order, lowest first,
+// ending with extra if it is not empty
+func Paths(extra string) []string {
+	paths := []string{SystemPath}
+	if user, err := UserPath(); err == nil {
+		paths = append(paths, user)
+	}
+	if extra != "" {
+		paths = append(paths, extra)
+	}
+	return paths
+}
+
+// Load reads the system and user configuration files, then extra if it is
+// not empty. Missing files are skipped, except extra. The returned
+// configuration is never nil: invalid settings, and files that fail to
+// load, are left out and reported in the error.
+func Load(extra string) (*Config, error) {
+	settings := make(map[string]any)
+	origins := make(map[string]string)
+	var files []string
+	var errs, signingErrs []error
+
+	for _, path := range Paths(extra) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) && path != extra {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("failed to read config: %w", err))
+			continue
+		}
+
+		layer, err := parse(path, data)
+		if err != nil {
+			errs = append(errs, err)
+			if layer == nil && setsSigning(data) || layer != nil && dropsSigning(err) {
+				signingErrs = append(signingErrs, err)
+			}
+		}
+		if layer == nil {
+			continue
+		}
+		merge(settings, layer, "", path, origins)
+		files = append(files, path)
+	}
+
+	cfg, err := decode(settings)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	cfg.Files = files
+	cfg.SigningErr = errors.Join(signingErrs...)
+	cfg.settings = settings
+	cfg.origins = origins
+
+	return cfg, errors.Join(errs...)
+}
+
+// parse validates a configuration file and returns its valid settings,
+// with the relative paths resolved against the directory of the file.
+// Invalid settings are left out and reported in the error; a file that is
+// not valid TOML is left out as a whole.
+func parse(path string, data []byte) (map[string]any, error) {
+	settings := make(map[string]any)
+	if _, err := toml.Decode(string(data), &settings); err != nil {
+		return nil, parseError(path, err)
+	}
+
+	var errs []error
+	for {
+		key, err := check(settings)
+		if err == nil {
+			break
+		}
+		errs = append(errs, &Error{Path: path, Line: keyLine(data, key), Key: key.String(), Err: err})
+		if !remove(settings, key) {
+			// The setting cannot be told apart from the others
+			return nil, errors.Join(errs...)
+		}
+	}
+
+	dir := filepath.Dir(path)
+	resolvePaths(dir, settings["plugin_dirs"])
+	if signing, ok := settings["signing"].(map[string]any); ok {
+		resolvePaths(dir, signing["trusted_keys"])
+	}
+	if plugins, ok := settings["plugins"].(map[string]any); ok {
+		for _, p := range plugins {
+			if p, ok := p.(map[string]any); ok {
+				if sandbox, ok := p["sandbox"].(map[string]any); ok {
+					resolvePaths(dir, sandbox["read_paths"])
+				}
+			}
+		}
+	}
+	if daemon, ok := settings["daemon"].(map[string]any); ok {
+		if socket, ok := daemon["socket"].(string); ok && socket != "" && !filepath.IsAbs(socket) {
+			daemon["socket"] = filepath.Join(dir, socket)
+		}
+	}
+	return settings, errors.Join(errs...)
+}
+
+// check decodes settings into a Config and returns the key of the first
+// unknown, mistyped or invalid setting along with the reason
+func check(settings map[string]any) (toml.Key, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(settings); err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	md, err := toml.Decode(buf.String(), &cfg)
+	if err != nil {
+		var e *Error
+		if errors.As(parseError("", err), &e) && e.Key != "" {
+			return splitKey(e.Key), e.Err
+		}
+		return nil, err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return undecoded[0], errors.New("unknown setting")
+	}
+	return cfg.validate()
+}
+
+// remove deletes a setting from nested tables, reporting whether it was found
+func remove(settings map[string]any, key toml.Key) bool {
+	if len(key) == 0 {
+		return false
+	}
+	for _, part := range key[:len(key)-1] {
+		table, ok := settings[part].(map[string]any)
+		if !ok {
+			return false
+		}
+		settings = table
+	}
+	if _, ok := settings[key[len(key)-1]]; !ok {
+		return false
+	}
+	delete(settings, key[len(key)-1])
+	return true
+}
+
+// resolvePaths makes the relative paths of a list setting relative to dir
+func resolvePaths(dir string, value any) {
+	paths, _ := value.([]any)
+	for i, path := range paths {
+		if s, ok := path.(string); ok && !filepath.IsAbs(s) {
+			paths[i] = filepath.Join(dir, s)
+		}
+	}
+}
+
+// merge copies the settings of src over the ones of dst, table by table,
+// recording the origin of every value under the dotted key prefix
+func merge(dst, src map[string]any, prefix, origin string, origins map[string]string) {
+	for key, value := range src {
+		full := prefix + key
+		if table, ok := value.(map[string]any); ok {
+			sub, ok := dst[key].(map[string]any)
+			if !ok {
+				sub = make(map[string]any)
+				dst[key] = sub
+			}
+			merge(sub, table, full+".", origin, origins)
+			continue
+		}
+		dst[key] = value
+		origins[full] = origin
+	}
+}
+
+// decode converts merged settings into a Config
+func decode(settings map[string]any) (*Config, error) {
+	cfg := &Config{}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(settings); err != nil {
+		return cfg, err
+	}
+	if _, err := toml.Decode(buf.String(), cfg); err != nil {
+		return &Config{}, err
+	}
+	return cfg, nil
+}
+
+// Plugin returns the settings of an external plugin
+func (c *Config) Plugin(name string) Plugin {
+	return c.Plugins[name]
+}
+
+// Settings returns every setting defined by the configuration files, sorted by key
+func (c *Config) Settings() []Setting {
+	var settings []Setting
+	flatten(c.settings, "", func(key string, value any) {
+		settings = append(settings, Setting{Key: key, Value: value, Origin: c.origins[key]})
+	})
+	sort.Slice(settings, func(i, j int) bool {
+		return settings[i].Key < settings[j].Key
+	})
+	return settings
+}
+
+// Get returns the settings under a dotted key: the setting itself, or every
+// setting of a table such as "plugins.hindi". Unset settings that have a
+// default are returned with it. ok is false when nothing is set under the key.
+func (c *Config) Get(key string) (settings []Setting, ok bool) {
+	for _, s := range c.Settings() {
+		if s.Key == key || strings.HasPrefix(s.Key, key+".") {
+			settings = append(settings, s)
+		}
+	}
+	if len(settings) == 0 {
+		if value, ok := Defaults[key]; ok {
+			settings = append(settings, Setting{Key: key, Value: value})
+		}
+	}
+	return settings, len(settings) > 0
+}
+
+// flatten calls fn with the dotted key of every value of nested tables
+func flatten(settings map[string]any, prefix string, fn func(key string, value any)) {
+	for key, value := range settings {
+		if table, ok := value.(map[string]any); ok {
+			flatten(table, prefix+key+".", fn)
+			continue
+		}
+		fn(prefix+key, value)
+	}
+}
+
+// FormatValue formats a setting value as TOML, e.g. a quoted string
+func FormatValue(value any) string {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(map[string]any{"v": value}); err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(buf.String(), "v = "))
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes a configuration file under dir and returns its path
+func writeConfig(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	user := writeConfig(t, home, "greeter/config.toml", `language = "hindi"
+plugin_dirs = ["plugins"]
+
+[log]
+level = "warn"
+format = "json"
+
+[plugins.hindi]
+timeout = "2s"
+env = { HINDI_DIALECT = "awadhi" }
+`)
+	extra := writeConfig(t, t.TempDir(), "extra.toml", `[log]
+level = "debug"
+
+[plugins.hindi]
+timeout = "5s"
+`)
+
+	cfg, err := Load(extra)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if want := []string{user, extra}; !reflect.DeepEqual(cfg.Files, want) {
+		t.Errorf("Files = %v, want %v", cfg.Files, want)
+	}
+	if cfg.Language != "hindi" {
+		t.Errorf("Language = %q, want hindi", cfg.Language)
+	}
+	if cfg.Log != (Log{Level: "debug", Format: "json"}) {
+		t.Errorf("Log = %+v, want the level of the extra file and the format of the user one", cfg.Log)
+	}
+	if p := cfg.Plugin("hindi"); p.Timeout != "5s" || p.Env["HINDI_DIALECT"] != "awadhi" {
+		t.Errorf("hindi plugin = %+v, want the tables merged", p)
+	}
+	if want := []string{filepath.Join(home, "greeter", "plugins")}; !reflect.DeepEqual(cfg.PluginDirs, want) {
+		t.Errorf("PluginDirs = %v, want %v", cfg.PluginDirs, want)
+	}
+
+	origins := map[string]string{
+		"language":              user,
+		"log.level":             extra,
+		"log.format":            user,
+		"plugins.hindi.timeout": extra,
+		"output.color":          "",
+	}
+	for key, want := range origins {
+		settings, ok := cfg.Get(key)
+		if !ok || len(settings) != 1 {
+			t.Errorf("Get(%q) = %v, %v", key, settings, ok)
+			continue
+		}
+		if settings[0].Origin != want {
+			t.Errorf("%s comes from %q, want %q", key, settings[0].Origin, want)
+		}
+	}
+	if settings, _ := cfg.Get("plugins.hindi"); len(settings) != 2 {
+		t.Errorf("Get(plugins.hindi) = %v, want its 2 settings", settings)
+	}
+	if settings, ok := cfg.Get("log.colour"); ok {
+		t.Errorf("Get(log.colour) = %v", settings)
+	}
+}
+
+func TestLoadMissingFiles(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(cfg.Files) != 0 || cfg.Language != "" {
+		t.Errorf("got %+v, want the defaults", cfg)
+	}
+
+	// A file given explicitly must exist
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.toml")); err == nil {
+		t.Error("a missing extra file was not reported")
+	}
+}
+
+func TestLoadInvalidFiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	writeConfig(t, home, "greeter/config.toml", `language = "hindi"
+
+[log]
+level = "loud"
+format = "json"
+`)
+
+	// Only the invalid settings of a valid TOML file are left out
+	cfg, err := Load("")
+	if err == nil || !strings.Contains(err.Error(), "log.level") {
+		t.Errorf("got %v, want the invalid level reported", err)
+	}
+	if cfg.Language != "hindi" || cfg.Log != (Log{Format: "json"}) {
+		t.Errorf("got %+v, want the valid settings", cfg)
+	}
+
+	// A file that is not valid TOML is left out as a whole
+	extra := writeConfig(t, t.TempDir(), "extra.toml", "language = \"japanese\"\n[log\n")
+	cfg, err = Load(extra)
+	if err == nil || !strings.Contains(err.Error(), extra) {
+		t.Errorf("got %v, want the extra file reported", err)
+	}
+	if cfg.Language != "hindi" || len(cfg.Files) != 1 {
+		t.Errorf("got %+v, want the user file only", cfg)
+	}
+	if cfg.SigningErr != nil {
+		t.Errorf("SigningErr = %v, want nil", cfg.SigningErr)
+	}
+}
+
+func TestLoadSigningErr(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"invalid setting", "[signing]\nmode = \"sometimes\"\n"},
+		{"unknown setting", "[signing]\ntrusted_key = \"release.pub\"\n"},
+		{"not TOML", "[signing]\nmode = enforce\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			extra := writeConfig(t, t.TempDir(), "extra.toml", tt.content)
+
+			cfg, err := Load(extra)
+			if err == nil {
+				t.Error("the invalid file was not reported")
+			}
+			if cfg.SigningErr == nil {
+				t.Error("SigningErr = nil, want the signing settings reported")
+			}
+		})
+	}
+}
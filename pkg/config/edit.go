@@ -0,0 +1,202 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/unsuman/greeter/pkg/statefile"
+)
+
+// listSettings are the settings holding a list, given to Set separated by commas
+var listSettings = map[string]bool{
+	"plugin_dirs":          true,
+	"signing.trusted_keys": true,
+}
+
+// boolSettings are the settings holding a boolean
+var boolSettings = map[string]bool{
+	"output.details":  true,
+	"daemon.disabled": true,
+}
+
+// pluginBoolSettings, pluginIntSettings and pluginListSettings are the
+// settings of plugins holding a boolean, an integer and a list, by name
+var (
+	pluginBoolSettings = map[string]bool{
+		"keep_warm": true,
+		"enabled":   true,
+		"required":  true,
+	}
+	pluginIntSettings = map[string]bool{
+		"max_restarts":  true,
+		"min_instances": true,
+		"max_instances": true,
+		"open_files":    true,
+		"processes":     true,
+	}
+	pluginListSettings = map[string]bool{
+		"read_paths": true,
+	}
+)
+
+// Set changes a setting of a configuration file, creating the file if
+// needed. The rest of the file, comments included, is left untouched. The
+// change is validated before the file is written: an invalid key or value
+// leaves the file as it was. The invalid settings already in the file are
+// kept, and may be fixed by setting them.
+func Set(path, key, value string) error {
+	parsed, err := parseValue(key, value)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	table, name := "", key
+	if dot := strings.LastIndex(key, "."); dot >= 0 {
+		table, name = key[:dot], key[dot+1:]
+	}
+	if name == "" || table != "" && strings.HasSuffix(table, ".") {
+		return fmt.Errorf("invalid setting name %q", key)
+	}
+
+	layer, invalid := parse(path, data)
+	edited := setLine(string(data), table, name+" = "+FormatValue(parsed))
+	if err := checkEdit(path, normalizeKey(key), layer != nil, invalid, []byte(edited)); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := statefile.WriteFile(path, []byte(edited), 0o644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// checkEdit validates the edit of a configuration file setting key. It
+// fails when the edited file is not valid TOML, or when it has invalid
+// settings that the file did not have, other than key. valid tells whether
+// the file was valid TOML, invalid reports its invalid settings.
+func checkEdit(path, key string, valid bool, invalid error, edited []byte) error {
+	layer, err := parse(path, edited)
+	if err == nil {
+		return nil
+	}
+	if layer == nil && !valid {
+		return fmt.Errorf("cannot change a configuration file that is not valid TOML: %w", invalid)
+	}
+
+	known := make(map[string]bool)
+	if valid {
+		for _, e := range settingErrors(invalid) {
+			known[e.Key] = true
+		}
+	}
+	var errs []error
+	for _, e := range settingErrors(err) {
+		if e.Key == key || !known[e.Key] {
+			// Lines refer to the edited file that was not written
+			errs = append(errs, &Error{Path: path, Key: e.Key, Err: e.Err})
+		}
+	}
+	if layer == nil && len(errs) == 0 {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// parseValue converts the command line value of a setting to its TOML type
+func parseValue(key, value string) (any, error) {
+	// The name of a plugin setting, e.g. keep_warm for plugins.hindi.keep_warm
+	var pluginSetting string
+	if strings.HasPrefix(key, "plugins.") {
+		pluginSetting = key[strings.LastIndex(key, ".")+1:]
+	}
+
+	switch {
+	case listSettings[key] || pluginListSettings[pluginSetting]:
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list, nil
+	case boolSettings[key] || pluginBoolSettings[pluginSetting]:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid value %q (expected true or false)", key, value)
+		}
+		return b, nil
+	case pluginIntSettings[pluginSetting]:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid value %q (expected a number)", key, value)
+		}
+		return n, nil
+	default:
+		return value, nil
+	}
+}
+
+// setLine replaces the line defining a key of a table, or adds one at the
+// end of the table, adding the table at the end of the file if needed.
+// The empty table is the one of the top-level keys, before any header.
+func setLine(content, table, line string) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	// Locate the section of the table: the lines after its header up to the next one
+	start, end := -1, len(lines)
+	if table == "" {
+		start = 0
+	}
+	for i, l := range lines {
+		trimmed := strings.TrimSpace(l)
+		if !strings.HasPrefix(trimmed, "[") {
+			continue
+		}
+		if start >= 0 && i >= start {
+			end = i
+			break
+		}
+		if normalizeKey(strings.Trim(strings.SplitN(trimmed, "]", 2)[0], "[")) == table {
+			start = i + 1
+		}
+	}
+
+	if start < 0 {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "["+table+"]", line)
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	name := strings.TrimSpace(strings.SplitN(line, "=", 2)[0])
+	insert := start
+	for i := start; i < end; i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if eq := strings.Index(trimmed, "="); eq > 0 && !strings.HasPrefix(trimmed, "#") && normalizeKey(trimmed[:eq]) == name {
+			lines[i] = line
+			return strings.Join(lines, "\n") + "\n"
+		}
+		if trimmed != "" {
+			insert = i + 1
+		}
+	}
+
+	lines = append(lines[:insert], append([]string{line}, lines[insert:]...)...)
+	return strings.Join(lines, "\n") + "\n"
+}
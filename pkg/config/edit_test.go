@@ -0,0 +1,197 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSetConcurrently(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(path, []byte("# Greeter\nlanguage = \"hindi\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := Set(path, "log.level", []string{"debug", "info", "warn", "error"}[i%4]); err != nil {
+				t.Errorf("Set: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, err := parse(path, data)
+	if err != nil {
+		t.Fatalf("the file is not valid after concurrent changes: %v\n%s", err, data)
+	}
+	if settings["language"] != "hindi" {
+		t.Errorf("language = %v, want hindi:\n%s", settings["language"], data)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary files left: %v", entries)
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		key   string
+		value string
+		want  string
+		// err is part of the error expected, the file being left unchanged
+		err string
+	}{
+		{
+			name:  "new file",
+			key:   "log.level",
+			value: "debug",
+			want:  "[log]\nlevel = \"debug\"\n",
+		},
+		{
+			name:  "replaced value",
+			file:  "# Greeter\nlanguage = \"hindi\" \n\n[log]\n# Quieter\nlevel = \"warn\"\nformat = \"json\"\n",
+			key:   "log.level",
+			value: "error",
+			want:  "# Greeter\nlanguage = \"hindi\" \n\n[log]\n# Quieter\nlevel = \"error\"\nformat = \"json\"\n",
+		},
+		{
+			name:  "added to its table",
+			file:  "[log]\nlevel = \"warn\"\n\n[output]\ncolor = \"never\"\n",
+			key:   "log.format",
+			value: "json",
+			want:  "[log]\nlevel = \"warn\"\nformat = \"json\"\n\n[output]\ncolor = \"never\"\n",
+		},
+		{
+			name:  "top-level key",
+			file:  "[log]\nlevel = \"warn\"\n",
+			key:   "language",
+			value: "japanese",
+			want:  "language = \"japanese\"\n[log]\nlevel = \"warn\"\n",
+		},
+		{
+			name:  "typed values",
+			key:   "output.details",
+			value: "true",
+			want:  "[output]\ndetails = true\n",
+		},
+		{
+			name:  "list",
+			key:   "plugin_dirs",
+			value: "/opt/greeter, /srv/greeter",
+			want:  "plugin_dirs = [\"/opt/greeter\", \"/srv/greeter\"]\n",
+		},
+		{
+			name:  "invalid value fixed",
+			file:  "[log]\nlevel = \"loud\"\n",
+			key:   "log.level",
+			value: "warn",
+			want:  "[log]\nlevel = \"warn\"\n",
+		},
+		{
+			name:  "invalid setting kept",
+			file:  "[log]\nlevel = \"loud\"\n",
+			key:   "output.color",
+			value: "never",
+			want:  "[log]\nlevel = \"loud\"\n\n[output]\ncolor = \"never\"\n",
+		},
+		{
+			name:  "unknown setting kept",
+			file:  "colour = \"red\"\n",
+			key:   "language",
+			value: "hindi",
+			want:  "colour = \"red\"\nlanguage = \"hindi\"\n",
+		},
+		{
+			name:  "invalid value",
+			file:  "[log]\nlevel = \"warn\"\n",
+			key:   "log.level",
+			value: "loud",
+			err:   `log.level: unknown log level "loud"`,
+		},
+		{
+			name:  "invalid value still",
+			file:  "[log]\nlevel = \"loud\"\n",
+			key:   "log.level",
+			value: "louder",
+			err:   `log.level: unknown log level "louder"`,
+		},
+		{
+			name:  "invalid with another setting",
+			file:  "[plugins.hindi]\nmax_instances = 2\n",
+			key:   "plugins.hindi.min_instances",
+			value: "3",
+			err:   "plugins.hindi.max_instances: invalid value 2",
+		},
+		{
+			name:  "unknown setting",
+			key:   "log.colour",
+			value: "red",
+			err:   "log.colour: unknown setting",
+		},
+		{
+			name:  "mistyped value",
+			key:   "output.details",
+			value: "maybe",
+			err:   "expected true or false",
+		},
+		{
+			name:  "invalid name",
+			key:   "log.",
+			value: "debug",
+			err:   "invalid setting name",
+		},
+		{
+			name:  "not TOML",
+			file:  "[log\nlevel = \"warn\"\n",
+			key:   "output.color",
+			value: "never",
+			err:   "not valid TOML",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if tt.file != "" {
+				if err := os.WriteFile(path, []byte(tt.file), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := Set(path, tt.key, tt.value)
+			data, _ := os.ReadFile(path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("got %v, want an error containing %q", err, tt.err)
+				}
+				if string(data) != tt.file {
+					t.Errorf("the file was changed:\n%s", data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", data, tt.want)
+			}
+		})
+	}
+}
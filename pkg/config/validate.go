@@ -0,0 +1,343 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/sirupsen/logrus"
+	"github.com/unsuman/greeter/pkg/plugin/limits"
+	"github.com/unsuman/greeter/pkg/plugin/signing"
+)
+
+// Error reports an invalid configuration file, pointing to the offending line
+type Error struct {
+	Path string
+	// Line is the line of the setting, 0 if unknown
+	Line int
+	// Key is the dotted name of the setting, empty if unknown
+	Key string
+	Err error
+}
+
+func (e *Error) Error() string {
+	location := e.Path
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d", e.Path, e.Line)
+	}
+	if e.Key == "" {
+		return fmt.Sprintf("%s: %v", location, e.Err)
+	}
+	return fmt.Sprintf("%s: %s: %v", location, e.Key, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// decodeErrorPattern matches the messages of the TOML decoder errors that are not a toml.ParseError
+var decodeErrorPattern = regexp.MustCompile(`^toml: line (\d+) \(last key "(.*)"\): (.*)$`)
+
+// parseError converts an error of the TOML decoder into an *Error
+func parseError(path string, err error) error {
+	var pe toml.ParseError
+	if !errors.As(err, &pe) {
+		// Type mismatches are only reported as formatted messages
+		if m := decodeErrorPattern.FindStringSubmatch(err.Error()); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			key, err := strconv.Unquote(`"` + m[2] + `"`)
+			if err != nil {
+				key = m[2]
+			}
+			return &Error{Path: path, Line: line, Key: key, Err: errors.New(m[3])}
+		}
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	msg := pe.Message
+	if msg == "" {
+		// Errors of values rejected while decoding only have their formatted message
+		msg = strings.TrimPrefix(pe.Error(), fmt.Sprintf("toml: line %d (last key %q): ", pe.Position.Line, pe.LastKey))
+	}
+	return &Error{Path: path, Line: pe.Position.Line, Key: pe.LastKey, Err: errors.New(msg)}
+}
+
+// validate checks the values of the settings and returns the key of the
+// first invalid one along with the reason
+func (c *Config) validate() (toml.Key, error) {
+	if c.Log.Level != "" {
+		if _, err := logrus.ParseLevel(c.Log.Level); err != nil {
+			return toml.Key{"log", "level"}, fmt.Errorf("unknown log level %q", c.Log.Level)
+		}
+	}
+	if err := oneOf(c.Log.Format, "text", "json"); err != nil {
+		return toml.Key{"log", "format"}, err
+	}
+	if err := oneOf(c.Output.Format, "both", "native", "romanized"); err != nil {
+		return toml.Key{"output", "format"}, err
+	}
+	if err := oneOf(c.Output.Color, "auto", "always", "never"); err != nil {
+		return toml.Key{"output", "color"}, err
+	}
+	if _, err := signing.ParseMode(c.Signing.Mode, signing.ModeOff); err != nil {
+		return toml.Key{"signing", "mode"}, err
+	}
+
+	if err := checkDuration(c.Daemon.IdleTimeout); err != nil {
+		return toml.Key{"daemon", "idle_timeout"}, err
+	}
+
+	for i, dir := range c.PluginDirs {
+		if dir == "" {
+			return toml.Key{"plugin_dirs"}, fmt.Errorf("entry %d is empty", i+1)
+		}
+	}
+	if c.PluginCgroup != "" && !filepath.IsAbs(c.PluginCgroup) {
+		return toml.Key{"plugin_cgroup"}, fmt.Errorf("%q is not an absolute path", c.PluginCgroup)
+	}
+
+	names := make([]string, 0, len(c.Plugins))
+	for name := range c.Plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := c.Plugins[name]
+		if err := checkDuration(p.StartTimeout); err != nil {
+			return toml.Key{"plugins", name, "start_timeout"}, err
+		}
+		if err := checkDuration(p.Timeout); err != nil {
+			return toml.Key{"plugins", name, "timeout"}, err
+		}
+		if err := oneOf(p.Restart, "on-crash", "never"); err != nil {
+			return toml.Key{"plugins", name, "restart"}, err
+		}
+		for setting, value := range map[string]string{
+			"restart_backoff":     p.RestartBackoff,
+			"restart_max_backoff": p.RestartMaxBackoff,
+			"restart_window":      p.RestartWindow,
+			"quarantine":          p.Quarantine,
+			"idle_timeout":        p.IdleTimeout,
+			"scale_down_after":    p.ScaleDownAfter,
+		} {
+			if err := checkDuration(value); err != nil {
+				return toml.Key{"plugins", name, setting}, err
+			}
+		}
+		if p.MaxRestarts < 0 {
+			return toml.Key{"plugins", name, "max_restarts"}, fmt.Errorf("invalid value %d (expected a positive number)", p.MaxRestarts)
+		}
+		if p.MinInstances < 0 {
+			return toml.Key{"plugins", name, "min_instances"}, fmt.Errorf("invalid value %d (expected a positive number)", p.MinInstances)
+		}
+		if p.MaxInstances < 0 || p.MaxInstances > 0 && p.MaxInstances < p.MinInstances {
+			return toml.Key{"plugins", name, "max_instances"}, fmt.Errorf("invalid value %d (expected a positive number, at least min_instances)", p.MaxInstances)
+		}
+		if key, err := p.Limits.validate(); err != nil {
+			return append(toml.Key{"plugins", name, "limits"}, key), err
+		}
+		for i, path := range p.Sandbox.ReadPaths {
+			if path == "" {
+				return toml.Key{"plugins", name, "sandbox", "read_paths"}, fmt.Errorf("entry %d is empty", i+1)
+			}
+		}
+		for variable := range p.Env {
+			if variable == "" || strings.ContainsAny(variable, "=\x00") {
+				return toml.Key{"plugins", name, "env", variable}, fmt.Errorf("invalid environment variable name %q", variable)
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// validate checks the resource limits of a plugin and returns the name of
+// the first invalid one along with the reason
+func (l Limits) validate() (string, error) {
+	for name, size := range map[string]string{"address_space": l.AddressSpace, "memory": l.Memory} {
+		if size == "" {
+			continue
+		}
+		if _, err := limits.ParseSize(size); err != nil {
+			return name, err
+		}
+	}
+	if err := checkDuration(l.CPUTime); err != nil {
+		return "cpu_time", err
+	}
+	if l.CPU != "" {
+		if _, err := limits.ParseCPU(l.CPU); err != nil {
+			return "cpu", err
+		}
+	}
+	for name, n := range map[string]int{"open_files": l.OpenFiles, "processes": l.Processes} {
+		if n < 0 {
+			return name, fmt.Errorf("invalid value %d (expected a positive number)", n)
+		}
+	}
+	return "", nil
+}
+
+// oneOf checks that a setting is empty or one of the allowed values
+func oneOf(value string, allowed ...string) error {
+	if value == "" {
+		return nil
+	}
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid value %q (expected %s)", value, strings.Join(allowed, ", "))
+}
+
+// checkDuration checks that a setting is empty or a positive Go duration such as "5s"
+func checkDuration(value string) error {
+	if value == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q (expected e.g. 500ms, 5s or 1m)", value)
+	}
+	if d <= 0 {
+		return fmt.Errorf("duration %q must be positive", value)
+	}
+	return nil
+}
+
+// keyLine returns the line a setting is defined on, 0 if it cannot be
+// found. Table headers, plain and dotted keys are recognized; keys nested
+// in inline tables are reported on the line of the table.
+func keyLine(data []byte, key toml.Key) int {
+	want := key.String()
+	best, bestLen := 0, 0
+	found := 0
+	definitions(data, func(line int, full string) bool {
+		if full == want {
+			found = line
+			return false
+		}
+		// Remember the closest enclosing definition, e.g. an inline table
+		if strings.HasPrefix(want, full+".") && len(full) > bestLen {
+			best, bestLen = line, len(full)
+		}
+		return true
+	})
+	if found > 0 {
+		return found
+	}
+	return best
+}
+
+// setsSigning reports whether a configuration file configures signing, as
+// far as can be told from its lines
+func setsSigning(data []byte) bool {
+	sets := false
+	definitions(data, func(line int, full string) bool {
+		sets = full == "signing" || strings.HasPrefix(full, "signing.")
+		return !sets
+	})
+	return sets
+}
+
+// dropsSigning reports whether the settings parse left out of a file
+// include signing settings
+func dropsSigning(err error) bool {
+	for _, e := range settingErrors(err) {
+		if e.Key == "signing" || strings.HasPrefix(e.Key, "signing.") {
+			return true
+		}
+	}
+	return false
+}
+
+// settingErrors returns the errors of the settings parse reported, joined in err
+func settingErrors(err error) []*Error {
+	if err == nil {
+		return nil
+	}
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	var settings []*Error
+	for _, err := range errs {
+		var e *Error
+		if errors.As(err, &e) {
+			settings = append(settings, e)
+		}
+	}
+	return settings
+}
+
+// definitions calls fn with the line and dotted key of every table header
+// and key definition of a file, until fn returns false. The lines are read
+// one by one, so that files that are not valid TOML can be scanned.
+func definitions(data []byte, fn func(line int, key string) bool) {
+	var table string
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var full string
+		if strings.HasPrefix(line, "[") {
+			end := strings.Index(line, "]")
+			if end < 0 {
+				// Still tell the table of a malformed header
+				end = len(line)
+			}
+			table = normalizeKey(strings.Trim(line[:end], "[]"))
+			full = table
+		} else {
+			eq := strings.Index(line, "=")
+			if eq < 0 {
+				continue
+			}
+			full = normalizeKey(line[:eq])
+			if table != "" {
+				full = table + "." + full
+			}
+		}
+
+		if !fn(i+1, full) {
+			return
+		}
+	}
+}
+
+// normalizeKey removes the quotes and spaces around the parts of a dotted key
+func normalizeKey(key string) string {
+	return splitKey(key).String()
+}
+
+// splitKey splits a dotted key into its parts, which may be quoted
+func splitKey(key string) toml.Key {
+	var parts toml.Key
+	var part strings.Builder
+	var quote rune
+	for _, r := range key {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			part.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '.':
+			parts = append(parts, strings.TrimSpace(part.String()))
+			part.Reset()
+		default:
+			part.WriteRune(r)
+		}
+	}
+	return append(parts, strings.TrimSpace(part.String()))
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseErrorLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		line    int
+		key     string
+		err     string
+	}{
+		{
+			name:    "invalid value",
+			content: "language = \"hindi\"\n\n[log]\nformat = \"json\"\nlevel = \"loud\"\n",
+			line:    5,
+			key:     "log.level",
+			err:     `unknown log level "loud"`,
+		},
+		{
+			name:    "unknown setting",
+			content: "[output]\n# Colors\ncolour = \"never\"\n",
+			line:    3,
+			key:     "output.colour",
+			err:     "unknown setting",
+		},
+		{
+			name:    "mistyped value",
+			content: "[output]\n\ndetails = \"yes\"\n",
+			line:    3,
+			key:     "output.details",
+		},
+		{
+			name:    "dotted key",
+			content: "language = \"hindi\"\nplugins.hindi.timeout = \"soon\"\n",
+			line:    2,
+			key:     "plugins.hindi.timeout",
+			err:     "invalid duration",
+		},
+		{
+			name:    "quoted table",
+			content: "[plugins.\"hindi\"]\nstart_timeout = \"1s\"\ntimeout = \"soon\"\n",
+			line:    3,
+			key:     "plugins.hindi.timeout",
+		},
+		{
+			name:    "inline table",
+			content: "[plugins.hindi]\nenv = { \"\" = \"x\" }\n",
+			line:    2,
+		},
+		{
+			name:    "not TOML",
+			content: "[log]\nlevel = \"warn\"\nformat = json\n",
+			line:    3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse("config.toml", []byte(tt.content))
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("got %v, want an *Error", err)
+			}
+			if e.Line != tt.line {
+				t.Errorf("line = %d, want %d: %v", e.Line, tt.line, err)
+			}
+			if tt.key != "" && e.Key != tt.key {
+				t.Errorf("key = %q, want %q", e.Key, tt.key)
+			}
+			if tt.err != "" && !strings.Contains(e.Err.Error(), tt.err) {
+				t.Errorf("got %v, want an error containing %q", e.Err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseErrorsAll(t *testing.T) {
+	content := "[log]\nlevel = \"loud\"\nformat = \"yaml\"\n\n[output]\ncolor = \"never\"\n"
+	settings, err := parse("config.toml", []byte(content))
+
+	var lines []int
+	for _, e := range settingErrors(err) {
+		lines = append(lines, e.Line)
+	}
+	if !reflect.DeepEqual(lines, []int{2, 3}) {
+		t.Errorf("errors on lines %v, want 2 and 3: %v", lines, err)
+	}
+	if output, _ := settings["output"].(map[string]any); output["color"] != "never" {
+		t.Errorf("settings = %v, want the valid ones kept", settings)
+	}
+}
@@ -0,0 +1,211 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	pb "github.com/unsuman/greeter/pkg/daemon/proto"
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin"
+	"github.com/unsuman/greeter/pkg/plugin/limits"
+	greeterpb "github.com/unsuman/greeter/pkg/plugin/proto"
+	"github.com/unsuman/greeter/pkg/plugin/sandbox"
+	"github.com/unsuman/greeter/pkg/plugin/signing"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// SocketPath returns the default socket of greeterd: greeter/greeterd.sock
+// in XDG_RUNTIME_DIR, or in a per-user directory of the temporary directory
+func SocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "greeter", "greeterd.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("greeter-%d", os.Getuid()), "greeterd.sock")
+}
+
+// UnsafeDirError reports a socket directory other users could control, e.g.
+// a directory of the temporary directory created by another user first
+type UnsafeDirError struct {
+	Dir    string
+	Reason string
+}
+
+func (e *UnsafeDirError) Error() string {
+	return fmt.Sprintf("refusing socket directory %s: %s", e.Dir, e.Reason)
+}
+
+// checkSocketDir refuses the directory of the default socket unless it is
+// private to the user. Configured sockets are left to the user.
+func checkSocketDir(path string) error {
+	dir := filepath.Dir(path)
+	if dir != filepath.Dir(SocketPath()) {
+		return nil
+	}
+	return checkPrivateDir(dir)
+}
+
+// Client talks to a running greeterd. It implements plugin.Remote.
+type Client struct {
+	conn *grpc.ClientConn
+	svc  pb.DaemonServiceClient
+
+	// Status is the status reported by the daemon when the client connected
+	Status *pb.DaemonStatus
+}
+
+// Dial connects to the daemon listening on the socket at path, failing if
+// no daemon answers within timeout
+func Dial(path string, timeout time.Duration) (*Client, error) {
+	if err := checkSocketDir(path); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.NewClient("unix://"+path, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	client := &Client{conn: conn, svc: pb.NewDaemonServiceClient(conn)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if client.Status, err = client.svc.Status(ctx, &greeterpb.Empty{}, grpc.WaitForReady(true)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("greeterd is not answering on %s: %w", path, err)
+	}
+	return client, nil
+}
+
+// Policy returns what the daemon enforces on the plugins it starts, as
+// reported when the client connected
+func (c *Client) Policy() plugin.Policy {
+	policy := plugin.Policy{
+		SignatureMode: signing.Mode(c.Status.GetSigningMode()),
+		TrustedKeys:   c.Status.GetTrustedKeys(),
+		CgroupRoot:    c.Status.GetPluginCgroup(),
+	}
+	for _, p := range c.Status.GetPolicies() {
+		if policy.Plugins == nil {
+			policy.Plugins = make(map[string]plugin.PluginPolicy)
+		}
+		policy.Plugins[p.GetName()] = plugin.PluginPolicy{
+			Limits: limits.Limits{
+				AddressSpace: p.GetAddressSpace(),
+				CPUTime:      time.Duration(p.GetCpuTime()),
+				OpenFiles:    p.GetOpenFiles(),
+				Processes:    p.GetProcesses(),
+				Memory:       p.GetMemory(),
+				CPU:          p.GetCpu(),
+			},
+			Sandbox: sandbox.Config{
+				Enabled:   p.GetSandbox(),
+				ReadPaths: p.GetSandboxReadPaths(),
+				Required:  p.GetSandboxRequired(),
+			},
+		}
+	}
+	return policy
+}
+
+// Close closes the connection to the daemon, its plugins keep running
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// GetGreeting requests a greeting from a plugin of the daemon
+func (c *Client) GetGreeting(ctx context.Context, category, name string, req greetings.Request) (greetings.Greeting, error) {
+	response, err := c.svc.Greet(ctx, &pb.PluginGreetingRequest{
+		Plugin:  &pb.PluginRef{Category: category, Name: name},
+		Request: greeterpb.FromRequest(req),
+	})
+	if err != nil {
+		return greetings.Greeting{}, c.fromStatus(ctx, category, name, req.Kind, err)
+	}
+	return greeterpb.ToGreeting(response), nil
+}
+
+// GetVariants requests the variants of a greeting from a plugin of the daemon
+func (c *Client) GetVariants(ctx context.Context, category, name string, req greetings.Request) ([]greetings.Variant, error) {
+	response, err := c.svc.Variants(ctx, &pb.PluginGreetingRequest{
+		Plugin:  &pb.PluginRef{Category: category, Name: name},
+		Request: greeterpb.FromRequest(req),
+	})
+	if err != nil {
+		return nil, c.fromStatus(ctx, category, name, req.Kind, err)
+	}
+	return greeterpb.ToVariants(response), nil
+}
+
+// ListKinds returns the greeting kinds supported by a plugin of the daemon
+func (c *Client) ListKinds(ctx context.Context, category, name string) ([]string, error) {
+	response, err := c.svc.ListKinds(ctx, &pb.PluginRef{Category: category, Name: name})
+	if err != nil {
+		return nil, c.fromStatus(ctx, category, name, "", err)
+	}
+	return response.GetKinds(), nil
+}
+
+// PluginInfo returns the metadata of a plugin of the daemon
+func (c *Client) PluginInfo(ctx context.Context, category, name string) (*greetings.Info, error) {
+	response, err := c.svc.GetInfo(ctx, &pb.PluginRef{Category: category, Name: name})
+	if err != nil {
+		return nil, c.fromStatus(ctx, category, name, "", err)
+	}
+	return greeterpb.ToInfo(response), nil
+}
+
+// Health returns the serving status of a plugin of the daemon
+func (c *Client) Health(ctx context.Context, category, name string) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	response, err := c.svc.Health(ctx, &pb.PluginRef{Category: category, Name: name})
+	if err != nil {
+		return healthpb.HealthCheckResponse_UNKNOWN, c.fromStatus(ctx, category, name, "", err)
+	}
+	return healthpb.HealthCheckResponse_ServingStatus(response.GetStatus()), nil
+}
+
+// StopPlugin stops a plugin of the daemon
+func (c *Client) StopPlugin(ctx context.Context, category, name string) error {
+	if _, err := c.svc.Stop(ctx, &pb.PluginRef{Category: category, Name: name}); err != nil {
+		return c.fromStatus(ctx, category, name, "", err)
+	}
+	return nil
+}
+
+// fromStatus converts an error of the daemon back to the error the plugin
+// manager would have returned, see toStatus. The message of the daemon is
+// kept, and the rebuilt error can be told with errors.As.
+func (c *Client) fromStatus(ctx context.Context, category, name, kind string, err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			if rebuilt := fromErrorInfo(info); rebuilt != nil {
+				return &remoteError{msg: st.Message(), err: rebuilt}
+			}
+		}
+	}
+	if st.Code() == codes.Unimplemented && kind != "" {
+		// Report what the plugin does provide, like the plugin manager does
+		supported, _ := c.ListKinds(ctx, category, name)
+		return &greetings.UnsupportedKindError{Kind: kind, Language: name, Supported: supported}
+	}
+	if st.Code() == codes.DeadlineExceeded {
+		return fmt.Errorf("%s: %w", st.Message(), context.DeadlineExceeded)
+	}
+	return errors.New(st.Message())
+}
@@ -0,0 +1,177 @@
+package daemon
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin"
+	"github.com/unsuman/greeter/pkg/plugin/limits"
+	"github.com/unsuman/greeter/pkg/plugin/manifest"
+	"github.com/unsuman/greeter/pkg/plugin/sandbox"
+	"github.com/unsuman/greeter/pkg/plugin/searchpath"
+	"github.com/unsuman/greeter/pkg/plugin/signing"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// errorDomain is the domain of the error details the daemon attaches to
+// its errors, so that clients can rebuild the errors of the plugin manager
+const errorDomain = "greeter.daemon"
+
+// Reasons of the error details, one per error type of the plugin manager
+const (
+	reasonUnsupportedKind    = "UNSUPPORTED_KIND"
+	reasonNotFound           = "PLUGIN_NOT_FOUND"
+	reasonRefused            = "SIGNATURE_REFUSED"
+	reasonInvalidManifest    = "MANIFEST_INVALID"
+	reasonIncompatible       = "MANIFEST_INCOMPATIBLE"
+	reasonQuarantined        = "QUARANTINED"
+	reasonExceeded           = "LIMIT_EXCEEDED"
+	reasonSandboxUnsupported = "SANDBOX_UNSUPPORTED"
+)
+
+// listSeparator joins the lists of the error details
+const listSeparator = "\n"
+
+// remoteError is an error of the daemon: it keeps the message of the
+// daemon and wraps the error the plugin manager of the daemon returned
+type remoteError struct {
+	msg string
+	err error
+}
+
+func (e *remoteError) Error() string {
+	return e.msg
+}
+
+func (e *remoteError) Unwrap() error {
+	return e.err
+}
+
+// errorInfo describes an error of the plugin manager, nil for other errors
+func errorInfo(err error) *errdetails.ErrorInfo {
+	var (
+		unsupported  *greetings.UnsupportedKindError
+		notFound     *plugin.NotFoundError
+		refused      *signing.VerificationError
+		invalid      *manifest.InvalidError
+		incompatible *manifest.IncompatibleError
+		quarantined  *plugin.QuarantinedError
+		exceeded     *limits.ExceededError
+		unsandboxed  *sandbox.UnsupportedError
+	)
+	info := &errdetails.ErrorInfo{Domain: errorDomain}
+	switch {
+	case errors.As(err, &unsupported):
+		info.Reason = reasonUnsupportedKind
+		info.Metadata = map[string]string{
+			"kind":      unsupported.Kind,
+			"language":  unsupported.Language,
+			"supported": strings.Join(unsupported.Supported, listSeparator),
+		}
+	case errors.As(err, &notFound):
+		var dirs, sources []string
+		for _, dir := range notFound.SearchPath {
+			dirs = append(dirs, dir.Path)
+			sources = append(sources, dir.Source)
+		}
+		info.Reason = reasonNotFound
+		info.Metadata = map[string]string{
+			"category":    notFound.Category,
+			"name":        notFound.Name,
+			"search_path": strings.Join(dirs, listSeparator),
+			"sources":     strings.Join(sources, listSeparator),
+		}
+	case errors.As(err, &refused):
+		info.Reason = reasonRefused
+		info.Metadata = map[string]string{"plugin": refused.Plugin, "path": refused.Path, "error": errString(refused.Err)}
+	case errors.As(err, &invalid):
+		info.Reason = reasonInvalidManifest
+		info.Metadata = map[string]string{"plugin": invalid.Plugin, "path": invalid.Path, "error": errString(invalid.Err)}
+	case errors.As(err, &incompatible):
+		info.Reason = reasonIncompatible
+		info.Metadata = map[string]string{"plugin": incompatible.Plugin, "reason": incompatible.Reason}
+	case errors.As(err, &quarantined):
+		info.Reason = reasonQuarantined
+		info.Metadata = map[string]string{
+			"plugin":     quarantined.Plugin,
+			"crashes":    strconv.Itoa(quarantined.Crashes),
+			"window":     quarantined.Window.String(),
+			"until":      quarantined.Until.Format(time.RFC3339Nano),
+			"last_error": quarantined.LastError,
+		}
+	case errors.As(err, &exceeded):
+		info.Reason = reasonExceeded
+		info.Metadata = map[string]string{"plugin": exceeded.Plugin, "limit": exceeded.Limit, "value": exceeded.Value, "error": errString(exceeded.Err)}
+	case errors.As(err, &unsandboxed):
+		info.Reason = reasonSandboxUnsupported
+		info.Metadata = map[string]string{"plugin": unsandboxed.Plugin, "missing": strings.Join(unsandboxed.Missing, listSeparator)}
+	default:
+		return nil
+	}
+	return info
+}
+
+// fromErrorInfo rebuilds the error of the plugin manager info describes,
+// nil if info is not one of errorInfo
+func fromErrorInfo(info *errdetails.ErrorInfo) error {
+	if info.GetDomain() != errorDomain {
+		return nil
+	}
+	m := info.GetMetadata()
+	switch info.GetReason() {
+	case reasonUnsupportedKind:
+		return &greetings.UnsupportedKindError{Kind: m["kind"], Language: m["language"], Supported: splitList(m["supported"])}
+	case reasonNotFound:
+		var path searchpath.Path
+		sources := splitList(m["sources"])
+		for i, dir := range splitList(m["search_path"]) {
+			var source string
+			if i < len(sources) {
+				source = sources[i]
+			}
+			path = append(path, searchpath.Dir{Path: dir, Source: source})
+		}
+		return &plugin.NotFoundError{Category: m["category"], Name: m["name"], SearchPath: path}
+	case reasonRefused:
+		err := errors.New(m["error"])
+		for _, sentinel := range []error{signing.ErrUnsigned, signing.ErrUntrusted} {
+			if m["error"] == sentinel.Error() {
+				err = sentinel
+			}
+		}
+		return &signing.VerificationError{Plugin: m["plugin"], Path: m["path"], Err: err}
+	case reasonInvalidManifest:
+		return &manifest.InvalidError{Plugin: m["plugin"], Path: m["path"], Err: errors.New(m["error"])}
+	case reasonIncompatible:
+		return &manifest.IncompatibleError{Plugin: m["plugin"], Reason: m["reason"]}
+	case reasonQuarantined:
+		crashes, _ := strconv.Atoi(m["crashes"])
+		window, _ := time.ParseDuration(m["window"])
+		until, _ := time.Parse(time.RFC3339Nano, m["until"])
+		return &plugin.QuarantinedError{Plugin: m["plugin"], Crashes: crashes, Window: window, Until: until, LastError: m["last_error"]}
+	case reasonExceeded:
+		return &limits.ExceededError{Plugin: m["plugin"], Limit: m["limit"], Value: m["value"], Err: errors.New(m["error"])}
+	case reasonSandboxUnsupported:
+		return &sandbox.UnsupportedError{Plugin: m["plugin"], Missing: splitList(m["missing"])}
+	}
+	return nil
+}
+
+// splitList splits a list joined by errorInfo, nil if it is empty
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, listSeparator)
+}
+
+// errString returns the message of err, empty if err is nil
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,75 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin"
+	"github.com/unsuman/greeter/pkg/plugin/limits"
+	"github.com/unsuman/greeter/pkg/plugin/manifest"
+	"github.com/unsuman/greeter/pkg/plugin/sandbox"
+	"github.com/unsuman/greeter/pkg/plugin/searchpath"
+	"github.com/unsuman/greeter/pkg/plugin/signing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusRoundTrip(t *testing.T) {
+	tests := []struct {
+		code codes.Code
+		err  error
+	}{
+		{codes.Unimplemented, &greetings.UnsupportedKindError{Kind: "goodnight", Language: "hindi", Supported: []string{"hello", "goodmorning"}}},
+		{codes.NotFound, &plugin.NotFoundError{Category: "lang", Name: "klingon", SearchPath: searchpath.Path{
+			{Path: "/opt/greeter", Source: searchpath.SourceFlag},
+			{Path: "/usr/share/greeter", Source: searchpath.SourceDataDirs},
+		}}},
+		{codes.PermissionDenied, &signing.VerificationError{Plugin: "hindi", Path: "/usr/share/greeter/lang/hindi.sig", Err: signing.ErrUnsigned}},
+		{codes.PermissionDenied, &signing.VerificationError{Plugin: "hindi", Path: "/usr/share/greeter/lang/hindi.sig", Err: errors.New("malformed signature")}},
+		{codes.PermissionDenied, &manifest.InvalidError{Plugin: "hindi", Path: "/usr/share/greeter/lang/hindi.plugin.json", Err: errors.New("checksum mismatch")}},
+		{codes.FailedPrecondition, &manifest.IncompatibleError{Plugin: "hindi", Reason: "requires greeter 9.0 or later, this is 1.0.0"}},
+		{codes.FailedPrecondition, &plugin.QuarantinedError{Plugin: "hindi", Crashes: 5, Window: time.Minute, Until: time.Date(2026, 10, 17, 12, 30, 0, 0, time.UTC), LastError: "exit status 2"}},
+		{codes.FailedPrecondition, &sandbox.UnsupportedError{Plugin: "hindi", Missing: []string{"Landlock: not supported by the kernel", "seccomp: not permitted"}}},
+		{codes.ResourceExhausted, &limits.ExceededError{Plugin: "hindi", Limit: "memory", Value: "64M", Err: errors.New("signal: killed")}},
+	}
+	client := &Client{}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %T", tt.code, tt.err), func(t *testing.T) {
+			// The manager wraps the errors of starting a plugin
+			wrapped := fmt.Errorf("plugin lang-hindi is not running and could not be started: %w", tt.err)
+			st := toStatus(wrapped)
+			if code := status.Code(st); code != tt.code {
+				t.Errorf("code = %s, want %s", code, tt.code)
+			}
+
+			err := client.fromStatus(context.Background(), "lang", "hindi", "goodnight", st)
+			if err.Error() != wrapped.Error() {
+				t.Errorf("got message %q, want %q", err, wrapped)
+			}
+			rebuilt := reflect.New(reflect.TypeOf(tt.err))
+			if !errors.As(err, rebuilt.Interface()) {
+				t.Fatalf("got %v, want a %T", err, tt.err)
+			}
+			if got := rebuilt.Elem().Interface(); !reflect.DeepEqual(got, tt.err) {
+				t.Errorf("rebuilt %#v, want %#v", got, tt.err)
+			}
+		})
+	}
+}
+
+func TestStatusRoundTripUntyped(t *testing.T) {
+	client := &Client{}
+	err := client.fromStatus(context.Background(), "lang", "hindi", "", toStatus(fmt.Errorf("calling plugin: %w", context.DeadlineExceeded)))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want a deadline error", err)
+	}
+	err = client.fromStatus(context.Background(), "lang", "hindi", "", toStatus(errors.New("plugin crashed")))
+	if err.Error() != "plugin crashed" {
+		t.Errorf("got %v, want the message kept", err)
+	}
+}
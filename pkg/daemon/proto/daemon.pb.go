@@ -0,0 +1,680 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: pkg/daemon/proto/daemon.proto
+
+package proto
+
+import (
+	proto "github.com/unsuman/greeter/pkg/plugin/proto"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// PluginRef names a plugin
+type PluginRef struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Category of the plugin, e.g. "lang"
+	Category      string `protobuf:"bytes,1,opt,name=category,proto3" json:"category,omitempty"`
+	Name          string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PluginRef) Reset() {
+	*x = PluginRef{}
+	mi := &file_pkg_daemon_proto_daemon_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PluginRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PluginRef) ProtoMessage() {}
+
+func (x *PluginRef) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_proto_daemon_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PluginRef.ProtoReflect.Descriptor instead.
+func (*PluginRef) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_proto_daemon_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PluginRef) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *PluginRef) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// PluginGreetingRequest asks a plugin for a greeting
+type PluginGreetingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Plugin        *PluginRef             `protobuf:"bytes,1,opt,name=plugin,proto3" json:"plugin,omitempty"`
+	Request       *proto.GreetingRequest `protobuf:"bytes,2,opt,name=request,proto3" json:"request,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PluginGreetingRequest) Reset() {
+	*x = PluginGreetingRequest{}
+	mi := &file_pkg_daemon_proto_daemon_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PluginGreetingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PluginGreetingRequest) ProtoMessage() {}
+
+func (x *PluginGreetingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_proto_daemon_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PluginGreetingRequest.ProtoReflect.Descriptor instead.
+func (*PluginGreetingRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_proto_daemon_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PluginGreetingRequest) GetPlugin() *PluginRef {
+	if x != nil {
+		return x.Plugin
+	}
+	return nil
+}
+
+func (x *PluginGreetingRequest) GetRequest() *proto.GreetingRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+// PluginHealth is the serving status of a plugin
+type PluginHealth struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// grpc.health.v1.HealthCheckResponse.ServingStatus value
+	Status        int32 `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PluginHealth) Reset() {
+	*x = PluginHealth{}
+	mi := &file_pkg_daemon_proto_daemon_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PluginHealth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PluginHealth) ProtoMessage() {}
+
+func (x *PluginHealth) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_proto_daemon_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PluginHealth.ProtoReflect.Descriptor instead.
+func (*PluginHealth) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_proto_daemon_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PluginHealth) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+// DaemonStatus describes a running daemon
+type DaemonStatus struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Greeter release of the daemon
+	Version string `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Pid     int64  `protobuf:"varint,2,opt,name=pid,proto3" json:"pid,omitempty"`
+	// Directories the daemon looks plugins up in, in order
+	SearchPath []string `protobuf:"bytes,3,rep,name=search_path,json=searchPath,proto3" json:"search_path,omitempty"`
+	// Running plugins as "category/name"
+	Running []string `protobuf:"bytes,4,rep,name=running,proto3" json:"running,omitempty"`
+	// Instance pools of the running plugins
+	Pools []*PoolStats `protobuf:"bytes,5,rep,name=pools,proto3" json:"pools,omitempty"`
+	// Signature verification mode, e.g. "enforce", and fingerprints of the trusted keys, sorted
+	SigningMode string   `protobuf:"bytes,6,opt,name=signing_mode,json=signingMode,proto3" json:"signing_mode,omitempty"`
+	TrustedKeys []string `protobuf:"bytes,7,rep,name=trusted_keys,json=trustedKeys,proto3" json:"trusted_keys,omitempty"`
+	// Cgroup delegated to the daemon for the memory and CPU limits of plugins
+	PluginCgroup string `protobuf:"bytes,8,opt,name=plugin_cgroup,json=pluginCgroup,proto3" json:"plugin_cgroup,omitempty"`
+	// Limits and sandbox of the plugins configured with some, sorted by name
+	Policies      []*PluginPolicy `protobuf:"bytes,9,rep,name=policies,proto3" json:"policies,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DaemonStatus) Reset() {
+	*x = DaemonStatus{}
+	mi := &file_pkg_daemon_proto_daemon_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DaemonStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DaemonStatus) ProtoMessage() {}
+
+func (x *DaemonStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_proto_daemon_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DaemonStatus.ProtoReflect.Descriptor instead.
+func (*DaemonStatus) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_proto_daemon_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DaemonStatus) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *DaemonStatus) GetPid() int64 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *DaemonStatus) GetSearchPath() []string {
+	if x != nil {
+		return x.SearchPath
+	}
+	return nil
+}
+
+func (x *DaemonStatus) GetRunning() []string {
+	if x != nil {
+		return x.Running
+	}
+	return nil
+}
+
+func (x *DaemonStatus) GetPools() []*PoolStats {
+	if x != nil {
+		return x.Pools
+	}
+	return nil
+}
+
+func (x *DaemonStatus) GetSigningMode() string {
+	if x != nil {
+		return x.SigningMode
+	}
+	return ""
+}
+
+func (x *DaemonStatus) GetTrustedKeys() []string {
+	if x != nil {
+		return x.TrustedKeys
+	}
+	return nil
+}
+
+func (x *DaemonStatus) GetPluginCgroup() string {
+	if x != nil {
+		return x.PluginCgroup
+	}
+	return ""
+}
+
+func (x *DaemonStatus) GetPolicies() []*PluginPolicy {
+	if x != nil {
+		return x.Policies
+	}
+	return nil
+}
+
+// PluginPolicy is what the daemon enforces on the processes of a plugin
+type PluginPolicy struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Resource limits, 0 for none: bytes of address space, nanoseconds of CPU
+	// time, file descriptors and processes, bytes of memory and CPUs
+	AddressSpace uint64  `protobuf:"varint,2,opt,name=address_space,json=addressSpace,proto3" json:"address_space,omitempty"`
+	CpuTime      int64   `protobuf:"varint,3,opt,name=cpu_time,json=cpuTime,proto3" json:"cpu_time,omitempty"`
+	OpenFiles    uint64  `protobuf:"varint,4,opt,name=open_files,json=openFiles,proto3" json:"open_files,omitempty"`
+	Processes    uint64  `protobuf:"varint,5,opt,name=processes,proto3" json:"processes,omitempty"`
+	Memory       uint64  `protobuf:"varint,6,opt,name=memory,proto3" json:"memory,omitempty"`
+	Cpu          float64 `protobuf:"fixed64,7,opt,name=cpu,proto3" json:"cpu,omitempty"`
+	// Sandbox confining the plugin
+	Sandbox          bool     `protobuf:"varint,8,opt,name=sandbox,proto3" json:"sandbox,omitempty"`
+	SandboxReadPaths []string `protobuf:"bytes,9,rep,name=sandbox_read_paths,json=sandboxReadPaths,proto3" json:"sandbox_read_paths,omitempty"`
+	SandboxRequired  bool     `protobuf:"varint,10,opt,name=sandbox_required,json=sandboxRequired,proto3" json:"sandbox_required,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *PluginPolicy) Reset() {
+	*x = PluginPolicy{}
+	mi := &file_pkg_daemon_proto_daemon_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PluginPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PluginPolicy) ProtoMessage() {}
+
+func (x *PluginPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_proto_daemon_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PluginPolicy.ProtoReflect.Descriptor instead.
+func (*PluginPolicy) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_proto_daemon_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PluginPolicy) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PluginPolicy) GetAddressSpace() uint64 {
+	if x != nil {
+		return x.AddressSpace
+	}
+	return 0
+}
+
+func (x *PluginPolicy) GetCpuTime() int64 {
+	if x != nil {
+		return x.CpuTime
+	}
+	return 0
+}
+
+func (x *PluginPolicy) GetOpenFiles() uint64 {
+	if x != nil {
+		return x.OpenFiles
+	}
+	return 0
+}
+
+func (x *PluginPolicy) GetProcesses() uint64 {
+	if x != nil {
+		return x.Processes
+	}
+	return 0
+}
+
+func (x *PluginPolicy) GetMemory() uint64 {
+	if x != nil {
+		return x.Memory
+	}
+	return 0
+}
+
+func (x *PluginPolicy) GetCpu() float64 {
+	if x != nil {
+		return x.Cpu
+	}
+	return 0
+}
+
+func (x *PluginPolicy) GetSandbox() bool {
+	if x != nil {
+		return x.Sandbox
+	}
+	return false
+}
+
+func (x *PluginPolicy) GetSandboxReadPaths() []string {
+	if x != nil {
+		return x.SandboxReadPaths
+	}
+	return nil
+}
+
+func (x *PluginPolicy) GetSandboxRequired() bool {
+	if x != nil {
+		return x.SandboxRequired
+	}
+	return false
+}
+
+// PoolStats describes the instances of a running plugin
+type PoolStats struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Plugin *PluginRef             `protobuf:"bytes,1,opt,name=plugin,proto3" json:"plugin,omitempty"`
+	// Running instances, and the ones being added
+	Instances int32 `protobuf:"varint,2,opt,name=instances,proto3" json:"instances,omitempty"`
+	Starting  int32 `protobuf:"varint,3,opt,name=starting,proto3" json:"starting,omitempty"`
+	// Instances serving calls, and the calls in progress
+	Busy     int32 `protobuf:"varint,4,opt,name=busy,proto3" json:"busy,omitempty"`
+	InFlight int32 `protobuf:"varint,5,opt,name=in_flight,json=inFlight,proto3" json:"in_flight,omitempty"`
+	// Bounds of the number of instances
+	Min int32 `protobuf:"varint,6,opt,name=min,proto3" json:"min,omitempty"`
+	Max int32 `protobuf:"varint,7,opt,name=max,proto3" json:"max,omitempty"`
+	// Calls served, and the ones that found every instance busy
+	Calls  uint64 `protobuf:"varint,8,opt,name=calls,proto3" json:"calls,omitempty"`
+	Queued uint64 `protobuf:"varint,9,opt,name=queued,proto3" json:"queued,omitempty"`
+	// Instances added under load and stopped for being unused
+	ScaleUps      uint64 `protobuf:"varint,10,opt,name=scale_ups,json=scaleUps,proto3" json:"scale_ups,omitempty"`
+	ScaleDowns    uint64 `protobuf:"varint,11,opt,name=scale_downs,json=scaleDowns,proto3" json:"scale_downs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PoolStats) Reset() {
+	*x = PoolStats{}
+	mi := &file_pkg_daemon_proto_daemon_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PoolStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PoolStats) ProtoMessage() {}
+
+func (x *PoolStats) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_daemon_proto_daemon_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PoolStats.ProtoReflect.Descriptor instead.
+func (*PoolStats) Descriptor() ([]byte, []int) {
+	return file_pkg_daemon_proto_daemon_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PoolStats) GetPlugin() *PluginRef {
+	if x != nil {
+		return x.Plugin
+	}
+	return nil
+}
+
+func (x *PoolStats) GetInstances() int32 {
+	if x != nil {
+		return x.Instances
+	}
+	return 0
+}
+
+func (x *PoolStats) GetStarting() int32 {
+	if x != nil {
+		return x.Starting
+	}
+	return 0
+}
+
+func (x *PoolStats) GetBusy() int32 {
+	if x != nil {
+		return x.Busy
+	}
+	return 0
+}
+
+func (x *PoolStats) GetInFlight() int32 {
+	if x != nil {
+		return x.InFlight
+	}
+	return 0
+}
+
+func (x *PoolStats) GetMin() int32 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *PoolStats) GetMax() int32 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+func (x *PoolStats) GetCalls() uint64 {
+	if x != nil {
+		return x.Calls
+	}
+	return 0
+}
+
+func (x *PoolStats) GetQueued() uint64 {
+	if x != nil {
+		return x.Queued
+	}
+	return 0
+}
+
+func (x *PoolStats) GetScaleUps() uint64 {
+	if x != nil {
+		return x.ScaleUps
+	}
+	return 0
+}
+
+func (x *PoolStats) GetScaleDowns() uint64 {
+	if x != nil {
+		return x.ScaleDowns
+	}
+	return 0
+}
+
+var File_pkg_daemon_proto_daemon_proto protoreflect.FileDescriptor
+
+const file_pkg_daemon_proto_daemon_proto_rawDesc = "" +
+	"\n" +
+	"\x1dpkg/daemon/proto/daemon.proto\x12\x0egreeter.daemon\x1a\x1epkg/plugin/proto/greeter.proto\";\n" +
+	"\tPluginRef\x12\x1a\n" +
+	"\bcategory\x18\x01 \x01(\tR\bcategory\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"~\n" +
+	"\x15PluginGreetingRequest\x121\n" +
+	"\x06plugin\x18\x01 \x01(\v2\x19.greeter.daemon.PluginRefR\x06plugin\x122\n" +
+	"\arequest\x18\x02 \x01(\v2\x18.greeter.GreetingRequestR\arequest\"&\n" +
+	"\fPluginHealth\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\x05R\x06status\"\xcb\x02\n" +
+	"\fDaemonStatus\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x10\n" +
+	"\x03pid\x18\x02 \x01(\x03R\x03pid\x12\x1f\n" +
+	"\vsearch_path\x18\x03 \x03(\tR\n" +
+	"searchPath\x12\x18\n" +
+	"\arunning\x18\x04 \x03(\tR\arunning\x12/\n" +
+	"\x05pools\x18\x05 \x03(\v2\x19.greeter.daemon.PoolStatsR\x05pools\x12!\n" +
+	"\fsigning_mode\x18\x06 \x01(\tR\vsigningMode\x12!\n" +
+	"\ftrusted_keys\x18\a \x03(\tR\vtrustedKeys\x12#\n" +
+	"\rplugin_cgroup\x18\b \x01(\tR\fpluginCgroup\x128\n" +
+	"\bpolicies\x18\t \x03(\v2\x1c.greeter.daemon.PluginPolicyR\bpolicies\"\xbc\x02\n" +
+	"\fPluginPolicy\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12#\n" +
+	"\raddress_space\x18\x02 \x01(\x04R\faddressSpace\x12\x19\n" +
+	"\bcpu_time\x18\x03 \x01(\x03R\acpuTime\x12\x1d\n" +
+	"\n" +
+	"open_files\x18\x04 \x01(\x04R\topenFiles\x12\x1c\n" +
+	"\tprocesses\x18\x05 \x01(\x04R\tprocesses\x12\x16\n" +
+	"\x06memory\x18\x06 \x01(\x04R\x06memory\x12\x10\n" +
+	"\x03cpu\x18\a \x01(\x01R\x03cpu\x12\x18\n" +
+	"\asandbox\x18\b \x01(\bR\asandbox\x12,\n" +
+	"\x12sandbox_read_paths\x18\t \x03(\tR\x10sandboxReadPaths\x12)\n" +
+	"\x10sandbox_required\x18\n" +
+	" \x01(\bR\x0fsandboxRequired\"\xb9\x02\n" +
+	"\tPoolStats\x121\n" +
+	"\x06plugin\x18\x01 \x01(\v2\x19.greeter.daemon.PluginRefR\x06plugin\x12\x1c\n" +
+	"\tinstances\x18\x02 \x01(\x05R\tinstances\x12\x1a\n" +
+	"\bstarting\x18\x03 \x01(\x05R\bstarting\x12\x12\n" +
+	"\x04busy\x18\x04 \x01(\x05R\x04busy\x12\x1b\n" +
+	"\tin_flight\x18\x05 \x01(\x05R\binFlight\x12\x10\n" +
+	"\x03min\x18\x06 \x01(\x05R\x03min\x12\x10\n" +
+	"\x03max\x18\a \x01(\x05R\x03max\x12\x14\n" +
+	"\x05calls\x18\b \x01(\x04R\x05calls\x12\x16\n" +
+	"\x06queued\x18\t \x01(\x04R\x06queued\x12\x1b\n" +
+	"\tscale_ups\x18\n" +
+	" \x01(\x04R\bscaleUps\x12\x1f\n" +
+	"\vscale_downs\x18\v \x01(\x04R\n" +
+	"scaleDowns2\xc7\x03\n" +
+	"\rDaemonService\x126\n" +
+	"\x06Status\x12\x0e.greeter.Empty\x1a\x1c.greeter.daemon.DaemonStatus\x12I\n" +
+	"\x05Greet\x12%.greeter.daemon.PluginGreetingRequest\x1a\x19.greeter.GreetingResponse\x12G\n" +
+	"\bVariants\x12%.greeter.daemon.PluginGreetingRequest\x1a\x14.greeter.VariantList\x129\n" +
+	"\tListKinds\x12\x19.greeter.daemon.PluginRef\x1a\x11.greeter.KindList\x129\n" +
+	"\aGetInfo\x12\x19.greeter.daemon.PluginRef\x1a\x13.greeter.PluginInfo\x12A\n" +
+	"\x06Health\x12\x19.greeter.daemon.PluginRef\x1a\x1c.greeter.daemon.PluginHealth\x121\n" +
+	"\x04Stop\x12\x19.greeter.daemon.PluginRef\x1a\x0e.greeter.EmptyB-Z+github.com/unsuman/greeter/pkg/daemon/protob\x06proto3"
+
+var (
+	file_pkg_daemon_proto_daemon_proto_rawDescOnce sync.Once
+	file_pkg_daemon_proto_daemon_proto_rawDescData []byte
+)
+
+func file_pkg_daemon_proto_daemon_proto_rawDescGZIP() []byte {
+	file_pkg_daemon_proto_daemon_proto_rawDescOnce.Do(func() {
+		file_pkg_daemon_proto_daemon_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_daemon_proto_daemon_proto_rawDesc), len(file_pkg_daemon_proto_daemon_proto_rawDesc)))
+	})
+	return file_pkg_daemon_proto_daemon_proto_rawDescData
+}
+
+var file_pkg_daemon_proto_daemon_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_pkg_daemon_proto_daemon_proto_goTypes = []any{
+	(*PluginRef)(nil),              // 0: greeter.daemon.PluginRef
+	(*PluginGreetingRequest)(nil),  // 1: greeter.daemon.PluginGreetingRequest
+	(*PluginHealth)(nil),           // 2: greeter.daemon.PluginHealth
+	(*DaemonStatus)(nil),           // 3: greeter.daemon.DaemonStatus
+	(*PluginPolicy)(nil),           // 4: greeter.daemon.PluginPolicy
+	(*PoolStats)(nil),              // 5: greeter.daemon.PoolStats
+	(*proto.GreetingRequest)(nil),  // 6: greeter.GreetingRequest
+	(*proto.Empty)(nil),            // 7: greeter.Empty
+	(*proto.GreetingResponse)(nil), // 8: greeter.GreetingResponse
+	(*proto.VariantList)(nil),      // 9: greeter.VariantList
+	(*proto.KindList)(nil),         // 10: greeter.KindList
+	(*proto.PluginInfo)(nil),       // 11: greeter.PluginInfo
+}
+var file_pkg_daemon_proto_daemon_proto_depIdxs = []int32{
+	0,  // 0: greeter.daemon.PluginGreetingRequest.plugin:type_name -> greeter.daemon.PluginRef
+	6,  // 1: greeter.daemon.PluginGreetingRequest.request:type_name -> greeter.GreetingRequest
+	5,  // 2: greeter.daemon.DaemonStatus.pools:type_name -> greeter.daemon.PoolStats
+	4,  // 3: greeter.daemon.DaemonStatus.policies:type_name -> greeter.daemon.PluginPolicy
+	0,  // 4: greeter.daemon.PoolStats.plugin:type_name -> greeter.daemon.PluginRef
+	7,  // 5: greeter.daemon.DaemonService.Status:input_type -> greeter.Empty
+	1,  // 6: greeter.daemon.DaemonService.Greet:input_type -> greeter.daemon.PluginGreetingRequest
+	1,  // 7: greeter.daemon.DaemonService.Variants:input_type -> greeter.daemon.PluginGreetingRequest
+	0,  // 8: greeter.daemon.DaemonService.ListKinds:input_type -> greeter.daemon.PluginRef
+	0,  // 9: greeter.daemon.DaemonService.GetInfo:input_type -> greeter.daemon.PluginRef
+	0,  // 10: greeter.daemon.DaemonService.Health:input_type -> greeter.daemon.PluginRef
+	0,  // 11: greeter.daemon.DaemonService.Stop:input_type -> greeter.daemon.PluginRef
+	3,  // 12: greeter.daemon.DaemonService.Status:output_type -> greeter.daemon.DaemonStatus
+	8,  // 13: greeter.daemon.DaemonService.Greet:output_type -> greeter.GreetingResponse
+	9,  // 14: greeter.daemon.DaemonService.Variants:output_type -> greeter.VariantList
+	10, // 15: greeter.daemon.DaemonService.ListKinds:output_type -> greeter.KindList
+	11, // 16: greeter.daemon.DaemonService.GetInfo:output_type -> greeter.PluginInfo
+	2,  // 17: greeter.daemon.DaemonService.Health:output_type -> greeter.daemon.PluginHealth
+	7,  // 18: greeter.daemon.DaemonService.Stop:output_type -> greeter.Empty
+	12, // [12:19] is the sub-list for method output_type
+	5,  // [5:12] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_pkg_daemon_proto_daemon_proto_init() }
+func file_pkg_daemon_proto_daemon_proto_init() {
+	if File_pkg_daemon_proto_daemon_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_daemon_proto_daemon_proto_rawDesc), len(file_pkg_daemon_proto_daemon_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_daemon_proto_daemon_proto_goTypes,
+		DependencyIndexes: file_pkg_daemon_proto_daemon_proto_depIdxs,
+		MessageInfos:      file_pkg_daemon_proto_daemon_proto_msgTypes,
+	}.Build()
+	File_pkg_daemon_proto_daemon_proto = out.File
+	file_pkg_daemon_proto_daemon_proto_goTypes = nil
+	file_pkg_daemon_proto_daemon_proto_depIdxs = nil
+}
@@ -0,0 +1,370 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: pkg/daemon/proto/daemon.proto
+
+package proto
+
+import (
+	context "context"
+	proto "github.com/unsuman/greeter/pkg/plugin/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	DaemonService_Status_FullMethodName    = "/greeter.daemon.DaemonService/Status"
+	DaemonService_Greet_FullMethodName     = "/greeter.daemon.DaemonService/Greet"
+	DaemonService_Variants_FullMethodName  = "/greeter.daemon.DaemonService/Variants"
+	DaemonService_ListKinds_FullMethodName = "/greeter.daemon.DaemonService/ListKinds"
+	DaemonService_GetInfo_FullMethodName   = "/greeter.daemon.DaemonService/GetInfo"
+	DaemonService_Health_FullMethodName    = "/greeter.daemon.DaemonService/Health"
+	DaemonService_Stop_FullMethodName      = "/greeter.daemon.DaemonService/Stop"
+)
+
+// DaemonServiceClient is the client API for DaemonService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// DaemonService is served by greeterd on a Unix socket. It gives the greeter
+// CLI access to the plugins greeterd keeps running between invocations.
+type DaemonServiceClient interface {
+	// Status describes the daemon, so clients can check it serves the same plugins they would
+	Status(ctx context.Context, in *proto.Empty, opts ...grpc.CallOption) (*DaemonStatus, error)
+	// Greet returns a greeting of a plugin, starting the plugin if needed
+	Greet(ctx context.Context, in *PluginGreetingRequest, opts ...grpc.CallOption) (*proto.GreetingResponse, error)
+	// Variants returns the variants of a greeting of a plugin
+	Variants(ctx context.Context, in *PluginGreetingRequest, opts ...grpc.CallOption) (*proto.VariantList, error)
+	// ListKinds returns the greeting kinds supported by a plugin
+	ListKinds(ctx context.Context, in *PluginRef, opts ...grpc.CallOption) (*proto.KindList, error)
+	// GetInfo returns the metadata of a plugin
+	GetInfo(ctx context.Context, in *PluginRef, opts ...grpc.CallOption) (*proto.PluginInfo, error)
+	// Health returns the serving status of a plugin
+	Health(ctx context.Context, in *PluginRef, opts ...grpc.CallOption) (*PluginHealth, error)
+	// Stop stops a running plugin
+	Stop(ctx context.Context, in *PluginRef, opts ...grpc.CallOption) (*proto.Empty, error)
+}
+
+type daemonServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDaemonServiceClient(cc grpc.ClientConnInterface) DaemonServiceClient {
+	return &daemonServiceClient{cc}
+}
+
+func (c *daemonServiceClient) Status(ctx context.Context, in *proto.Empty, opts ...grpc.CallOption) (*DaemonStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DaemonStatus)
+	err := c.cc.Invoke(ctx, DaemonService_Status_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) Greet(ctx context.Context, in *PluginGreetingRequest, opts ...grpc.CallOption) (*proto.GreetingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(proto.GreetingResponse)
+	err := c.cc.Invoke(ctx, DaemonService_Greet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) Variants(ctx context.Context, in *PluginGreetingRequest, opts ...grpc.CallOption) (*proto.VariantList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(proto.VariantList)
+	err := c.cc.Invoke(ctx, DaemonService_Variants_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) ListKinds(ctx context.Context, in *PluginRef, opts ...grpc.CallOption) (*proto.KindList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(proto.KindList)
+	err := c.cc.Invoke(ctx, DaemonService_ListKinds_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) GetInfo(ctx context.Context, in *PluginRef, opts ...grpc.CallOption) (*proto.PluginInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(proto.PluginInfo)
+	err := c.cc.Invoke(ctx, DaemonService_GetInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) Health(ctx context.Context, in *PluginRef, opts ...grpc.CallOption) (*PluginHealth, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PluginHealth)
+	err := c.cc.Invoke(ctx, DaemonService_Health_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) Stop(ctx context.Context, in *PluginRef, opts ...grpc.CallOption) (*proto.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(proto.Empty)
+	err := c.cc.Invoke(ctx, DaemonService_Stop_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DaemonServiceServer is the server API for DaemonService service.
+// All implementations must embed UnimplementedDaemonServiceServer
+// for forward compatibility.
+//
+// DaemonService is served by greeterd on a Unix socket. It gives the greeter
+// CLI access to the plugins greeterd keeps running between invocations.
+type DaemonServiceServer interface {
+	// Status describes the daemon, so clients can check it serves the same plugins they would
+	Status(context.Context, *proto.Empty) (*DaemonStatus, error)
+	// Greet returns a greeting of a plugin, starting the plugin if needed
+	Greet(context.Context, *PluginGreetingRequest) (*proto.GreetingResponse, error)
+	// Variants returns the variants of a greeting of a plugin
+	Variants(context.Context, *PluginGreetingRequest) (*proto.VariantList, error)
+	// ListKinds returns the greeting kinds supported by a plugin
+	ListKinds(context.Context, *PluginRef) (*proto.KindList, error)
+	// GetInfo returns the metadata of a plugin
+	GetInfo(context.Context, *PluginRef) (*proto.PluginInfo, error)
+	// Health returns the serving status of a plugin
+	Health(context.Context, *PluginRef) (*PluginHealth, error)
+	// Stop stops a running plugin
+	Stop(context.Context, *PluginRef) (*proto.Empty, error)
+	mustEmbedUnimplementedDaemonServiceServer()
+}
+
+// UnimplementedDaemonServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDaemonServiceServer struct{}
+
+func (UnimplementedDaemonServiceServer) Status(context.Context, *proto.Empty) (*DaemonStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (UnimplementedDaemonServiceServer) Greet(context.Context, *PluginGreetingRequest) (*proto.GreetingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Greet not implemented")
+}
+func (UnimplementedDaemonServiceServer) Variants(context.Context, *PluginGreetingRequest) (*proto.VariantList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Variants not implemented")
+}
+func (UnimplementedDaemonServiceServer) ListKinds(context.Context, *PluginRef) (*proto.KindList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListKinds not implemented")
+}
+func (UnimplementedDaemonServiceServer) GetInfo(context.Context, *PluginRef) (*proto.PluginInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInfo not implemented")
+}
+func (UnimplementedDaemonServiceServer) Health(context.Context, *PluginRef) (*PluginHealth, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Health not implemented")
+}
+func (UnimplementedDaemonServiceServer) Stop(context.Context, *PluginRef) (*proto.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stop not implemented")
+}
+func (UnimplementedDaemonServiceServer) mustEmbedUnimplementedDaemonServiceServer() {}
+func (UnimplementedDaemonServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeDaemonServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DaemonServiceServer will
+// result in compilation errors.
+type UnsafeDaemonServiceServer interface {
+	mustEmbedUnimplementedDaemonServiceServer()
+}
+
+func RegisterDaemonServiceServer(s grpc.ServiceRegistrar, srv DaemonServiceServer) {
+	// If the following call pancis, it indicates UnimplementedDaemonServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&DaemonService_ServiceDesc, srv)
+}
+
+func _DaemonService_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(proto.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DaemonService_Status_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).Status(ctx, req.(*proto.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_Greet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginGreetingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).Greet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DaemonService_Greet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).Greet(ctx, req.(*PluginGreetingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_Variants_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginGreetingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).Variants(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DaemonService_Variants_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).Variants(ctx, req.(*PluginGreetingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_ListKinds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).ListKinds(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DaemonService_ListKinds_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).ListKinds(ctx, req.(*PluginRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_GetInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).GetInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DaemonService_GetInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).GetInfo(ctx, req.(*PluginRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).Health(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DaemonService_Health_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).Health(ctx, req.(*PluginRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PluginRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).Stop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DaemonService_Stop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).Stop(ctx, req.(*PluginRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// DaemonService_ServiceDesc is the grpc.ServiceDesc for DaemonService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DaemonService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "greeter.daemon.DaemonService",
+	HandlerType: (*DaemonServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Status",
+			Handler:    _DaemonService_Status_Handler,
+		},
+		{
+			MethodName: "Greet",
+			Handler:    _DaemonService_Greet_Handler,
+		},
+		{
+			MethodName: "Variants",
+			Handler:    _DaemonService_Variants_Handler,
+		},
+		{
+			MethodName: "ListKinds",
+			Handler:    _DaemonService_ListKinds_Handler,
+		},
+		{
+			MethodName: "GetInfo",
+			Handler:    _DaemonService_GetInfo_Handler,
+		},
+		{
+			MethodName: "Health",
+			Handler:    _DaemonService_Health_Handler,
+		},
+		{
+			MethodName: "Stop",
+			Handler:    _DaemonService_Stop_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/daemon/proto/daemon.proto",
+}
@@ -0,0 +1,245 @@
+// Package daemon serves the plugins of a PluginManager on a Unix socket, so
+// that plugins keep running between invocations of the greeter CLI.
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	pb "github.com/unsuman/greeter/pkg/daemon/proto"
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin"
+	"github.com/unsuman/greeter/pkg/plugin/limits"
+	"github.com/unsuman/greeter/pkg/plugin/manifest"
+	greeterpb "github.com/unsuman/greeter/pkg/plugin/proto"
+	"github.com/unsuman/greeter/pkg/plugin/sandbox"
+	"github.com/unsuman/greeter/pkg/plugin/signing"
+	"github.com/unsuman/greeter/pkg/version"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements the daemon service over the plugins of a PluginManager
+type Server struct {
+	pb.UnimplementedDaemonServiceServer
+	pluginMgr *plugin.PluginManager
+	logger    *logrus.Logger
+}
+
+// NewServer creates a server for the plugins of pluginMgr
+func NewServer(logger *logrus.Logger, pluginMgr *plugin.PluginManager) *Server {
+	return &Server{pluginMgr: pluginMgr, logger: logger}
+}
+
+// Serve accepts connections on the socket at path until the gRPC server is
+// stopped. The socket is only accessible to the current user.
+func (s *Server) Serve(grpcServer *grpc.Server, path string) error {
+	listener, err := Listen(path)
+	if err != nil {
+		return err
+	}
+
+	pb.RegisterDaemonServiceServer(grpcServer, s)
+	s.logger.Infof("Listening on %s", path)
+	return grpcServer.Serve(listener)
+}
+
+// Listen creates the socket at path, replacing a stale socket left by a
+// daemon that did not exit cleanly. It fails if a daemon is listening there,
+// or if the directory of the default socket is not private to the user.
+func Listen(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	if err := checkSocketDir(path); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		if client, err := Dial(path, time.Second); err == nil {
+			client.Close()
+			return nil, fmt.Errorf("greeterd is already running on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// Status describes the daemon
+func (s *Server) Status(ctx context.Context, empty *greeterpb.Empty) (*pb.DaemonStatus, error) {
+	running := s.pluginMgr.RunningPlugins()
+	sort.Strings(running)
+
+	var searchPath []string
+	for _, dir := range s.pluginMgr.SearchPath() {
+		searchPath = append(searchPath, dir.Path)
+	}
+
+	var pools []*pb.PoolStats
+	for _, stats := range s.pluginMgr.PoolStats() {
+		pools = append(pools, &pb.PoolStats{
+			Plugin:     &pb.PluginRef{Category: stats.Category, Name: stats.Name},
+			Instances:  int32(stats.Instances),
+			Starting:   int32(stats.Starting),
+			Busy:       int32(stats.Busy),
+			InFlight:   int32(stats.InFlight),
+			Min:        int32(stats.Min),
+			Max:        int32(stats.Max),
+			Calls:      stats.Calls,
+			Queued:     stats.Queued,
+			ScaleUps:   stats.ScaleUps,
+			ScaleDowns: stats.ScaleDowns,
+		})
+	}
+
+	policy := s.pluginMgr.Policy()
+	var policies []*pb.PluginPolicy
+	for name, p := range policy.Plugins {
+		policies = append(policies, &pb.PluginPolicy{
+			Name:             name,
+			AddressSpace:     p.Limits.AddressSpace,
+			CpuTime:          int64(p.Limits.CPUTime),
+			OpenFiles:        p.Limits.OpenFiles,
+			Processes:        p.Limits.Processes,
+			Memory:           p.Limits.Memory,
+			Cpu:              p.Limits.CPU,
+			Sandbox:          p.Sandbox.Enabled,
+			SandboxReadPaths: p.Sandbox.ReadPaths,
+			SandboxRequired:  p.Sandbox.Required,
+		})
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Name < policies[j].Name
+	})
+
+	return &pb.DaemonStatus{
+		Version:      version.Version,
+		Pid:          int64(os.Getpid()),
+		SearchPath:   searchPath,
+		Running:      running,
+		Pools:        pools,
+		SigningMode:  string(policy.SignatureMode),
+		TrustedKeys:  policy.TrustedKeys,
+		PluginCgroup: policy.CgroupRoot,
+		Policies:     policies,
+	}, nil
+}
+
+// Greet serves a greeting of a plugin
+func (s *Server) Greet(ctx context.Context, req *pb.PluginGreetingRequest) (*greeterpb.GreetingResponse, error) {
+	ref := req.GetPlugin()
+	s.logger.Debugf("Received Greet request for %s/%s", ref.GetCategory(), ref.GetName())
+
+	greeting, err := s.pluginMgr.GetGreeting(ctx, ref.GetCategory(), ref.GetName(), greeterpb.ToRequest(req.GetRequest()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return greeterpb.FromGreeting(greeting), nil
+}
+
+// Variants serves the variants of a greeting of a plugin
+func (s *Server) Variants(ctx context.Context, req *pb.PluginGreetingRequest) (*greeterpb.VariantList, error) {
+	ref := req.GetPlugin()
+	s.logger.Debugf("Received Variants request for %s/%s", ref.GetCategory(), ref.GetName())
+
+	variants, err := s.pluginMgr.GetVariants(ctx, ref.GetCategory(), ref.GetName(), greeterpb.ToRequest(req.GetRequest()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return greeterpb.FromVariants(variants), nil
+}
+
+// ListKinds serves the greeting kinds supported by a plugin
+func (s *Server) ListKinds(ctx context.Context, ref *pb.PluginRef) (*greeterpb.KindList, error) {
+	kinds, err := s.pluginMgr.ListKinds(ctx, ref.GetCategory(), ref.GetName())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &greeterpb.KindList{Kinds: kinds}, nil
+}
+
+// GetInfo serves the metadata of a plugin
+func (s *Server) GetInfo(ctx context.Context, ref *pb.PluginRef) (*greeterpb.PluginInfo, error) {
+	info, err := s.pluginMgr.PluginInfo(ctx, ref.GetCategory(), ref.GetName())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return greeterpb.FromInfo(*info), nil
+}
+
+// Health serves the serving status of a plugin, UNKNOWN if it is not running
+func (s *Server) Health(ctx context.Context, ref *pb.PluginRef) (*pb.PluginHealth, error) {
+	return &pb.PluginHealth{Status: int32(s.pluginMgr.Health(ref.GetCategory(), ref.GetName()))}, nil
+}
+
+// Stop stops a running plugin
+func (s *Server) Stop(ctx context.Context, ref *pb.PluginRef) (*greeterpb.Empty, error) {
+	s.logger.Infof("Received Stop request for %s/%s", ref.GetCategory(), ref.GetName())
+
+	if err := s.pluginMgr.StopPlugin(ref.GetCategory(), ref.GetName()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &greeterpb.Empty{}, nil
+}
+
+// toStatus maps plugin manager errors to gRPC status codes, with the
+// details fromStatus rebuilds them from
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		// Errors of the plugin itself are passed through
+		return err
+	}
+
+	var (
+		unsupported  *greetings.UnsupportedKindError
+		notFound     *plugin.NotFoundError
+		refused      *signing.VerificationError
+		invalid      *manifest.InvalidError
+		incompatible *manifest.IncompatibleError
+		quarantined  *plugin.QuarantinedError
+		exceeded     *limits.ExceededError
+		unsandboxed  *sandbox.UnsupportedError
+	)
+	code := codes.Internal
+	switch {
+	case errors.As(err, &unsupported):
+		code = codes.Unimplemented
+	case errors.As(err, &notFound):
+		code = codes.NotFound
+	case errors.As(err, &refused), errors.As(err, &invalid):
+		code = codes.PermissionDenied
+	case errors.As(err, &incompatible), errors.As(err, &quarantined), errors.As(err, &unsandboxed):
+		code = codes.FailedPrecondition
+	case errors.As(err, &exceeded):
+		code = codes.ResourceExhausted
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	}
+
+	st := status.New(code, err.Error())
+	if info := errorInfo(err); info != nil {
+		if detailed, err := st.WithDetails(info); err == nil {
+			st = detailed
+		}
+	}
+	return st.Err()
+}
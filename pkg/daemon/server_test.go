@@ -0,0 +1,41 @@
+package daemon
+
+import (
+	"context"
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/unsuman/greeter/pkg/plugin"
+	"github.com/unsuman/greeter/pkg/plugin/limits"
+	"github.com/unsuman/greeter/pkg/plugin/sandbox"
+	"github.com/unsuman/greeter/pkg/plugin/searchpath"
+	"github.com/unsuman/greeter/pkg/plugin/signing"
+)
+
+func TestStatusPolicy(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger, _ := logtest.NewNullLogger()
+	pm := plugin.NewPluginManager(logger, searchpath.Path{{Path: t.TempDir(), Source: "test"}})
+	pm.SetSignatureVerification(signing.ModeEnforce, signing.Keyring{{ID: "release.pub", PublicKey: public}})
+	pm.SetCgroupRoot("/sys/fs/cgroup/greeter")
+	pm.SetPluginConfig("hindi", plugin.PluginConfig{
+		Limits:  limits.Limits{AddressSpace: 1 << 30, CPUTime: 10 * time.Second, OpenFiles: 64, Processes: 32, Memory: 64 << 20, CPU: 0.5},
+		Sandbox: sandbox.Config{Enabled: true, ReadPaths: []string{"/usr/share/greeter"}, Required: true},
+	})
+	pm.SetPluginConfig("japanese", plugin.PluginConfig{Sandbox: sandbox.Config{Enabled: true}})
+	pm.SetPluginConfig("english", plugin.PluginConfig{Timeout: time.Second})
+
+	status, err := NewServer(logger, pm).Status(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &Client{Status: status}
+	if policy := client.Policy(); !policy.Equal(pm.Policy()) || len(policy.Plugins) != 2 {
+		t.Errorf("reported policy %+v, want %+v", policy, pm.Policy())
+	}
+}
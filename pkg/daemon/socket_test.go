@@ -0,0 +1,104 @@
+//go:build unix
+
+package daemon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckPrivateDir(t *testing.T) {
+	root := t.TempDir()
+
+	private := filepath.Join(root, "private")
+	if err := os.Mkdir(private, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	shared := filepath.Join(root, "shared")
+	if err := os.Mkdir(shared, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(shared, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(root, "link")
+	if err := os.Symlink(private, link); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(root, "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkPrivateDir(private); err != nil {
+		t.Errorf("checkPrivateDir(private): %v", err)
+	}
+	for _, dir := range []string{shared, link, file} {
+		var unsafe *UnsafeDirError
+		if err := checkPrivateDir(dir); !errors.As(err, &unsafe) {
+			t.Errorf("checkPrivateDir(%s) = %v, want an UnsafeDirError", filepath.Base(dir), err)
+		}
+	}
+
+	if os.Getuid() == 0 {
+		foreign := filepath.Join(root, "foreign")
+		if err := os.Mkdir(foreign, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chown(foreign, 12345, 12345); err != nil {
+			t.Fatal(err)
+		}
+		var unsafe *UnsafeDirError
+		if err := checkPrivateDir(foreign); !errors.As(err, &unsafe) {
+			t.Errorf("checkPrivateDir(foreign) = %v, want an UnsafeDirError", err)
+		}
+	}
+}
+
+func TestListenRefusesSharedSocketDir(t *testing.T) {
+	runtime := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtime)
+
+	// Another user created the directory first
+	dir := filepath.Join(runtime, "greeter")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(dir, 0o777); err != nil {
+		t.Fatal(err)
+	}
+
+	var unsafe *UnsafeDirError
+	if _, err := Listen(SocketPath()); !errors.As(err, &unsafe) {
+		t.Fatalf("Listen = %v, want an UnsafeDirError", err)
+	}
+	if _, err := Dial(SocketPath(), 0); !errors.As(err, &unsafe) {
+		t.Fatalf("Dial = %v, want an UnsafeDirError", err)
+	}
+
+	if err := os.Chmod(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := Listen(SocketPath())
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	listener.Close()
+}
+
+func TestListenConfiguredSocket(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	// Configured sockets may live in shared directories
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := Listen(filepath.Join(dir, "greeterd.sock"))
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	listener.Close()
+}
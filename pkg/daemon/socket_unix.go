@@ -0,0 +1,28 @@
+//go:build unix
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkPrivateDir refuses a directory that is a symbolic link, belongs to
+// another user or is accessible to other users
+func checkPrivateDir(dir string) error {
+	fi, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return &UnsafeDirError{Dir: dir, Reason: "not a directory"}
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); !ok || int(st.Uid) != os.Getuid() {
+		return &UnsafeDirError{Dir: dir, Reason: "owned by another user"}
+	}
+	if perm := fi.Mode().Perm(); perm != 0o700 {
+		return &UnsafeDirError{Dir: dir, Reason: fmt.Sprintf("mode %#o instead of 0700", perm)}
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package daemon
+
+import "os"
+
+// checkPrivateDir refuses a directory that is a symbolic link. Windows
+// keeps the temporary directory of every user apart.
+func checkPrivateDir(dir string) error {
+	fi, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return &UnsafeDirError{Dir: dir, Reason: "not a directory"}
+	}
+	return nil
+}
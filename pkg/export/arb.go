@@ -0,0 +1,67 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// arbAttributes are the "@id" attributes of an ARB message
+type arbAttributes struct {
+	Description   string                    `json:"description,omitempty"`
+	Placeholders  map[string]arbPlaceholder `json:"placeholders,omitempty"`
+	Romanization  string                    `json:"x-romanization,omitempty"`
+	Pronunciation string                    `json:"x-pronunciation,omitempty"`
+	Weight        int                       `json:"x-weight,omitempty"`
+}
+
+type arbPlaceholder struct {
+	Type string `json:"type"`
+}
+
+// writeARB writes a Flutter Application Resource Bundle. ARB files are
+// ordered JSON objects, so they are written field by field.
+func writeARB(w io.Writer, c *Catalog) error {
+	var b bytes.Buffer
+	b.WriteString("{\n")
+
+	fields := [][2]any{{"@@locale", c.Info.Locale}}
+	for _, m := range c.Messages {
+		attrs := arbAttributes{
+			Description:   m.Greeting.Meaning,
+			Romanization:  m.Greeting.Romanization,
+			Pronunciation: m.Greeting.Pronunciation,
+		}
+		if m.Weight > 1 {
+			attrs.Weight = m.Weight
+		}
+		if m.Named {
+			attrs.Placeholders = map[string]arbPlaceholder{"name": {Type: "String"}}
+		}
+		fields = append(fields, [2]any{m.ID, m.Greeting.Text}, [2]any{"@" + m.ID, attrs})
+	}
+
+	for i, field := range fields {
+		key, err := json.Marshal(field[0])
+		if err != nil {
+			return err
+		}
+		value, err := json.MarshalIndent(field[1], "  ", "  ")
+		if err != nil {
+			return err
+		}
+
+		b.WriteString("  ")
+		b.Write(key)
+		b.WriteString(": ")
+		b.Write(value)
+		if i < len(fields)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("}\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
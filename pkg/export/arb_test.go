@@ -0,0 +1,41 @@
+package export
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteARB(t *testing.T) {
+	var b strings.Builder
+	if err := WriteCatalog(&b, "arb", germanCatalog(t), nil); err != nil {
+		t.Fatal(err)
+	}
+	// Flutter tools expect the locale first and messages in order
+	if !strings.HasPrefix(b.String(), "{\n  \"@@locale\": \"de\",\n  \"hello\": \"Hallo!\",\n  \"@hello\": {},\n  \"hello_name\": ") {
+		t.Errorf("unexpected start of the ARB file:\n%s", b.String())
+	}
+
+	var arb map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(b.String()), &arb); err != nil {
+		t.Fatalf("invalid ARB file: %v", err)
+	}
+	var text string
+	if err := json.Unmarshal(arb["hello_formal_v2_name"], &text); err != nil || text != "Grüß Gott, {name}!" {
+		t.Errorf("hello_formal_v2_name = %q, %v", text, err)
+	}
+
+	var attrs arbAttributes
+	if err := json.Unmarshal(arb["@hello_formal_v2_name"], &attrs); err != nil {
+		t.Fatal(err)
+	}
+	want := arbAttributes{
+		Description:  "a polite greeting",
+		Placeholders: map[string]arbPlaceholder{"name": {Type: "String"}},
+		Weight:       2,
+	}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("@hello_formal_v2_name = %+v, want %+v", attrs, want)
+	}
+}
@@ -0,0 +1,225 @@
+// Package export writes the greetings of languages to standard i18n formats
+// (gettext PO, XLIFF 1.2, Flutter ARB, JSON and CSV) so apps can reuse them.
+//
+// Every greeting kind and register of a language becomes two messages: the
+// generic greeting, e.g. "hello", and the greeting addressed to someone,
+// e.g. "hello_name", where the recipient is the {name} placeholder. Registers
+// other than neutral are exported when they differ from every register
+// before them, e.g. "hello_formal" and "hello_formal_name": a language
+// lacking a register falls back to another one. Further variants of a
+// greeting are numbered from 2, e.g. "hello_casual_v2".
+package export
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/unsuman/greeter/pkg/greetings"
+)
+
+// Formats lists the supported export formats
+var Formats = []string{"po", "xliff", "arb", "json", "csv"}
+
+// nameSuffix marks the messages addressed to a recipient
+const nameSuffix = "_name"
+
+// Message is a greeting of a language
+type Message struct {
+	// ID identifies the message across languages, e.g. "hello_formal_name"
+	ID        string
+	Kind      string
+	Formality greetings.Formality
+	// Variant is the index of the variant of the greeting, 0 for the default one
+	Variant int
+	Weight  int
+	// Named is set when the greeting is addressed to greetings.NamePlaceholder
+	Named    bool
+	Greeting greetings.Greeting
+}
+
+// Catalog holds the greetings of a language
+type Catalog struct {
+	Info     greetings.Info
+	Messages []Message
+}
+
+// registers lists the registers in export order
+var registers = []greetings.Formality{greetings.Neutral, greetings.Casual, greetings.Formal, greetings.Honorific}
+
+// Collect renders every variant of every greeting kind and register
+// declared by info with variants, see greetings.VariantsOf
+func Collect(info greetings.Info, variants func(greetings.Request) ([]greetings.Variant, error)) (*Catalog, error) {
+	c := &Catalog{Info: info}
+
+	kinds := append([]string(nil), info.Kinds...)
+	sort.Strings(kinds)
+
+	for _, kind := range kinds {
+		var exported [][2][]greetings.Variant
+		for _, formality := range registers {
+			var forms [2][]greetings.Variant
+			for i, recipient := range []string{"", greetings.NamePlaceholder} {
+				v, err := variants(greetings.Request{Kind: kind, Recipient: recipient, Formality: formality})
+				if err != nil {
+					return nil, fmt.Errorf("failed to render %s (%s): %w", kind, formality, err)
+				}
+				forms[i] = v
+			}
+
+			// Missing registers fall back to another one, e.g. honorific
+			// to formal, only keep real ones
+			if slices.ContainsFunc(exported, func(e [2][]greetings.Variant) bool { return reflect.DeepEqual(e, forms) }) {
+				continue
+			}
+			exported = append(exported, forms)
+
+			for n := range forms[0] {
+				for i := range forms {
+					if n >= len(forms[i]) {
+						continue
+					}
+					c.Messages = append(c.Messages, Message{
+						ID:        messageID(kind, formality, n, i == 1),
+						Kind:      kind,
+						Formality: formality,
+						Variant:   n,
+						Weight:    forms[i][n].Weight,
+						Named:     i == 1,
+						Greeting:  forms[i][n].Greeting,
+					})
+				}
+			}
+		}
+	}
+
+	return c, nil
+}
+
+func messageID(kind string, formality greetings.Formality, variant int, named bool) string {
+	id := kind
+	if formality != greetings.Neutral {
+		id += "_" + formality.String()
+	}
+	if variant > 0 {
+		id += fmt.Sprintf("_v%d", variant+1)
+	}
+	if named {
+		id += nameSuffix
+	}
+	return id
+}
+
+// Lookup returns the message with the given ID
+func (c *Catalog) Lookup(id string) (Message, bool) {
+	if c == nil {
+		return Message{}, false
+	}
+	for _, m := range c.Messages {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return Message{}, false
+}
+
+// sourceText returns the text of a message in the source catalog, falling
+// back to the message ID when the source lacks it
+func sourceText(source *Catalog, id string) string {
+	if m, ok := source.Lookup(id); ok {
+		return m.Greeting.Text
+	}
+	return id
+}
+
+// Extension returns the file extension of a format, e.g. ".xlf" for xliff
+func Extension(format string) string {
+	if format == "xliff" {
+		return ".xlf"
+	}
+	return "." + format
+}
+
+// CheckFormat rejects unknown export formats
+func CheckFormat(format string) error {
+	for _, f := range Formats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown export format %q (expected %s)", format, strings.Join(Formats, ", "))
+}
+
+// WriteCatalog writes the catalog of a single language. Source is the
+// catalog of the source language, usually English, that formats with source
+// strings (po, xliff) translate from; it may be nil.
+func WriteCatalog(w io.Writer, format string, c, source *Catalog) error {
+	if err := CheckFormat(format); err != nil {
+		return err
+	}
+
+	switch format {
+	case "po":
+		return writePO(w, c, source)
+	case "xliff":
+		return writeXLIFF(w, []*Catalog{c}, source)
+	case "arb":
+		return writeARB(w, c)
+	case "json":
+		return writeJSON(w, c)
+	default:
+		return writeCSV(w, c)
+	}
+}
+
+// WriteMatrix writes the catalogs of several languages as a single
+// translation matrix. Only xliff, json and csv hold several languages.
+func WriteMatrix(w io.Writer, format string, catalogs []*Catalog, source *Catalog) error {
+	if err := CheckFormat(format); err != nil {
+		return err
+	}
+
+	switch format {
+	case "xliff":
+		return writeXLIFF(w, catalogs, source)
+	case "json":
+		return writeJSONMatrix(w, catalogs)
+	case "csv":
+		return writeCSVMatrix(w, catalogs)
+	default:
+		return fmt.Errorf("%s files hold a single language, export one catalog per language instead", format)
+	}
+}
+
+// matrixRows returns the union of the message IDs of the catalogs, ordered
+// by kind, register, variant and then generic before named
+func matrixRows(catalogs []*Catalog) []Message {
+	seen := make(map[string]bool)
+	var rows []Message
+	for _, c := range catalogs {
+		for _, m := range c.Messages {
+			if !seen[m.ID] {
+				seen[m.ID] = true
+				rows = append(rows, m)
+			}
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Formality != b.Formality {
+			return a.Formality < b.Formality
+		}
+		if a.Variant != b.Variant {
+			return a.Variant < b.Variant
+		}
+		return !a.Named && b.Named
+	})
+	return rows
+}
@@ -0,0 +1,120 @@
+package export
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/unsuman/greeter/pkg/greetings"
+)
+
+// testVariants renders the greetings of a language with a casual register
+// and a second formal variant, whose honorific register falls back to the
+// formal one, as plugins do
+func testVariants(req greetings.Request) ([]greetings.Variant, error) {
+	if req.Kind != "hello" {
+		return nil, &greetings.UnsupportedKindError{Kind: req.Kind}
+	}
+
+	var texts []string
+	switch req.Formality {
+	case greetings.Casual:
+		texts = []string{"Hey"}
+	case greetings.Formal, greetings.Honorific:
+		texts = []string{"Good day", "Greetings"}
+	default:
+		texts = []string{"Hello"}
+	}
+
+	var variants []greetings.Variant
+	for i, text := range texts {
+		if req.Recipient != "" {
+			text += ", " + req.Recipient
+		}
+		g := greetings.Greeting{Text: text + "!", Script: "Latn"}
+		if req.Formality == greetings.Formal || req.Formality == greetings.Honorific {
+			g.Meaning = "a polite greeting"
+		}
+		variants = append(variants, greetings.Variant{Greeting: g, Weight: i + 1})
+	}
+	return variants, nil
+}
+
+// testCatalog collects the catalog of testVariants
+func testCatalog(t *testing.T) *Catalog {
+	t.Helper()
+	info := greetings.Info{Name: "english", DisplayName: "English", Version: "1.0.0", Author: "greeter", Locale: "en", Kinds: []string{"hello"}}
+	c, err := Collect(info, testVariants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+var german = strings.NewReplacer("Hello", "Hallo", "Hey", "Hi", "Good day", "Guten Tag", "Greetings", "Grüß Gott")
+
+// germanCatalog collects testVariants translated to German
+func germanCatalog(t *testing.T) *Catalog {
+	t.Helper()
+	info := greetings.Info{Name: "german", DisplayName: "German", Locale: "de", Kinds: []string{"hello"}}
+	c, err := Collect(info, func(req greetings.Request) ([]greetings.Variant, error) {
+		variants, err := testVariants(req)
+		for i := range variants {
+			variants[i].Text = german.Replace(variants[i].Text)
+		}
+		return variants, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCollect(t *testing.T) {
+	c := testCatalog(t)
+
+	var ids []string
+	for _, m := range c.Messages {
+		ids = append(ids, m.ID)
+	}
+	// The honorific register is the formal one, and not exported again
+	want := []string{
+		"hello", "hello_name",
+		"hello_casual", "hello_casual_name",
+		"hello_formal", "hello_formal_name", "hello_formal_v2", "hello_formal_v2_name",
+	}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("message IDs = %q, want %q", ids, want)
+	}
+
+	m, ok := c.Lookup("hello_formal_v2_name")
+	if !ok {
+		t.Fatal("no hello_formal_v2_name message")
+	}
+	if m.Greeting.Text != "Greetings, {name}!" || m.Formality != greetings.Formal || m.Variant != 1 || m.Weight != 2 || !m.Named {
+		t.Errorf("hello_formal_v2_name = %+v", m)
+	}
+}
+
+func TestCollectError(t *testing.T) {
+	info := greetings.Info{Name: "english", Kinds: []string{"hello", "goodbye"}}
+	_, err := Collect(info, testVariants)
+	var unsupported *greetings.UnsupportedKindError
+	if !errors.As(err, &unsupported) || unsupported.Kind != "goodbye" {
+		t.Errorf("got %v, want goodbye reported unsupported", err)
+	}
+}
+
+func TestWriteMatrixSingleLanguageFormat(t *testing.T) {
+	c := testCatalog(t)
+	for _, format := range []string{"po", "arb"} {
+		var b strings.Builder
+		if err := WriteMatrix(&b, format, []*Catalog{c}, nil); err == nil {
+			t.Errorf("WriteMatrix(%s) succeeded, want an error", format)
+		}
+	}
+	if err := WriteCatalog(&strings.Builder{}, "yaml", c, nil); err == nil || !strings.Contains(err.Error(), "unknown export format") {
+		t.Errorf("WriteCatalog(yaml) = %v, want an unknown format error", err)
+	}
+}
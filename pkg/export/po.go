@@ -0,0 +1,78 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/unsuman/greeter/pkg/gettext"
+)
+
+// writePO writes a gettext PO file, with the source text as msgid and the
+// message ID as msgctxt. The file is meant for translation tools and is
+// deliberately not a catalog for the gettext package: it holds rendered
+// greetings keyed by message ID, like the other formats, rather than
+// kind|register|field contexts and templates, and says so in its header.
+func writePO(w io.Writer, c, source *Catalog) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s greetings exported by greeter.\n", c.Info.DisplayName)
+	b.WriteString("msgid \"\"\nmsgstr \"\"\n")
+	header := []string{
+		"Project-Id-Version: " + c.Info.Version,
+		"Last-Translator: " + c.Info.Author,
+		"Language: " + c.Info.Locale,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding: 8bit",
+		gettext.ContextsHeader + ": " + gettext.ExportedContexts,
+	}
+	for _, field := range header {
+		fmt.Fprintf(&b, "%s\n", poQuote(field+"\n"))
+	}
+
+	for _, m := range c.Messages {
+		b.WriteString("\n")
+		fmt.Fprintf(&b, "#. id: %s\n", m.ID)
+		for _, note := range notes(m) {
+			fmt.Fprintf(&b, "#. %s: %s\n", note[0], note[1])
+		}
+		fmt.Fprintf(&b, "msgctxt %s\n", poQuote(m.ID))
+		fmt.Fprintf(&b, "msgid %s\n", poQuote(sourceText(source, m.ID)))
+		fmt.Fprintf(&b, "msgstr %s\n", poQuote(m.Greeting.Text))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var poEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+
+func poQuote(s string) string {
+	return `"` + poEscaper.Replace(s) + `"`
+}
+
+// notes returns the metadata of a message as name and value pairs
+func notes(m Message) [][2]string {
+	var n [][2]string
+	for _, note := range [][2]string{
+		{"romanization", m.Greeting.Romanization},
+		{"pronunciation", m.Greeting.Pronunciation},
+		{"meaning", m.Greeting.Meaning},
+		{"weight", weight(m)},
+	} {
+		if note[1] != "" {
+			n = append(n, note)
+		}
+	}
+	return n
+}
+
+// weight formats the weight of a variant, empty for the default weight
+func weight(m Message) string {
+	if m.Weight <= 1 {
+		return ""
+	}
+	return strconv.Itoa(m.Weight)
+}
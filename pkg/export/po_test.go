@@ -0,0 +1,53 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/unsuman/greeter/pkg/gettext"
+)
+
+func TestWritePO(t *testing.T) {
+	var b strings.Builder
+	if err := WriteCatalog(&b, "po", germanCatalog(t), testCatalog(t)); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+
+	want := `#. id: hello_formal_v2_name
+#. meaning: a polite greeting
+#. weight: 2
+msgctxt "hello_formal_v2_name"
+msgid "Greetings, {name}!"
+msgstr "Grüß Gott, {name}!"
+`
+	if !strings.Contains(out, want) {
+		t.Errorf("the PO file lacks\n%s\ngot\n%s", want, out)
+	}
+
+	// Translation tools read the file as any PO file
+	catalog, err := gettext.ParsePO([]byte(out))
+	if err != nil {
+		t.Fatalf("ParsePO: %v", err)
+	}
+	if got := catalog.Header["Language"]; got != "de" {
+		t.Errorf("Language header = %q, want de", got)
+	}
+	if got := catalog.Header[gettext.ContextsHeader]; got != gettext.ExportedContexts {
+		t.Errorf("%s header = %q, want %q", gettext.ContextsHeader, got, gettext.ExportedContexts)
+	}
+	if got, ok := catalog.Get("hello_name", "Hello, {name}!"); !ok || got != "Hallo, {name}!" {
+		t.Errorf("Get(hello_name) = %q, %v", got, ok)
+	}
+}
+
+func TestWritePOWithoutSource(t *testing.T) {
+	var b strings.Builder
+	if err := WriteCatalog(&b, "po", germanCatalog(t), nil); err != nil {
+		t.Fatal(err)
+	}
+	// The message ID stands for the missing source text
+	if want := "msgctxt \"hello_casual\"\nmsgid \"hello_casual\"\nmsgstr \"Hi!\"\n"; !strings.Contains(b.String(), want) {
+		t.Errorf("the PO file lacks\n%s\ngot\n%s", want, b.String())
+	}
+}
@@ -0,0 +1,133 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+)
+
+type jsonLanguage struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name,omitempty"`
+	Version     string `json:"version,omitempty"`
+	Author      string `json:"author,omitempty"`
+	Description string `json:"description,omitempty"`
+	Locale      string `json:"locale,omitempty"`
+}
+
+type jsonMessage struct {
+	Kind          string `json:"kind"`
+	Formality     string `json:"formality"`
+	Text          string `json:"text"`
+	Script        string `json:"script,omitempty"`
+	Romanization  string `json:"romanization,omitempty"`
+	Pronunciation string `json:"pronunciation,omitempty"`
+	Meaning       string `json:"meaning,omitempty"`
+	Variant       int    `json:"variant,omitempty"`
+	Weight        int    `json:"weight,omitempty"`
+}
+
+func toJSONLanguage(c *Catalog) jsonLanguage {
+	return jsonLanguage{
+		Name:        c.Info.Name,
+		DisplayName: c.Info.DisplayName,
+		Version:     c.Info.Version,
+		Author:      c.Info.Author,
+		Description: c.Info.Description,
+		Locale:      c.Info.Locale,
+	}
+}
+
+// writeJSON writes the messages of a language keyed by message ID
+func writeJSON(w io.Writer, c *Catalog) error {
+	doc := struct {
+		Language jsonLanguage           `json:"language"`
+		Messages map[string]jsonMessage `json:"messages"`
+	}{
+		Language: toJSONLanguage(c),
+		Messages: make(map[string]jsonMessage),
+	}
+
+	for _, m := range c.Messages {
+		doc.Messages[m.ID] = jsonMessage{
+			Kind:          m.Kind,
+			Formality:     m.Formality.String(),
+			Text:          m.Greeting.Text,
+			Script:        m.Greeting.Script,
+			Romanization:  m.Greeting.Romanization,
+			Pronunciation: m.Greeting.Pronunciation,
+			Meaning:       m.Greeting.Meaning,
+			Variant:       m.Variant + 1,
+			Weight:        m.Weight,
+		}
+	}
+
+	return encodeJSON(w, doc)
+}
+
+// writeJSONMatrix writes the text of every message keyed by message ID, then language name
+func writeJSONMatrix(w io.Writer, catalogs []*Catalog) error {
+	doc := struct {
+		Languages []jsonLanguage               `json:"languages"`
+		Messages  map[string]map[string]string `json:"messages"`
+	}{
+		Languages: []jsonLanguage{},
+		Messages:  make(map[string]map[string]string),
+	}
+
+	for _, c := range catalogs {
+		doc.Languages = append(doc.Languages, toJSONLanguage(c))
+		for _, m := range c.Messages {
+			if doc.Messages[m.ID] == nil {
+				doc.Messages[m.ID] = make(map[string]string)
+			}
+			doc.Messages[m.ID][c.Info.Name] = m.Greeting.Text
+		}
+	}
+
+	return encodeJSON(w, doc)
+}
+
+func encodeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeCSV writes one row per message with its metadata
+func writeCSV(w io.Writer, c *Catalog) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"id", "kind", "formality", "text", "romanization", "pronunciation", "meaning"})
+	for _, m := range c.Messages {
+		out.Write([]string{
+			m.ID, m.Kind, m.Formality.String(),
+			m.Greeting.Text, m.Greeting.Romanization, m.Greeting.Pronunciation, m.Greeting.Meaning,
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// writeCSVMatrix writes one row per message and one column per language
+func writeCSVMatrix(w io.Writer, catalogs []*Catalog) error {
+	out := csv.NewWriter(w)
+
+	header := []string{"id", "kind", "formality"}
+	for _, c := range catalogs {
+		header = append(header, c.Info.Name)
+	}
+	out.Write(header)
+
+	for _, row := range matrixRows(catalogs) {
+		record := []string{row.ID, row.Kind, row.Formality.String()}
+		for _, c := range catalogs {
+			m, _ := c.Lookup(row.ID)
+			record = append(record, m.Greeting.Text)
+		}
+		out.Write(record)
+	}
+
+	out.Flush()
+	return out.Error()
+}
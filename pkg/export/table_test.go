@@ -0,0 +1,131 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWriteJSON(t *testing.T) {
+	var b strings.Builder
+	if err := WriteCatalog(&b, "json", germanCatalog(t), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Language jsonLanguage           `json:"language"`
+		Messages map[string]jsonMessage `json:"messages"`
+	}
+	if err := json.Unmarshal([]byte(b.String()), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if want := (jsonLanguage{Name: "german", DisplayName: "German", Locale: "de"}); doc.Language != want {
+		t.Errorf("language = %+v, want %+v", doc.Language, want)
+	}
+	if len(doc.Messages) != 8 {
+		t.Errorf("got %d messages, want 8", len(doc.Messages))
+	}
+	want := jsonMessage{Kind: "hello", Formality: "formal", Text: "Grüß Gott!", Script: "Latn", Meaning: "a polite greeting", Variant: 2, Weight: 2}
+	if got := doc.Messages["hello_formal_v2"]; got != want {
+		t.Errorf("hello_formal_v2 = %+v, want %+v", got, want)
+	}
+	// Non-ASCII text is not escaped
+	if !strings.Contains(b.String(), `"text": "Grüß Gott!"`) {
+		t.Errorf("the text is escaped:\n%s", b.String())
+	}
+}
+
+func TestWriteJSONMatrix(t *testing.T) {
+	english, german := testCatalog(t), germanCatalog(t)
+	// German lacks the casual register
+	german.Messages = withoutMessages(german.Messages, "hello_casual", "hello_casual_name")
+
+	var b strings.Builder
+	if err := WriteMatrix(&b, "json", []*Catalog{english, german}, english); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Languages []jsonLanguage               `json:"languages"`
+		Messages  map[string]map[string]string `json:"messages"`
+	}
+	if err := json.Unmarshal([]byte(b.String()), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(doc.Languages) != 2 || doc.Languages[0].Name != "english" || doc.Languages[1].Name != "german" {
+		t.Errorf("languages = %+v, want english and german", doc.Languages)
+	}
+	if got, want := doc.Messages["hello_name"], map[string]string{"english": "Hello, {name}!", "german": "Hallo, {name}!"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("hello_name = %v, want %v", got, want)
+	}
+	if got, want := doc.Messages["hello_casual"], map[string]string{"english": "Hey!"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("hello_casual = %v, want %v", got, want)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var b strings.Builder
+	if err := WriteCatalog(&b, "csv", germanCatalog(t), nil); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(b.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	want := [][]string{
+		{"id", "kind", "formality", "text", "romanization", "pronunciation", "meaning"},
+		{"hello", "hello", "neutral", "Hallo!", "", "", ""},
+		{"hello_name", "hello", "neutral", "Hallo, {name}!", "", "", ""},
+		{"hello_casual", "hello", "casual", "Hi!", "", "", ""},
+		{"hello_casual_name", "hello", "casual", "Hi, {name}!", "", "", ""},
+		{"hello_formal", "hello", "formal", "Guten Tag!", "", "", "a polite greeting"},
+		{"hello_formal_name", "hello", "formal", "Guten Tag, {name}!", "", "", "a polite greeting"},
+		{"hello_formal_v2", "hello", "formal", "Grüß Gott!", "", "", "a polite greeting"},
+		{"hello_formal_v2_name", "hello", "formal", "Grüß Gott, {name}!", "", "", "a polite greeting"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("got %q, want %q", records, want)
+	}
+}
+
+func TestWriteCSVMatrix(t *testing.T) {
+	english, german := testCatalog(t), germanCatalog(t)
+	german.Messages = withoutMessages(german.Messages, "hello_formal_v2", "hello_formal_v2_name")
+
+	var b strings.Builder
+	if err := WriteMatrix(&b, "csv", []*Catalog{german, english}, english); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(b.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	// Rows are the union of the messages, in kind and register order
+	want := [][]string{
+		{"id", "kind", "formality", "german", "english"},
+		{"hello", "hello", "neutral", "Hallo!", "Hello!"},
+		{"hello_name", "hello", "neutral", "Hallo, {name}!", "Hello, {name}!"},
+		{"hello_casual", "hello", "casual", "Hi!", "Hey!"},
+		{"hello_casual_name", "hello", "casual", "Hi, {name}!", "Hey, {name}!"},
+		{"hello_formal", "hello", "formal", "Guten Tag!", "Good day!"},
+		{"hello_formal_name", "hello", "formal", "Guten Tag, {name}!", "Good day, {name}!"},
+		{"hello_formal_v2", "hello", "formal", "", "Greetings!"},
+		{"hello_formal_v2_name", "hello", "formal", "", "Greetings, {name}!"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("got %q, want %q", records, want)
+	}
+}
+
+// withoutMessages returns the messages but the ones with the given IDs
+func withoutMessages(messages []Message, ids ...string) []Message {
+	var kept []Message
+	for _, m := range messages {
+		if !slices.Contains(ids, m.ID) {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,70 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// XLIFF 1.2 document, which unlike XLIFF 2 holds one <file> per target language
+type xliffDocument struct {
+	XMLName xml.Name    `xml:"urn:oasis:names:tc:xliff:document:1.2 xliff"`
+	Version string      `xml:"version,attr"`
+	Files   []xliffFile `xml:"file"`
+}
+
+type xliffFile struct {
+	Original       string           `xml:"original,attr"`
+	SourceLanguage string           `xml:"source-language,attr"`
+	TargetLanguage string           `xml:"target-language,attr"`
+	Datatype       string           `xml:"datatype,attr"`
+	Units          []xliffTransUnit `xml:"body>trans-unit"`
+}
+
+type xliffTransUnit struct {
+	ID     string      `xml:"id,attr"`
+	Source string      `xml:"source"`
+	Target string      `xml:"target"`
+	Notes  []xliffNote `xml:"note"`
+}
+
+type xliffNote struct {
+	From string `xml:"from,attr"`
+	Text string `xml:",chardata"`
+}
+
+// writeXLIFF writes an XLIFF 1.2 document with a <file> per catalog
+func writeXLIFF(w io.Writer, catalogs []*Catalog, source *Catalog) error {
+	sourceLanguage := "en"
+	if source != nil && source.Info.Locale != "" {
+		sourceLanguage = source.Info.Locale
+	}
+
+	doc := xliffDocument{Version: "1.2"}
+	for _, c := range catalogs {
+		file := xliffFile{
+			Original:       c.Info.Name,
+			SourceLanguage: sourceLanguage,
+			TargetLanguage: c.Info.Locale,
+			Datatype:       "plaintext",
+		}
+		for _, m := range c.Messages {
+			unit := xliffTransUnit{ID: m.ID, Source: sourceText(source, m.ID), Target: m.Greeting.Text}
+			for _, note := range notes(m) {
+				unit.Notes = append(unit.Notes, xliffNote{From: note[0], Text: note[1]})
+			}
+			file.Units = append(file.Units, unit)
+		}
+		doc.Files = append(doc.Files, file)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
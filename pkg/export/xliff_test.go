@@ -0,0 +1,43 @@
+package export
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteXLIFF(t *testing.T) {
+	var b strings.Builder
+	if err := WriteMatrix(&b, "xliff", []*Catalog{germanCatalog(t)}, testCatalog(t)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), xml.Header) {
+		t.Errorf("the document lacks the XML header:\n%s", b.String())
+	}
+
+	var doc xliffDocument
+	if err := xml.Unmarshal([]byte(b.String()), &doc); err != nil {
+		t.Fatalf("invalid XLIFF: %v", err)
+	}
+	if doc.Version != "1.2" || len(doc.Files) != 1 {
+		t.Fatalf("got version %q with %d files, want a 1.2 document with one file", doc.Version, len(doc.Files))
+	}
+	file := doc.Files[0]
+	if file.Original != "german" || file.SourceLanguage != "en" || file.TargetLanguage != "de" {
+		t.Errorf("file = %s from %s to %s, want german from en to de", file.Original, file.SourceLanguage, file.TargetLanguage)
+	}
+	if len(file.Units) != 8 {
+		t.Fatalf("got %d trans-units, want 8", len(file.Units))
+	}
+
+	want := xliffTransUnit{
+		ID:     "hello_formal_v2",
+		Source: "Greetings!",
+		Target: "Grüß Gott!",
+		Notes:  []xliffNote{{From: "meaning", Text: "a polite greeting"}, {From: "weight", Text: "2"}},
+	}
+	if got := file.Units[6]; !reflect.DeepEqual(got, want) {
+		t.Errorf("trans-unit = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,176 @@
+// Package gettext reads gettext PO and MO message catalogs and serves them
+// as greetings.Plugin implementations, so translators can provide a language
+// with their usual tooling.
+//
+// Greetings are looked up by message context. The context names the greeting
+// kind, optionally followed by a register and a phrase field:
+//
+//	msgctxt "hello"                      -> neutral text of the hello greeting
+//	msgctxt "hello|formal"               -> formal text
+//	msgctxt "hello|formal|romanization"  -> romanization of the formal text
+//
+// The msgid is the English template, for the translator's reference, and the
+// msgstr the translated template (see greetings.Render). Fuzzy and untranslated
+// entries are ignored, like msgfmt does.
+//
+// Unlike programs using gettext, which look messages up by context and msgid,
+// greetings are looked up by context alone: the msgid only tells translators
+// what to translate, so every context may appear once. Greetings have no
+// count to choose a plural form with, so plural entries use the form the
+// Plural-Forms rule of the catalog picks for 1.
+//
+// PO files written by greeter export are keyed by message ID instead and are
+// refused, see ContextsHeader.
+package gettext
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Message is a catalog entry
+type Message struct {
+	Context  string
+	ID       string
+	IDPlural string
+	// Translations holds the translated string, or one string per plural form
+	Translations []string
+	// Comments holds the translator and extracted comments of PO entries
+	Comments []string
+}
+
+// Catalog is a parsed message catalog
+type Catalog struct {
+	// Header holds the fields of the catalog header, e.g. "Language" or "Plural-Forms"
+	Header   map[string]string
+	Messages []*Message
+	plural   *PluralForms
+	index    map[string]*Message
+}
+
+// contextSeparator joins the context and id of a message in MO files
+const contextSeparator = "\x04"
+
+func key(context, id string) string {
+	if context == "" {
+		return id
+	}
+	return context + contextSeparator + id
+}
+
+// newCatalog indexes the messages and reads the header entry
+func newCatalog(messages []*Message) (*Catalog, error) {
+	c := &Catalog{
+		Header: make(map[string]string),
+		index:  make(map[string]*Message),
+	}
+
+	for _, m := range messages {
+		if m.Context == "" && m.ID == "" {
+			if len(m.Translations) > 0 {
+				c.Header = parseHeader(m.Translations[0])
+			}
+			continue
+		}
+		c.Messages = append(c.Messages, m)
+		c.index[key(m.Context, m.ID)] = m
+	}
+
+	plural, err := ParsePluralForms(c.Header["Plural-Forms"])
+	if err != nil {
+		return nil, err
+	}
+	c.plural = plural
+
+	return c, nil
+}
+
+// parseHeader reads the "Field: value" lines of the header entry
+func parseHeader(s string) map[string]string {
+	header := make(map[string]string)
+	for _, line := range strings.Split(s, "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		header[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return header
+}
+
+// Get returns the translation of a message, false if it is missing
+func (c *Catalog) Get(context, id string) (string, bool) {
+	m, ok := c.index[key(context, id)]
+	if !ok || len(m.Translations) == 0 || m.Translations[0] == "" {
+		return "", false
+	}
+	return m.Translations[0], true
+}
+
+// GetPlural returns the plural form of a message for the count n
+func (c *Catalog) GetPlural(context, id string, n int) (string, bool) {
+	m, ok := c.index[key(context, id)]
+	if !ok || len(m.Translations) == 0 {
+		return "", false
+	}
+
+	form := c.plural.Index(n)
+	if form >= len(m.Translations) || m.Translations[form] == "" {
+		return "", false
+	}
+	return m.Translations[form], true
+}
+
+// Lookup returns the translation of the first message with the given
+// context, whatever its msgid, picking the plural form for 1 of plural messages
+func (c *Catalog) Lookup(context string) (string, bool) {
+	for _, m := range c.Messages {
+		if m.Context != context {
+			continue
+		}
+		if m.IDPlural != "" {
+			return c.GetPlural(m.Context, m.ID, 1)
+		}
+		return c.Get(m.Context, m.ID)
+	}
+	return "", false
+}
+
+// Contexts returns the distinct message contexts of the catalog, sorted
+func (c *Catalog) Contexts() []string {
+	seen := make(map[string]bool)
+	var contexts []string
+	for _, m := range c.Messages {
+		if m.Context != "" && !seen[m.Context] {
+			seen[m.Context] = true
+			contexts = append(contexts, m.Context)
+		}
+	}
+	sort.Strings(contexts)
+	return contexts
+}
+
+// PluralForms returns the plural rule of the catalog
+func (c *Catalog) PluralForms() *PluralForms {
+	return c.plural
+}
+
+// ParseError reports a malformed PO or MO file
+type ParseError struct {
+	File string
+	// Line is the offending line of a PO file, 0 when unknown
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.File, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,98 @@
+package gettext
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	moMagicLittleEndian = 0x950412de
+	moMagicBigEndian    = 0xde120495
+)
+
+// LoadMO reads a compiled MO file
+func LoadMO(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := ParseMO(data)
+	if err != nil {
+		return nil, &ParseError{File: path, Err: err}
+	}
+	return catalog, nil
+}
+
+// ParseMO parses the contents of a compiled MO file of either byte order
+func ParseMO(data []byte) (*Catalog, error) {
+	if len(data) < 20 {
+		return nil, fmt.Errorf("file too short for an MO header")
+	}
+
+	var order binary.ByteOrder
+	switch binary.LittleEndian.Uint32(data) {
+	case moMagicLittleEndian:
+		order = binary.LittleEndian
+	case moMagicBigEndian:
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("not an MO file")
+	}
+
+	if revision := order.Uint32(data[4:]) >> 16; revision > 1 {
+		return nil, fmt.Errorf("unsupported MO revision %d", revision)
+	}
+
+	count := order.Uint32(data[8:])
+	originals := order.Uint32(data[12:])
+	translations := order.Uint32(data[16:])
+
+	// The count comes from the file: check that both descriptor tables fit
+	// before allocating for them
+	for _, table := range []uint32{originals, translations} {
+		if uint64(table)+uint64(count)*8 > uint64(len(data)) {
+			return nil, fmt.Errorf("%d strings do not fit in the file", count)
+		}
+	}
+
+	// readString returns the i-th string of a descriptor table
+	readString := func(table, i uint32) (string, error) {
+		at := uint64(table) + uint64(i)*8
+		if at+8 > uint64(len(data)) {
+			return "", fmt.Errorf("string table out of bounds")
+		}
+		length := uint64(order.Uint32(data[at:]))
+		offset := uint64(order.Uint32(data[at+4:]))
+		if offset+length > uint64(len(data)) {
+			return "", fmt.Errorf("string %d out of bounds", i)
+		}
+		return string(data[offset : offset+length]), nil
+	}
+
+	messages := make([]*Message, 0, count)
+	for i := uint32(0); i < count; i++ {
+		original, err := readString(originals, i)
+		if err != nil {
+			return nil, err
+		}
+		translation, err := readString(translations, i)
+		if err != nil {
+			return nil, err
+		}
+
+		m := &Message{}
+		if context, rest, ok := strings.Cut(original, contextSeparator); ok {
+			m.Context = context
+			original = rest
+		}
+		m.ID, m.IDPlural, _ = strings.Cut(original, "\x00")
+		m.Translations = strings.Split(translation, "\x00")
+
+		messages = append(messages, m)
+	}
+
+	return newCatalog(messages)
+}
@@ -0,0 +1,104 @@
+package gettext
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// buildMO encodes pairs of original and translated strings as an MO file
+func buildMO(order binary.ByteOrder, pairs [][2]string) []byte {
+	const header = 28
+	count := uint32(len(pairs))
+	originals := uint32(header)
+	translations := originals + count*8
+	offset := translations + count*8
+
+	data := make([]byte, offset)
+	order.PutUint32(data[0:], moMagicLittleEndian)
+	if order == binary.BigEndian {
+		binary.LittleEndian.PutUint32(data[0:], moMagicBigEndian)
+	}
+	order.PutUint32(data[8:], count)
+	order.PutUint32(data[12:], originals)
+	order.PutUint32(data[16:], translations)
+
+	for i, pair := range pairs {
+		for j, s := range pair {
+			at := []uint32{originals, translations}[j] + uint32(i)*8
+			order.PutUint32(data[at:], uint32(len(s)))
+			order.PutUint32(data[at+4:], uint32(len(data)))
+			data = append(data, s...)
+			data = append(data, 0)
+		}
+	}
+	return data
+}
+
+var testMOPairs = [][2]string{
+	{"", "Language: ja\nPlural-Forms: nplurals=1; plural=0;\n"},
+	{"hello\x04Hello!", "こんにちは！"},
+	{"apples\x04one apple\x00{n} apples", "{n}個のりんご"},
+}
+
+func TestParseMO(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		t.Run(order.String(), func(t *testing.T) {
+			catalog, err := ParseMO(buildMO(order, testMOPairs))
+			if err != nil {
+				t.Fatalf("ParseMO: %v", err)
+			}
+			if got := catalog.Header["Language"]; got != "ja" {
+				t.Errorf("Language header = %q, want ja", got)
+			}
+			if got, ok := catalog.Get("hello", "Hello!"); !ok || got != "こんにちは！" {
+				t.Errorf("Get(hello) = %q, %v", got, ok)
+			}
+			if got, ok := catalog.GetPlural("apples", "one apple", 3); !ok || got != "{n}個のりんご" {
+				t.Errorf("GetPlural(apples, 3) = %q, %v", got, ok)
+			}
+			if got := catalog.PluralForms().Count; got != 1 {
+				t.Errorf("nplurals = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestParseMOCorrupt(t *testing.T) {
+	valid := buildMO(binary.LittleEndian, testMOPairs)
+
+	for _, tt := range []struct {
+		name   string
+		mangle func(data []byte) []byte
+	}{
+		{"too short", func(data []byte) []byte { return data[:12] }},
+		{"bad magic", func(data []byte) []byte {
+			binary.LittleEndian.PutUint32(data, 0x12345678)
+			return data
+		}},
+		{"unsupported revision", func(data []byte) []byte {
+			binary.LittleEndian.PutUint32(data[4:], 2<<16)
+			return data
+		}},
+		{"huge count", func(data []byte) []byte {
+			binary.LittleEndian.PutUint32(data[8:], 0xffffffff)
+			return data
+		}},
+		{"truncated tables", func(data []byte) []byte { return data[:40] }},
+		{"tables out of bounds", func(data []byte) []byte {
+			binary.LittleEndian.PutUint32(data[16:], 0xfffffff0)
+			return data
+		}},
+		{"string out of bounds", func(data []byte) []byte {
+			binary.LittleEndian.PutUint32(data[28+4:], uint32(len(data)))
+			binary.LittleEndian.PutUint32(data[28:], 10)
+			return data
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.mangle(append([]byte(nil), valid...))
+			if _, err := ParseMO(data); err == nil {
+				t.Error("ParseMO accepted a corrupt file")
+			}
+		})
+	}
+}
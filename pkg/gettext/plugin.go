@@ -0,0 +1,297 @@
+package gettext
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin/registry"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// DayPartsHeader is the optional header declaring the time windows of the
+// language, e.g. "X-Greeter-Day-Parts: 05:00 goodmorning, 18:00 goodevening"
+const DayPartsHeader = "X-Greeter-Day-Parts"
+
+// ContextsHeader declares what the message contexts of a catalog hold.
+// Catalogs written by greeter export set it to ExportedContexts: their
+// contexts are message IDs such as "hello_formal_v2_name" and their strings
+// rendered greetings, not kind|register|field contexts and templates.
+const ContextsHeader = "X-Greeter-Contexts"
+
+// ExportedContexts is the ContextsHeader value of exported catalogs
+const ExportedContexts = "message-ids"
+
+// Plugin is a language served from a gettext catalog
+type Plugin struct {
+	// Path is the file the catalog was loaded from
+	Path    string
+	Catalog *Catalog
+	info    greetings.Info
+	phrases *greetings.Phrasebook
+}
+
+// Greet renders a greeting from the catalog
+func (p *Plugin) Greet(req greetings.Request) (greetings.Greeting, error) {
+	return p.phrases.Greet(p.info.Name, req)
+}
+
+// Variants renders the requested greeting and its variants from the catalog
+func (p *Plugin) Variants(req greetings.Request) ([]greetings.Variant, error) {
+	return p.phrases.Variants(p.info.Name, req)
+}
+
+// Kinds returns the greeting kinds translated in the catalog
+func (p *Plugin) Kinds() []string {
+	return p.phrases.Kinds()
+}
+
+// Name returns the language name, the catalog file name without extension
+func (p *Plugin) Name() string {
+	return p.info.Name
+}
+
+// Info returns the metadata read from the catalog header
+func (p *Plugin) Info() greetings.Info {
+	return p.info
+}
+
+func (p *Plugin) Init() error {
+	return nil
+}
+
+func (p *Plugin) Close() error {
+	return nil
+}
+
+// IsCatalog reports whether a file name has a PO or MO extension
+func IsCatalog(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".po", ".mo":
+		return true
+	}
+	return false
+}
+
+// Load reads a PO or MO file and builds a language from its greetings
+func Load(path string) (*Plugin, error) {
+	var catalog *Catalog
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".po":
+		catalog, err = LoadPO(path)
+	case ".mo":
+		catalog, err = LoadMO(path)
+	default:
+		err = fmt.Errorf("%s: unsupported file type, expected .po or .mo", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stem := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	p, err := newPlugin(strings.ToLower(stem), catalog)
+	if err != nil {
+		return nil, &ParseError{File: path, Err: err}
+	}
+
+	p.Path = path
+	return p, nil
+}
+
+// newPlugin maps the message contexts of a catalog onto a phrasebook
+func newPlugin(name string, catalog *Catalog) (*Plugin, error) {
+	if catalog.Header[ContextsHeader] == ExportedContexts {
+		return nil, fmt.Errorf("the catalog was written by greeter export, its contexts are message IDs rather than kind|register|field")
+	}
+
+	info := greetings.Info{
+		Name:    name,
+		Version: catalog.Header["Project-Id-Version"],
+		Author:  catalog.Header["Last-Translator"],
+		Locale:  catalog.Header["Language"],
+	}
+	if info.Locale == "" {
+		info.Locale = name
+	}
+
+	phrases := &greetings.Phrasebook{Greetings: make(map[string]greetings.Registers)}
+	if tag, err := language.Parse(strings.ReplaceAll(info.Locale, "_", "-")); err == nil {
+		info.Locale = tag.String()
+		info.DisplayName = display.Self.Name(tag)
+		info.Description = display.English.Tags().Name(tag) + " greetings from a gettext catalog"
+		if script, confidence := tag.Script(); confidence != language.No {
+			phrases.Script = script.String()
+		}
+	}
+
+	// Greetings are looked up by context alone, see Catalog.Lookup
+	ids := make(map[string]string)
+	for _, m := range catalog.Messages {
+		if id, ok := ids[m.Context]; ok && m.Context != "" {
+			return nil, fmt.Errorf("msgctxt %q is used by both msgid %q and %q, greetings are looked up by context alone", m.Context, id, m.ID)
+		}
+		ids[m.Context] = m.ID
+	}
+
+	for _, context := range catalog.Contexts() {
+		text, ok := catalog.Lookup(context)
+		if !ok {
+			continue
+		}
+
+		kind, rest, _ := strings.Cut(context, "|")
+		register, field, _ := strings.Cut(rest, "|")
+
+		formality := greetings.Neutral
+		if register != "" {
+			f, err := greetings.ParseFormality(register)
+			if err != nil {
+				return nil, fmt.Errorf("msgctxt %q: %w", context, err)
+			}
+			formality = f
+		}
+
+		registers, ok := phrases.Greetings[kind]
+		if !ok {
+			registers = make(greetings.Registers)
+			phrases.Greetings[kind] = registers
+		}
+		phrase := registers[formality]
+
+		switch field {
+		case "", "text":
+			phrase.Text = text
+		case "romanization":
+			phrase.Romanization = text
+		case "pronunciation":
+			phrase.Pronunciation = text
+		case "meaning":
+			phrase.Meaning = text
+		default:
+			return nil, fmt.Errorf("msgctxt %q: unknown field %q (expected text, romanization, pronunciation or meaning)", context, field)
+		}
+		registers[formality] = phrase
+	}
+
+	// Extra fields without a translated text cannot be rendered
+	for kind, registers := range phrases.Greetings {
+		for formality, phrase := range registers {
+			if phrase.Text == "" {
+				delete(registers, formality)
+			}
+		}
+		if len(registers) == 0 {
+			delete(phrases.Greetings, kind)
+		}
+	}
+	if len(phrases.Greetings) == 0 {
+		return nil, fmt.Errorf("no translated greetings")
+	}
+
+	if header := catalog.Header[DayPartsHeader]; header != "" {
+		parts, err := parseDayParts(header, phrases)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", DayPartsHeader, err)
+		}
+		info.DayParts = parts
+	}
+
+	return &Plugin{Catalog: catalog, info: info, phrases: phrases}, nil
+}
+
+var dayPartFormat = regexp.MustCompile(`^([01]?[0-9]|2[0-3]):([0-5][0-9])\s+(\S+)$`)
+
+// parseDayParts reads a comma separated list of "HH:MM kind" windows
+func parseDayParts(header string, phrases *greetings.Phrasebook) ([]greetings.DayPart, error) {
+	var parts []greetings.DayPart
+	for _, entry := range strings.Split(header, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		m := dayPartFormat.FindStringSubmatch(entry)
+		if m == nil {
+			return nil, fmt.Errorf("%q is not a \"HH:MM kind\" entry", entry)
+		}
+		if _, exists := phrases.Greetings[m[3]]; !exists {
+			return nil, fmt.Errorf("greeting %q is not translated", m[3])
+		}
+
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		parts = append(parts, greetings.DayPart{Start: greetings.At(hour, minute), Kind: m[3]})
+	}
+	return parts, nil
+}
+
+// LoadDir loads every catalog of a directory, sorted by file name. When a
+// language has both a PO and an MO file, the most recently modified one wins.
+// Malformed catalogs are reported in the returned errors and skipped; a
+// missing directory holds no catalogs.
+func LoadDir(dir string) ([]*Plugin, []error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, []error{fmt.Errorf("failed to read catalogs directory: %w", err)}
+	}
+
+	var plugins []*Plugin
+	var errs []error
+	index := make(map[string]int)
+	modified := make(map[string]int64)
+	for _, entry := range entries {
+		if entry.IsDir() || !IsCatalog(entry.Name()) {
+			continue
+		}
+
+		fi, err := entry.Info()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		p, err := Load(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		name := p.Name()
+		if i, seen := index[name]; seen {
+			if fi.ModTime().UnixNano() > modified[name] {
+				plugins[i] = p
+				modified[name] = fi.ModTime().UnixNano()
+			}
+			continue
+		}
+		index[name] = len(plugins)
+		modified[name] = fi.ModTime().UnixNano()
+		plugins = append(plugins, p)
+	}
+
+	return plugins, errs
+}
+
+// RegisterDir loads the catalogs of a directory into a registry. Malformed
+// catalogs are logged and skipped.
+func RegisterDir(reg *registry.Registry, dir string, logger *logrus.Logger) {
+	plugins, errs := LoadDir(dir)
+	for _, err := range errs {
+		logger.Errorf("Skipping gettext catalog %v", err)
+	}
+
+	for _, p := range plugins {
+		logger.Debugf("Loaded gettext catalog %s from %s", p.Name(), p.Path)
+		reg.Register(p)
+	}
+}
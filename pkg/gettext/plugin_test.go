@@ -0,0 +1,133 @@
+package gettext
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/unsuman/greeter/pkg/greetings"
+)
+
+func TestNewPlugin(t *testing.T) {
+	catalog, err := ParsePO([]byte(`msgid ""
+msgstr ""
+"Language: de\n"
+
+msgctxt "hello"
+msgid "Hello[, {name}]!"
+msgstr "Hallo[, {name}]!"
+
+msgctxt "hello|formal"
+msgid "Good day[, {name}]!"
+msgstr "Guten Tag[, {name}]!"
+
+msgctxt "hello|formal|meaning"
+msgid "good day"
+msgstr "good day"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := newPlugin("de", catalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		req  greetings.Request
+		want string
+	}{
+		{greetings.Request{Kind: "hello"}, "Hallo!"},
+		{greetings.Request{Kind: "hello", Recipient: "Priya"}, "Hallo, Priya!"},
+		{greetings.Request{Kind: "hello", Formality: greetings.Formal, Recipient: "Priya"}, "Guten Tag, Priya!"},
+	} {
+		g, err := p.Greet(tt.req)
+		if err != nil {
+			t.Errorf("Greet(%+v): %v", tt.req, err)
+			continue
+		}
+		if g.Text != tt.want {
+			t.Errorf("Greet(%+v) = %q, want %q", tt.req, g.Text, tt.want)
+		}
+	}
+}
+
+func TestNewPluginExportedCatalog(t *testing.T) {
+	catalog, err := ParsePO([]byte(`msgid ""
+msgstr ""
+"Language: de\n"
+"X-Greeter-Contexts: message-ids\n"
+
+#. id: hello_formal_v2_name
+msgctxt "hello_formal_v2_name"
+msgid "Good day, {name}!"
+msgstr "Guten Tag, {name}!"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = newPlugin("de", catalog)
+	if err == nil || !strings.Contains(err.Error(), "greeter export") {
+		t.Errorf("newPlugin of an exported catalog: got %v, want an error naming greeter export", err)
+	}
+}
+
+func TestNewPluginKeyedByContext(t *testing.T) {
+	// Arabic picks the second plural form for 1
+	catalog, err := ParsePO([]byte(`msgid ""
+msgstr ""
+"Language: ar\n"
+"Plural-Forms: nplurals=6; plural=n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5;\n"
+
+msgctxt "hello"
+msgid "Hi there[, {name}]!"
+msgstr "مرحبا[ {name}]!"
+
+msgctxt "welcome"
+msgid "Welcome[, {name}]!"
+msgid_plural "Welcome, all!"
+msgstr[0] "أهلا بالجميع"
+msgstr[1] "أهلا وسهلا[ {name}]!"
+msgstr[2] "أهلا بكما"
+msgstr[3] "أهلا بكم"
+msgstr[4] "أهلا بكم"
+msgstr[5] "أهلا بكم"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := newPlugin("ar", catalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The msgid is only a reference for translators
+	for kind, want := range map[string]string{"hello": "مرحبا Salma!", "welcome": "أهلا وسهلا Salma!"} {
+		g, err := p.Greet(greetings.Request{Kind: kind, Recipient: "Salma"})
+		if err != nil || g.Text != want {
+			t.Errorf("Greet(%s) = %q, %v, want %q", kind, g.Text, err, want)
+		}
+	}
+}
+
+func TestNewPluginDuplicateContext(t *testing.T) {
+	catalog, err := ParsePO([]byte(`msgid ""
+msgstr ""
+"Language: de\n"
+
+msgctxt "hello"
+msgid "Hello!"
+msgstr "Hallo!"
+
+msgctxt "hello"
+msgid "Hi!"
+msgstr "Hi!"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Gettext tells the entries apart by msgid, greeter could only pick one
+	_, err = newPlugin("de", catalog)
+	if err == nil || !strings.Contains(err.Error(), `msgctxt "hello" is used by both msgid "Hello!" and "Hi!"`) {
+		t.Errorf("got %v, want the duplicate context reported", err)
+	}
+}
@@ -0,0 +1,283 @@
+package gettext
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PluralForms is the plural rule of a catalog, read from the Plural-Forms
+// header, e.g. "nplurals=2; plural=(n != 1);"
+type PluralForms struct {
+	Count      int
+	Expression string
+	eval       func(n int) int
+}
+
+// germanicPlurals is the rule used when a catalog does not declare one
+var germanicPlurals = &PluralForms{
+	Count:      2,
+	Expression: "n != 1",
+	eval: func(n int) int {
+		if n != 1 {
+			return 1
+		}
+		return 0
+	},
+}
+
+// ParsePluralForms parses a Plural-Forms header value; an empty value yields
+// the Germanic rule shared by English
+func ParsePluralForms(header string) (*PluralForms, error) {
+	if strings.TrimSpace(header) == "" {
+		return germanicPlurals, nil
+	}
+
+	forms := &PluralForms{}
+	for _, part := range strings.Split(header, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(name) {
+		case "nplurals":
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid nplurals %q", value)
+			}
+			forms.Count = n
+		case "plural":
+			forms.Expression = strings.TrimSpace(value)
+		}
+	}
+
+	if forms.Count == 0 || forms.Expression == "" {
+		return nil, fmt.Errorf("invalid Plural-Forms %q", header)
+	}
+
+	p := &exprParser{src: forms.Expression}
+	expr, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("invalid plural expression %q: %w", forms.Expression, err)
+	}
+	forms.eval = expr
+
+	return forms, nil
+}
+
+// Index returns the plural form to use for the count n
+func (f *PluralForms) Index(n int) int {
+	i := f.eval(n)
+	if i < 0 || i >= f.Count {
+		return 0
+	}
+	return i
+}
+
+// exprParser is a recursive descent parser for the C subset used by
+// plural expressions: n, integers, parentheses, ! and the arithmetic,
+// comparison, logical and ternary operators
+type exprParser struct {
+	src string
+	pos int
+}
+
+type expr func(n int) int
+
+func (p *exprParser) parse() (expr, error) {
+	e, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos != len(p.src) {
+		return nil, fmt.Errorf("unexpected %q", p.src[p.pos:])
+	}
+	return e, nil
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// accept consumes op if it comes next
+func (p *exprParser) accept(op string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.src[p.pos:], op) {
+		p.pos += len(op)
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) ternary() (expr, error) {
+	cond, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept("?") {
+		return cond, nil
+	}
+
+	then, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept(":") {
+		return nil, fmt.Errorf("expected ':'")
+	}
+	otherwise, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(n int) int {
+		if cond(n) != 0 {
+			return then(n)
+		}
+		return otherwise(n)
+	}, nil
+}
+
+// binaryLevels lists the binary operators by increasing precedence; longer
+// operators come first so "<=" is not read as "<"
+var binaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<=", ">=", "<", ">"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *exprParser) binary(level int) (expr, error) {
+	if level == len(binaryLevels) {
+		return p.unary()
+	}
+
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op := ""
+		for _, candidate := range binaryLevels[level] {
+			if p.accept(candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = combine(op, left, right)
+	}
+}
+
+func combine(op string, l, r expr) expr {
+	b := func(v bool) int {
+		if v {
+			return 1
+		}
+		return 0
+	}
+
+	switch op {
+	case "||":
+		return func(n int) int { return b(l(n) != 0 || r(n) != 0) }
+	case "&&":
+		return func(n int) int { return b(l(n) != 0 && r(n) != 0) }
+	case "==":
+		return func(n int) int { return b(l(n) == r(n)) }
+	case "!=":
+		return func(n int) int { return b(l(n) != r(n)) }
+	case "<=":
+		return func(n int) int { return b(l(n) <= r(n)) }
+	case ">=":
+		return func(n int) int { return b(l(n) >= r(n)) }
+	case "<":
+		return func(n int) int { return b(l(n) < r(n)) }
+	case ">":
+		return func(n int) int { return b(l(n) > r(n)) }
+	case "+":
+		return func(n int) int { return l(n) + r(n) }
+	case "-":
+		return func(n int) int { return l(n) - r(n) }
+	case "*":
+		return func(n int) int { return l(n) * r(n) }
+	case "/":
+		return func(n int) int {
+			if d := r(n); d != 0 {
+				return l(n) / d
+			}
+			return 0
+		}
+	default: // "%"
+		return func(n int) int {
+			if d := r(n); d != 0 {
+				return l(n) % d
+			}
+			return 0
+		}
+	}
+}
+
+func (p *exprParser) unary() (expr, error) {
+	if p.accept("!") {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(n int) int {
+			if operand(n) == 0 {
+				return 1
+			}
+			return 0
+		}, nil
+	}
+	return p.primary()
+}
+
+func (p *exprParser) primary() (expr, error) {
+	p.skipSpace()
+	if p.pos == len(p.src) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	switch c := p.src[p.pos]; {
+	case c == '(':
+		p.pos++
+		e, err := p.ternary()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("expected ')'")
+		}
+		return e, nil
+
+	case c == 'n':
+		p.pos++
+		return func(n int) int { return n }, nil
+
+	case c >= '0' && c <= '9':
+		start := p.pos
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+		}
+		v, _ := strconv.Atoi(p.src[start:p.pos])
+		return func(int) int { return v }, nil
+
+	default:
+		return nil, fmt.Errorf("unexpected %q", p.src[p.pos:])
+	}
+}
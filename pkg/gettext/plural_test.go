@@ -0,0 +1,58 @@
+package gettext
+
+import "testing"
+
+func TestParsePluralForms(t *testing.T) {
+	for _, tt := range []struct {
+		header string
+		count  int
+		// forms are the expected indexes for n = 0, 1, 2, 5, 11, 21, 22, 101, 111
+		forms []int
+	}{
+		{"", 2, []int{1, 0, 1, 1, 1, 1, 1, 1, 1}},
+		{"nplurals=1; plural=0;", 1, []int{0, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{"nplurals=2; plural=(n != 1);", 2, []int{1, 0, 1, 1, 1, 1, 1, 1, 1}},
+		{"nplurals=2; plural=(n > 1);", 2, []int{0, 0, 1, 1, 1, 1, 1, 1, 1}},
+		// Russian
+		{"nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);", 3,
+			[]int{2, 0, 1, 2, 2, 0, 1, 0, 2}},
+		// Arabic
+		{"nplurals=6; plural=n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5;", 6,
+			[]int{0, 1, 2, 3, 4, 4, 4, 5, 4}},
+		// Out of range results fall back to the first form
+		{"nplurals=2; plural=n;", 2, []int{0, 1, 0, 0, 0, 0, 0, 0, 0}},
+		{"nplurals=2; plural=!(n == 1) * 1 + 0 / 0;", 2, []int{1, 0, 1, 1, 1, 1, 1, 1, 1}},
+	} {
+		forms, err := ParsePluralForms(tt.header)
+		if err != nil {
+			t.Errorf("ParsePluralForms(%q): %v", tt.header, err)
+			continue
+		}
+		if forms.Count != tt.count {
+			t.Errorf("ParsePluralForms(%q).Count = %d, want %d", tt.header, forms.Count, tt.count)
+		}
+		for i, n := range []int{0, 1, 2, 5, 11, 21, 22, 101, 111} {
+			if got := forms.Index(n); got != tt.forms[i] {
+				t.Errorf("ParsePluralForms(%q).Index(%d) = %d, want %d", tt.header, n, got, tt.forms[i])
+			}
+		}
+	}
+}
+
+func TestParsePluralFormsErrors(t *testing.T) {
+	for _, header := range []string{
+		"plural=(n != 1);",
+		"nplurals=2;",
+		"nplurals=0; plural=0;",
+		"nplurals=x; plural=0;",
+		"nplurals=2; plural=(n != 1;",
+		"nplurals=2; plural=n ? 1;",
+		"nplurals=2; plural=n + ;",
+		"nplurals=2; plural=n 1;",
+		"nplurals=2; plural=m;",
+	} {
+		if _, err := ParsePluralForms(header); err == nil {
+			t.Errorf("ParsePluralForms(%q) succeeded, want an error", header)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package gettext
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LoadPO reads a PO file
+func LoadPO(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := ParsePO(data)
+	if err != nil {
+		if perr, ok := err.(*ParseError); ok {
+			perr.File = path
+		}
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// poParser accumulates the entry being read
+type poParser struct {
+	messages []*Message
+	current  *Message
+	fuzzy    bool
+	// field points to the string continuation lines are appended to
+	field *string
+	// started is set once a keyword of the current entry has been read
+	started bool
+}
+
+// flush finishes the current entry, dropping fuzzy and untranslated ones
+func (p *poParser) flush() {
+	if p.current != nil && p.started {
+		m := p.current
+		translated := false
+		for _, t := range m.Translations {
+			if t != "" {
+				translated = true
+			}
+		}
+		isHeader := m.Context == "" && m.ID == ""
+		if translated && (!p.fuzzy || isHeader) {
+			p.messages = append(p.messages, m)
+		}
+	}
+	p.current = &Message{}
+	p.fuzzy = false
+	p.field = nil
+	p.started = false
+}
+
+// ParsePO parses the contents of a PO file
+func ParsePO(data []byte) (*Catalog, error) {
+	p := &poParser{current: &Message{}}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if lineNo == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+
+		fail := func(format string, args ...any) error {
+			return &ParseError{Line: lineNo, Err: fmt.Errorf(format, args...)}
+		}
+
+		switch {
+		case line == "":
+			continue
+
+		case strings.HasPrefix(line, "#~"):
+			// Obsolete entry
+			continue
+
+		case strings.HasPrefix(line, "#"):
+			// A comment after a keyword starts a new entry
+			if p.started {
+				p.flush()
+			}
+			switch {
+			case strings.HasPrefix(line, "#,"):
+				for _, flag := range strings.Split(line[2:], ",") {
+					if strings.TrimSpace(flag) == "fuzzy" {
+						p.fuzzy = true
+					}
+				}
+			case strings.HasPrefix(line, "# "), strings.HasPrefix(line, "#."), line == "#":
+				comment := strings.TrimSpace(strings.TrimLeft(line, "#."))
+				if comment != "" {
+					p.current.Comments = append(p.current.Comments, comment)
+				}
+			}
+			continue
+
+		case strings.HasPrefix(line, `"`):
+			if p.field == nil {
+				return nil, fail("string without a keyword")
+			}
+			s, err := unquote(line)
+			if err != nil {
+				return nil, fail("%v", err)
+			}
+			*p.field += s
+			continue
+		}
+
+		keyword, rest, _ := strings.Cut(line, " ")
+		value, err := unquote(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fail("%s: %v", keyword, err)
+		}
+
+		switch {
+		case keyword == "msgctxt":
+			if p.started {
+				p.flush()
+			}
+			p.current.Context = value
+			p.field = &p.current.Context
+
+		case keyword == "msgid":
+			if p.started && (p.current.ID != "" || len(p.current.Translations) > 0) {
+				p.flush()
+			}
+			p.current.ID = value
+			p.field = &p.current.ID
+
+		case keyword == "msgid_plural":
+			p.current.IDPlural = value
+			p.field = &p.current.IDPlural
+
+		case keyword == "msgstr":
+			p.current.Translations = []string{value}
+			p.field = &p.current.Translations[0]
+
+		case strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]"):
+			n, err := strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+			if err != nil || n != len(p.current.Translations) {
+				return nil, fail("unexpected %s", keyword)
+			}
+			p.current.Translations = append(p.current.Translations, value)
+			p.field = &p.current.Translations[n]
+
+		default:
+			return nil, fail("unknown keyword %q", keyword)
+		}
+		p.started = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	p.flush()
+
+	return newCatalog(p.messages)
+}
+
+// unquote decodes a C-style quoted PO string
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("expected a quoted string, got %q", s)
+	}
+	s = s[1 : len(s)-1]
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '"' {
+			return "", fmt.Errorf("unescaped quote")
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		if i == len(s) {
+			return "", fmt.Errorf("trailing backslash")
+		}
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '\\', '"', '\'', '?':
+			b.WriteByte(s[i])
+		default:
+			return "", fmt.Errorf("unknown escape sequence \\%c", s[i])
+		}
+	}
+	return b.String(), nil
+}
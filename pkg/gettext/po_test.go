@@ -0,0 +1,123 @@
+package gettext
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+const testPO = `# Test catalog
+msgid ""
+msgstr ""
+"Language: de\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+#. Extracted comment
+msgctxt "hello"
+msgid "Hello!"
+msgstr "Hallo!"
+
+msgctxt "hello|formal"
+msgid "Good day!"
+msgstr ""
+"Guten "
+"Tag!"
+
+#, fuzzy
+msgctxt "goodbye"
+msgid "Goodbye!"
+msgstr "Tschüss!"
+
+msgctxt "untranslated"
+msgid "Untranslated"
+msgstr ""
+
+msgctxt "apples"
+msgid "one apple"
+msgid_plural "{n} apples"
+msgstr[0] "ein Apfel"
+msgstr[1] "{n} Äpfel"
+
+msgctxt "escapes"
+msgid "Escapes"
+msgstr "tab\there \"quoted\"\n"
+
+#~ msgid "Obsolete"
+#~ msgstr "Veraltet"
+`
+
+func TestParsePO(t *testing.T) {
+	catalog, err := ParsePO([]byte(testPO))
+	if err != nil {
+		t.Fatalf("ParsePO: %v", err)
+	}
+
+	if got := catalog.Header["Language"]; got != "de" {
+		t.Errorf("Language header = %q, want de", got)
+	}
+	if got, want := catalog.Contexts(), []string{"apples", "escapes", "hello", "hello|formal"}; !slices.Equal(got, want) {
+		t.Errorf("Contexts() = %q, want %q", got, want)
+	}
+
+	for _, tt := range []struct {
+		context string
+		want    string
+		ok      bool
+	}{
+		{"hello", "Hallo!", true},
+		{"hello|formal", "Guten Tag!", true},
+		{"escapes", "tab\there \"quoted\"\n", true},
+		{"apples", "ein Apfel", true},
+		{"goodbye", "", false},
+		{"untranslated", "", false},
+		{"missing", "", false},
+	} {
+		got, ok := catalog.Lookup(tt.context)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Lookup(%q) = %q, %v, want %q, %v", tt.context, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	if got, _ := catalog.GetPlural("apples", "one apple", 5); got != "{n} Äpfel" {
+		t.Errorf("GetPlural(5) = %q, want {n} Äpfel", got)
+	}
+	if m := catalog.index[key("hello", "Hello!")]; m == nil || !slices.Equal(m.Comments, []string{"Extracted comment"}) {
+		t.Errorf("comments of hello = %v, want [Extracted comment]", m)
+	}
+}
+
+func TestParsePOErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		po   string
+		line int
+	}{
+		{"string without keyword", "\"orphan\"\n", 1},
+		{"unknown keyword", "msgid \"a\"\nmsgfoo \"b\"\n", 2},
+		{"unquoted", "msgid a\n", 1},
+		{"unterminated", "msgid \"a\n", 1},
+		{"unescaped quote", "msgid \"a\"b\"\n", 1},
+		{"trailing backslash", "msgid \"a\\\"\n", 1},
+		{"unknown escape", "msgid \"\\q\"\n", 1},
+		{"plural out of order", "msgid \"a\"\nmsgid_plural \"b\"\nmsgstr[1] \"c\"\n", 3},
+		{"plural index", "msgid \"a\"\nmsgstr[x] \"c\"\n", 2},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePO([]byte(tt.po))
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("ParsePO error = %v, want a ParseError", err)
+			}
+			if perr.Line != tt.line {
+				t.Errorf("error line = %d, want %d (%v)", perr.Line, tt.line, err)
+			}
+		})
+	}
+}
+
+func TestParsePOInvalidPluralForms(t *testing.T) {
+	po := "msgid \"\"\nmsgstr \"Plural-Forms: nplurals=2; plural=n !=;\\n\"\n"
+	if _, err := ParsePO([]byte(po)); err == nil {
+		t.Error("ParsePO accepted an invalid Plural-Forms header")
+	}
+}
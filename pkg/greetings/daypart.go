@@ -0,0 +1,68 @@
+package greetings
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+// DayPart starts a window of the day during which a greeting kind is used.
+// A window lasts until the start of the next one and the last window of the
+// day wraps around midnight.
+type DayPart struct {
+	// Start is the beginning of the window in minutes after midnight
+	Start int
+	// Kind is the greeting used during the window
+	Kind string
+}
+
+// At returns the start of a window at the given hour and minute
+func At(hour, minute int) int {
+	return hour*60 + minute
+}
+
+func (d DayPart) String() string {
+	return fmt.Sprintf("%02d:%02d %s", d.Start/60, d.Start%60, d.Kind)
+}
+
+// DefaultDayParts is used for languages that do not declare their own windows
+var DefaultDayParts = []DayPart{
+	{Start: At(5, 0), Kind: KindGoodMorning},
+	{Start: At(12, 0), Kind: KindGoodAfternoon},
+	{Start: At(17, 0), Kind: KindGoodEvening},
+	{Start: At(21, 0), Kind: KindGoodNight},
+}
+
+// KindAt returns the greeting kind of the window containing t. Languages
+// that declare no windows get DefaultDayParts, limited to the kinds they
+// support: a window of a missing kind is part of the window before it, and
+// languages supporting none of the kinds are greeted with hello.
+func KindAt(parts []DayPart, kinds []string, t time.Time) string {
+	if len(parts) == 0 {
+		for _, part := range DefaultDayParts {
+			if slices.Contains(kinds, part.Kind) {
+				parts = append(parts, part)
+			}
+		}
+		if len(parts) == 0 {
+			return KindHello
+		}
+	}
+
+	sorted := make([]DayPart, len(parts))
+	copy(sorted, parts)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	minute := At(t.Hour(), t.Minute())
+
+	// Before the first window of the day we are still in the last one of the previous day
+	kind := sorted[len(sorted)-1].Kind
+	for _, part := range sorted {
+		if part.Start > minute {
+			break
+		}
+		kind = part.Kind
+	}
+	return kind
+}
@@ -0,0 +1,45 @@
+package greetings
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKindAt(t *testing.T) {
+	japanese := []DayPart{
+		{Start: At(4, 0), Kind: KindGoodMorning},
+		{Start: At(10, 30), Kind: KindHello},
+		{Start: At(18, 0), Kind: KindGoodEvening},
+	}
+	daily := []string{KindHello, KindGoodMorning, KindGoodAfternoon, KindGoodEvening, KindGoodNight}
+
+	tests := []struct {
+		name  string
+		parts []DayPart
+		kinds []string
+		at    string
+		want  string
+	}{
+		{"declared window", japanese, daily, "11:00", KindHello},
+		{"start of a window", japanese, daily, "18:00", KindGoodEvening},
+		// The last window of the day wraps around midnight
+		{"before the first window", japanese, daily, "02:00", KindGoodEvening},
+		{"default windows", nil, daily, "13:00", KindGoodAfternoon},
+		{"default night", nil, daily, "23:30", KindGoodNight},
+		{"default night after midnight", nil, daily, "04:59", KindGoodNight},
+		// Default windows of missing kinds belong to the window before them
+		{"no evening greeting", nil, []string{KindGoodMorning, KindGoodAfternoon, KindGoodNight}, "18:00", KindGoodAfternoon},
+		{"no night greeting", nil, []string{KindGoodMorning, KindGoodAfternoon, KindGoodEvening}, "23:00", KindGoodEvening},
+		{"morning only", nil, []string{KindHello, KindGoodMorning}, "20:00", KindGoodMorning},
+		{"no time of day greeting", nil, []string{KindHello, KindGoodBye}, "08:00", KindHello},
+	}
+	for _, tt := range tests {
+		at, err := time.Parse("15:04", tt.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := KindAt(tt.parts, tt.kinds, at); got != tt.want {
+			t.Errorf("%s: KindAt(%s) = %s, want %s", tt.name, tt.at, got, tt.want)
+		}
+	}
+}
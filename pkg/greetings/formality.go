@@ -0,0 +1,61 @@
+package greetings
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Formality is the politeness register of a greeting
+type Formality int
+
+// The zero value is Neutral so requests default to the everyday register.
+// The values match the Formality enum of the plugin protocol.
+const (
+	Neutral Formality = iota
+	Casual
+	Formal
+	Honorific
+)
+
+var formalityNames = map[Formality]string{
+	Neutral:   "neutral",
+	Casual:    "casual",
+	Formal:    "formal",
+	Honorific: "honorific",
+}
+
+func (f Formality) String() string {
+	if name, ok := formalityNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("Formality(%d)", int(f))
+}
+
+// ParseFormality parses a register name such as "casual" or "honorific"
+func ParseFormality(s string) (Formality, error) {
+	for f, name := range formalityNames {
+		if strings.EqualFold(s, name) {
+			return f, nil
+		}
+	}
+	return Neutral, fmt.Errorf("unknown formality %q (expected casual, neutral, formal or honorific)", s)
+}
+
+// formalityFallbacks lists, for every register, the registers to try in order.
+// A missing register falls back to the closest one on the scale
+// casual < neutral < formal < honorific, erring on the polite side when two
+// are equally close: being too polite is a smaller faux pas than being too familiar.
+var formalityFallbacks = map[Formality][]Formality{
+	Casual:    {Casual, Neutral, Formal, Honorific},
+	Neutral:   {Neutral, Formal, Casual, Honorific},
+	Formal:    {Formal, Honorific, Neutral, Casual},
+	Honorific: {Honorific, Formal, Neutral, Casual},
+}
+
+// Fallbacks returns the registers to try, in order, when f is requested
+func (f Formality) Fallbacks() []Formality {
+	if fallbacks, ok := formalityFallbacks[f]; ok {
+		return fallbacks
+	}
+	return formalityFallbacks[Neutral]
+}
@@ -1,17 +1,125 @@
 package greetings
 
+import (
+	"fmt"
+	"strings"
+)
+
+// Greeting kinds provided by the bundled languages. Plugins are free to
+// declare any other kind through Kinds.
+const (
+	KindHello           = "hello"
+	KindGoodMorning     = "goodmorning"
+	KindGoodAfternoon   = "goodafternoon"
+	KindGoodEvening     = "goodevening"
+	KindGoodNight       = "goodnight"
+	KindGoodBye         = "goodbye"
+	KindWelcome         = "welcome"
+	KindCongratulations = "congratulations"
+	KindHappyBirthday   = "happybirthday"
+	KindNewYear         = "newyear"
+)
+
+// Request carries the parameters of a single greeting
+type Request struct {
+	// Kind selects the greeting, e.g. "hello" or "goodmorning"
+	Kind string
+	// Recipient is the name of the person being greeted, empty for a generic greeting
+	Recipient string
+	// Formality is the requested politeness register
+	Formality Formality
+}
+
+// Greeting is a greeting rendered for a request
+type Greeting struct {
+	// Text is the greeting in the language's native script
+	Text string
+	// Script is the ISO 15924 code of the script of Text, e.g. "Deva" or "Jpan"
+	Script string
+	// Romanization is Text transcribed into the Latin alphabet, empty for Latin scripts
+	Romanization string
+	// Pronunciation is an IPA or respelling hint for saying the greeting
+	Pronunciation string
+	// Meaning is the literal English meaning of the greeting
+	Meaning string
+}
+
+// String formats the greeting as its native text followed by its romanization, if any
+func (g Greeting) String() string {
+	if g.Romanization == "" || g.Romanization == g.Text {
+		return g.Text
+	}
+	return g.Text + " (" + g.Romanization + ")"
+}
+
+// Romanized returns the greeting in the Latin alphabet, falling back to the native text
+func (g Greeting) Romanized() string {
+	if g.Romanization == "" {
+		return g.Text
+	}
+	return g.Romanization
+}
+
 // Greeter defines the interface for different greeting types
 type Greeter interface {
-	Hello() string
-	GoodMorning() string
-	GoodAfternoon() string
-	GoodNight() string
-	GoodBye() string
+	// Greet returns the greeting of the requested kind, or an
+	// *UnsupportedKindError if the greeter does not provide it
+	Greet(req Request) (Greeting, error)
+	// Kinds lists the greeting kinds the greeter supports
+	Kinds() []string
 }
 
 type Plugin interface {
 	Greeter
 	Name() string
+	// Info describes the plugin, see Describe for the fields filled in by the host
+	Info() Info
 	Init() error
 	Close() error
 }
+
+// Info holds the metadata of a language plugin
+type Info struct {
+	Name        string
+	DisplayName string
+	Version     string
+	Author      string
+	Description string
+	// Locale is the BCP 47 tag of the language, e.g. "hi" or "pt-BR"
+	Locale string
+	// Kinds lists the supported greeting kinds
+	Kinds []string
+	// DayParts declares which greeting fits which time of day, see KindAt
+	DayParts []DayPart
+	// Occasions lists the festivals and holidays the language has greetings for
+	Occasions []Occasion
+	// ProtocolVersion is the plugin protocol spoken by an external plugin, 0 for built-in ones
+	ProtocolVersion int
+}
+
+// Describe returns the metadata of a plugin, completing what the plugin
+// reports with its name and supported greeting kinds
+func Describe(p Plugin) Info {
+	info := p.Info()
+	info.Name = p.Name()
+	info.Kinds = p.Kinds()
+	if info.DisplayName == "" {
+		info.DisplayName = info.Name
+	}
+	return info
+}
+
+// UnsupportedKindError is returned when a language does not provide the requested greeting kind
+type UnsupportedKindError struct {
+	Kind      string
+	Language  string
+	Supported []string
+}
+
+func (e *UnsupportedKindError) Error() string {
+	msg := fmt.Sprintf("greeting %q is not supported by %s", e.Kind, e.Language)
+	if len(e.Supported) > 0 {
+		msg += fmt.Sprintf(" (supported: %s)", strings.Join(e.Supported, ", "))
+	}
+	return msg
+}
@@ -0,0 +1,14 @@
+package greetings
+
+// Occasion is a festival or holiday with a greeting of its own, used by the
+// greet command instead of the time of day greeting while it is observed
+type Occasion struct {
+	// Kind is the greeting used on the occasion, e.g. "diwali"
+	Kind string
+	// Name is the display name of the occasion, e.g. "Diwali"
+	Name string
+	// Rule tells on which day the occasion falls, see calendar.ParseRule
+	Rule string
+	// Days is how many days the occasion lasts, 1 when 0
+	Days int
+}
@@ -0,0 +1,89 @@
+package greetings
+
+import "sort"
+
+// Phrase holds the templates of one greeting, see Render. Only Text is required.
+type Phrase struct {
+	Text          string
+	Romanization  string
+	Pronunciation string
+	Meaning       string
+	// Weight is the relative frequency of the phrase among its variants, see Variant
+	Weight int
+	// Variants lists other ways of saying the greeting in the same register
+	Variants []Phrase
+}
+
+// Render fills the phrase templates for the given request
+func (p Phrase) Render(req Request) Greeting {
+	return Greeting{
+		Text:          Render(p.Text, req),
+		Romanization:  Render(p.Romanization, req),
+		Pronunciation: Render(p.Pronunciation, req),
+		Meaning:       Render(p.Meaning, req),
+	}
+}
+
+// Registers maps politeness registers to the phrase used in each of them.
+// Registers that are missing are served through Formality.Fallbacks.
+type Registers map[Formality]Phrase
+
+// Pick returns the phrase for the requested register or its closest fallback
+func (r Registers) Pick(formality Formality) (Phrase, Formality, bool) {
+	for _, f := range formality.Fallbacks() {
+		if phrase, ok := r[f]; ok {
+			return phrase, f, true
+		}
+	}
+	return Phrase{}, formality, false
+}
+
+// Phrasebook holds the greetings of a language that is a plain string table.
+// It implements the bulk of Greeter for such languages.
+type Phrasebook struct {
+	// Script is the ISO 15924 code of the script the phrases are written in
+	Script string
+	// Greetings maps greeting kinds to their phrases in each register
+	Greetings map[string]Registers
+}
+
+// Greet renders the phrase registered for the requested kind and register
+func (p *Phrasebook) Greet(language string, req Request) (Greeting, error) {
+	phrase, _, ok := p.Greetings[req.Kind].Pick(req.Formality)
+	if !ok {
+		return Greeting{}, &UnsupportedKindError{Kind: req.Kind, Language: language, Supported: p.Kinds()}
+	}
+
+	return p.render(phrase, req), nil
+}
+
+// Variants renders the phrase registered for the requested kind and register
+// followed by its variants
+func (p *Phrasebook) Variants(language string, req Request) ([]Variant, error) {
+	phrase, _, ok := p.Greetings[req.Kind].Pick(req.Formality)
+	if !ok {
+		return nil, &UnsupportedKindError{Kind: req.Kind, Language: language, Supported: p.Kinds()}
+	}
+
+	variants := []Variant{{Greeting: p.render(phrase, req), Weight: phrase.Weight}}
+	for _, v := range phrase.Variants {
+		variants = append(variants, Variant{Greeting: p.render(v, req), Weight: v.Weight})
+	}
+	return variants, nil
+}
+
+func (p *Phrasebook) render(phrase Phrase, req Request) Greeting {
+	greeting := phrase.Render(req)
+	greeting.Script = p.Script
+	return greeting
+}
+
+// Kinds returns the greeting kinds in the phrasebook, sorted by name
+func (p *Phrasebook) Kinds() []string {
+	kinds := make([]string, 0, len(p.Greetings))
+	for kind := range p.Greetings {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
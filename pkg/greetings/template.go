@@ -0,0 +1,53 @@
+package greetings
+
+import "strings"
+
+// NamePlaceholder marks where the recipient's name goes in a greeting template
+const NamePlaceholder = "{name}"
+
+// Render fills a greeting template for the given request.
+//
+// Templates let every language place the recipient where its grammar wants it.
+// NamePlaceholder is replaced by the recipient's name, and text enclosed in
+// square brackets is only kept when a recipient is given, so a single template
+// covers both forms:
+//
+//	"Hello[, {name}]!"   -> "Hello!" or "Hello, Priya!"
+//	"[{name}さん、]こんにちは!" -> "こんにちは!" or "Priyaさん、こんにちは!"
+//
+// A doubled bracket, "[[" or "]]", writes a literal one. A '[' that is never
+// closed and a ']' that closes nothing are kept as they are. The name is
+// inserted last, so brackets and placeholders in it are not interpreted.
+func Render(template string, req Request) string {
+	var b, section strings.Builder
+	open := false
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		out := &b
+		if open {
+			out = &section
+		}
+
+		switch {
+		case (c == '[' || c == ']') && i+1 < len(template) && template[i+1] == c:
+			out.WriteByte(c)
+			i++
+		case c == '[' && !open:
+			open = true
+		case c == ']' && open:
+			open = false
+			if req.Recipient != "" {
+				b.WriteString(section.String())
+			}
+			section.Reset()
+		default:
+			out.WriteByte(c)
+		}
+	}
+	if open {
+		b.WriteByte('[')
+		b.WriteString(section.String())
+	}
+
+	return strings.ReplaceAll(b.String(), NamePlaceholder, req.Recipient)
+}
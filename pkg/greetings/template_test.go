@@ -0,0 +1,41 @@
+package greetings
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		template  string
+		recipient string
+		want      string
+	}{
+		{"Hello[, {name}]!", "", "Hello!"},
+		{"Hello[, {name}]!", "Priya", "Hello, Priya!"},
+		{"Hello!", "Priya", "Hello!"},
+		// Several optional sections
+		{"Good morning[, {name}][, my friend]!", "", "Good morning!"},
+		{"Good morning[, {name}][, my friend]!", "Priya", "Good morning, Priya, my friend!"},
+		// The name mid-sentence
+		{"[{name}さん、]こんにちは!", "", "こんにちは!"},
+		{"[{name}さん、]こんにちは!", "Priya", "Priyaさん、こんにちは!"},
+		{"Dear[ {name}], welcome!", "Priya", "Dear Priya, welcome!"},
+		// Unmatched brackets are kept
+		{"Hello[, {name}!", "", "Hello[, !"},
+		{"Hello[, {name}!", "Priya", "Hello[, Priya!"},
+		{"Hello], {name}!", "Priya", "Hello], Priya!"},
+		{"[a[b]c", "Priya", "a[bc"},
+		// Doubled brackets are literal
+		{"[[1]] Hello[, {name}]!", "", "[1] Hello!"},
+		{"Hello[ [[{name}]]]!", "Priya", "Hello [Priya]!"},
+		{"Hello[ [[{name}]]]!", "", "Hello!"},
+		// The name is not interpreted
+		{"Hello[, {name}]!", "[Priya]", "Hello, [Priya]!"},
+		{"Hello[, {name}]!", "{name}", "Hello, {name}!"},
+		{"Hello[, {name}]!", "]]", "Hello, ]]!"},
+	}
+	for _, tt := range tests {
+		got := Render(tt.template, Request{Recipient: tt.recipient})
+		if got != tt.want {
+			t.Errorf("Render(%q, %q) = %q, want %q", tt.template, tt.recipient, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package greetings
+
+import (
+	"errors"
+	"math/rand/v2"
+)
+
+// ErrNoVariants is returned when picking a variant from an empty list
+var ErrNoVariants = errors.New("no variants to pick from")
+
+// Variant is one of several ways of saying a greeting
+type Variant struct {
+	Greeting
+	// Weight is the relative frequency of the variant, values below 1 count as 1
+	Weight int
+}
+
+func (v Variant) weight() int {
+	if v.Weight < 1 {
+		return 1
+	}
+	return v.Weight
+}
+
+// VariantGreeter is implemented by greeters that know several ways of saying
+// a greeting
+type VariantGreeter interface {
+	// Variants returns the variants of the requested greeting, starting with
+	// the one Greet returns, or an *UnsupportedKindError
+	Variants(req Request) ([]Variant, error)
+}
+
+// VariantsOf returns the variants of a greeting, the greeting returned by
+// Greet for greeters that do not implement VariantGreeter
+func VariantsOf(g Greeter, req Request) ([]Variant, error) {
+	if vg, ok := g.(VariantGreeter); ok {
+		return vg.Variants(req)
+	}
+
+	greeting, err := g.Greet(req)
+	if err != nil {
+		return nil, err
+	}
+	return []Variant{{Greeting: greeting, Weight: 1}}, nil
+}
+
+// PickRandom picks a variant at random, in proportion to the weights. It
+// fails with ErrNoVariants for an empty list.
+func PickRandom(variants []Variant, r *rand.Rand) (Variant, error) {
+	if len(variants) == 0 {
+		return Variant{}, ErrNoVariants
+	}
+	total := 0
+	for _, v := range variants {
+		total += v.weight()
+	}
+
+	n := r.IntN(total)
+	for _, v := range variants {
+		if n < v.weight() {
+			return v, nil
+		}
+		n -= v.weight()
+	}
+	return variants[len(variants)-1], nil
+}
+
+// PickRotating picks the variant of the n-th greeting in a smooth weighted
+// round robin: every variant comes up as often as its weight in each cycle,
+// spread out rather than in runs, e.g. A B A C B A for weights 3, 2 and 1.
+// It fails with ErrNoVariants for an empty list.
+func PickRotating(variants []Variant, n int) (Variant, error) {
+	if len(variants) == 0 {
+		return Variant{}, ErrNoVariants
+	}
+	total := 0
+	for _, v := range variants {
+		total += v.weight()
+	}
+
+	current := make([]int, len(variants))
+	picked := 0
+	for step := 0; step <= n%total; step++ {
+		picked = 0
+		for i, v := range variants {
+			current[i] += v.weight()
+			if current[i] > current[picked] {
+				picked = i
+			}
+		}
+		current[picked] -= total
+	}
+	return variants[picked], nil
+}
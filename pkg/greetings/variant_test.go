@@ -0,0 +1,98 @@
+package greetings
+
+import (
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// weighted returns variants named A, B, C... with the given weights
+func weighted(weights ...int) []Variant {
+	variants := make([]Variant, len(weights))
+	for i, w := range weights {
+		variants[i] = Variant{Greeting: Greeting{Text: string(rune('A' + i))}, Weight: w}
+	}
+	return variants
+}
+
+func TestPickRotating(t *testing.T) {
+	tests := []struct {
+		weights []int
+		want    string
+	}{
+		{[]int{3, 2, 1}, "ABACBA"},
+		{[]int{1, 1, 1}, "ABC"},
+		// Weights below 1 count as 1
+		{[]int{0, -2}, "AB"},
+		{[]int{1}, "A"},
+	}
+	for _, tt := range tests {
+		variants := weighted(tt.weights...)
+		var got strings.Builder
+		// Two cycles, the second one repeating the first
+		for n := range 2 * len(tt.want) {
+			v, err := PickRotating(variants, n)
+			if err != nil {
+				t.Fatalf("PickRotating(%v, %d): %v", tt.weights, n, err)
+			}
+			got.WriteString(v.Text)
+		}
+		if want := strings.Repeat(tt.want, 2); got.String() != want {
+			t.Errorf("weights %v picked %s, want %s", tt.weights, got.String(), want)
+		}
+	}
+}
+
+func TestPickRandom(t *testing.T) {
+	variants := weighted(3, 2, 1)
+
+	// A seed always picks the same variants
+	pick := func(seed uint64) string {
+		r := rand.New(rand.NewPCG(seed, seed))
+		var picked strings.Builder
+		for range 20 {
+			v, err := PickRandom(variants, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			picked.WriteString(v.Text)
+		}
+		return picked.String()
+	}
+	if first, again := pick(42), pick(42); first != again {
+		t.Errorf("seed 42 picked %s, then %s", first, again)
+	}
+
+	// Variants come up in proportion to their weights
+	counts := make(map[string]int)
+	r := rand.New(rand.NewPCG(1, 2))
+	for range 6000 {
+		v, _ := PickRandom(variants, r)
+		counts[v.Text]++
+	}
+	for name, want := range map[string]int{"A": 3000, "B": 2000, "C": 1000} {
+		if got := counts[name]; got < want*9/10 || got > want*11/10 {
+			t.Errorf("%s picked %d times out of 6000, want about %d", name, got, want)
+		}
+	}
+
+	// Weights below 1 count as 1
+	counts = make(map[string]int)
+	for range 1000 {
+		v, _ := PickRandom(weighted(0, 0), r)
+		counts[v.Text]++
+	}
+	if counts["A"] == 0 || counts["B"] == 0 {
+		t.Errorf("picked %v, want both variants", counts)
+	}
+}
+
+func TestPickEmpty(t *testing.T) {
+	if _, err := PickRandom(nil, rand.New(rand.NewPCG(1, 1))); !errors.Is(err, ErrNoVariants) {
+		t.Errorf("PickRandom(nil) = %v, want ErrNoVariants", err)
+	}
+	if _, err := PickRotating(nil, 3); !errors.Is(err, ErrNoVariants) {
+		t.Errorf("PickRotating(nil) = %v, want ErrNoVariants", err)
+	}
+}
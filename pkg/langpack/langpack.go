@@ -0,0 +1,598 @@
+// Package langpack loads language packs: YAML, JSON or TOML files describing
+// the greetings of a language, served as ordinary greetings.Plugin instances.
+//
+// A pack looks like this in YAML:
+//
+//	name: french
+//	display_name: Français
+//	version: 1.0.0
+//	author: Jane Doe
+//	description: French greetings
+//	locale: fr
+//	script: Latn
+//	day_parts:
+//	  - start: "05:00"
+//	    kind: goodmorning
+//	  - start: "18:00"
+//	    kind: goodevening
+//	occasions:
+//	  - kind: christmas
+//	    name: Noël
+//	    rule: "12-25"
+//	greetings:
+//	  hello:
+//	    neutral: "Bonjour[, {name}]!"
+//	    casual:
+//	      text: "Salut[ {name}]!"
+//	      meaning: hi
+//
+// Only name and greetings are required. A register is either a template
+// string or a table with text, romanization, pronunciation and meaning, see
+// greetings.Phrase and greetings.Render, or a list of such variants, each
+// table taking an optional weight:
+//
+//	casual:
+//	  - text: "Salut[ {name}]!"
+//	    weight: 3
+//	  - "Coucou[ {name}]!"
+package langpack
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/sirupsen/logrus"
+	"github.com/unsuman/greeter/pkg/calendar"
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin/registry"
+	"gopkg.in/yaml.v3"
+)
+
+// Extensions lists the file extensions recognised as language packs
+var Extensions = []string{".yaml", ".yml", ".json", ".toml"}
+
+// Error reports a malformed language pack
+type Error struct {
+	File string
+	// Field is the path of the offending field, e.g. "greetings.hello.casual", empty for syntax errors
+	Field string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("language pack %s: %v", e.File, e.Err)
+	}
+	return fmt.Sprintf("language pack %s: %s: %v", e.File, e.Field, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Pack is a language loaded from a language pack file
+type Pack struct {
+	// Path is the file the pack was loaded from
+	Path    string
+	info    greetings.Info
+	phrases *greetings.Phrasebook
+}
+
+// Greet renders a greeting from the pack
+func (p *Pack) Greet(req greetings.Request) (greetings.Greeting, error) {
+	return p.phrases.Greet(p.info.Name, req)
+}
+
+// Variants renders the requested greeting and its variants from the pack
+func (p *Pack) Variants(req greetings.Request) ([]greetings.Variant, error) {
+	return p.phrases.Variants(p.info.Name, req)
+}
+
+// Kinds returns the greeting kinds defined by the pack
+func (p *Pack) Kinds() []string {
+	return p.phrases.Kinds()
+}
+
+// Name returns the language name declared by the pack
+func (p *Pack) Name() string {
+	return p.info.Name
+}
+
+// Info returns the metadata declared by the pack
+func (p *Pack) Info() greetings.Info {
+	return p.info
+}
+
+func (p *Pack) Init() error {
+	return nil
+}
+
+func (p *Pack) Close() error {
+	return nil
+}
+
+// IsPack reports whether a file name has a language pack extension
+func IsPack(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Load reads and validates a language pack file
+func Load(path string) (*Pack, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &Error{File: path, Err: err}
+	}
+
+	var doc map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".json":
+		err = json.Unmarshal(data, &doc)
+	case ".toml":
+		err = toml.Unmarshal(data, &doc)
+	default:
+		err = fmt.Errorf("unsupported file type, expected one of %s", strings.Join(Extensions, ", "))
+	}
+	if err != nil {
+		return nil, &Error{File: path, Err: err}
+	}
+
+	pack, err := parse(doc)
+	if err != nil {
+		var perr *Error
+		if errors.As(err, &perr) {
+			perr.File = path
+			return nil, perr
+		}
+		return nil, &Error{File: path, Err: err}
+	}
+
+	pack.Path = path
+	return pack, nil
+}
+
+// LoadDir loads every language pack of a directory, sorted by file name.
+// Malformed packs are reported in the returned errors and skipped; a missing
+// directory holds no packs.
+func LoadDir(dir string) ([]*Pack, []error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, []error{fmt.Errorf("failed to read language packs directory: %w", err)}
+	}
+
+	var packs []*Pack
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || !IsPack(entry.Name()) {
+			continue
+		}
+
+		pack, err := Load(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		packs = append(packs, pack)
+	}
+
+	return packs, errs
+}
+
+// RegisterDir loads the language packs of a directory into a registry.
+// Malformed packs are logged and skipped.
+func RegisterDir(reg *registry.Registry, dir string, logger *logrus.Logger) {
+	packs, errs := LoadDir(dir)
+	for _, err := range errs {
+		logger.Errorf("Skipping %v", err)
+	}
+
+	for _, pack := range packs {
+		logger.Debugf("Loaded language pack %s from %s", pack.Name(), pack.Path)
+		reg.Register(pack)
+	}
+}
+
+var (
+	namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	clockFormat = regexp.MustCompile(`^([01]?[0-9]|2[0-3]):([0-5][0-9])$`)
+
+	topLevelFields = []string{"name", "display_name", "version", "author", "description", "locale", "script", "day_parts", "occasions", "greetings"}
+	phraseFields   = []string{"text", "romanization", "pronunciation", "meaning", "weight"}
+)
+
+// parse validates a decoded pack document
+func parse(doc map[string]any) (*Pack, error) {
+	if err := checkFields("", doc, topLevelFields); err != nil {
+		return nil, err
+	}
+
+	info := greetings.Info{}
+	strs := map[string]*string{
+		"name":         &info.Name,
+		"display_name": &info.DisplayName,
+		"version":      &info.Version,
+		"author":       &info.Author,
+		"description":  &info.Description,
+		"locale":       &info.Locale,
+	}
+	for field, dst := range strs {
+		if err := optionalString(doc, "", field, dst); err != nil {
+			return nil, err
+		}
+	}
+
+	if info.Name == "" {
+		return nil, &Error{Field: "name", Err: fmt.Errorf("is required")}
+	}
+	if !namePattern.MatchString(info.Name) {
+		return nil, &Error{Field: "name", Err: fmt.Errorf("%q must be lower case letters, digits, '-' or '_'", info.Name)}
+	}
+
+	phrases := &greetings.Phrasebook{Greetings: make(map[string]greetings.Registers)}
+	if err := optionalString(doc, "", "script", &phrases.Script); err != nil {
+		return nil, err
+	}
+
+	raw, ok := doc["greetings"].(map[string]any)
+	if !ok || len(raw) == 0 {
+		return nil, &Error{Field: "greetings", Err: fmt.Errorf("must be a non-empty table of greeting kinds")}
+	}
+	for _, kind := range sortedKeys(raw) {
+		registers, err := parseRegisters("greetings."+kind, raw[kind])
+		if err != nil {
+			return nil, err
+		}
+		phrases.Greetings[kind] = registers
+	}
+
+	if value, ok := doc["day_parts"]; ok {
+		parts, err := parseDayParts(value, phrases)
+		if err != nil {
+			return nil, err
+		}
+		info.DayParts = parts
+	}
+
+	if value, ok := doc["occasions"]; ok {
+		occasions, err := parseOccasions(value, phrases)
+		if err != nil {
+			return nil, err
+		}
+		info.Occasions = occasions
+	}
+
+	return &Pack{info: info, phrases: phrases}, nil
+}
+
+// parseRegisters validates the registers of a greeting kind
+func parseRegisters(field string, value any) (greetings.Registers, error) {
+	table, ok := value.(map[string]any)
+	if !ok || len(table) == 0 {
+		return nil, &Error{Field: field, Err: fmt.Errorf("must be a non-empty table of registers")}
+	}
+
+	registers := make(greetings.Registers)
+	for _, name := range sortedKeys(table) {
+		v := table[name]
+		f := field + "." + name
+		formality, err := greetings.ParseFormality(name)
+		if err != nil {
+			return nil, &Error{Field: f, Err: err}
+		}
+
+		phrase, err := parsePhrase(f, v)
+		if err != nil {
+			return nil, err
+		}
+		registers[formality] = phrase
+	}
+
+	return registers, nil
+}
+
+// parsePhrase validates a phrase given as a template string, a table or a
+// list of variants, the first of which is the default one
+func parsePhrase(field string, value any) (greetings.Phrase, error) {
+	var phrase greetings.Phrase
+
+	switch v := value.(type) {
+	case []any:
+		if len(v) == 0 {
+			return phrase, &Error{Field: field, Err: fmt.Errorf("must list at least one variant")}
+		}
+		for i, item := range v {
+			if _, nested := item.([]any); nested {
+				return phrase, &Error{Field: fmt.Sprintf("%s[%d]", field, i), Err: fmt.Errorf("variants cannot be nested")}
+			}
+			variant, err := parsePhrase(fmt.Sprintf("%s[%d]", field, i), item)
+			if err != nil {
+				return phrase, err
+			}
+			if i == 0 {
+				phrase = variant
+			} else {
+				phrase.Variants = append(phrase.Variants, variant)
+			}
+		}
+		return phrase, nil
+	case []map[string]any:
+		// TOML arrays of tables decode as []map[string]any
+		list := make([]any, len(v))
+		for i, t := range v {
+			list[i] = t
+		}
+		return parsePhrase(field, list)
+	case string:
+		phrase.Text = v
+	case map[string]any:
+		if err := checkFields(field, v, phraseFields); err != nil {
+			return phrase, err
+		}
+		for name, dst := range map[string]*string{
+			"text":          &phrase.Text,
+			"romanization":  &phrase.Romanization,
+			"pronunciation": &phrase.Pronunciation,
+			"meaning":       &phrase.Meaning,
+		} {
+			if err := optionalString(v, field, name, dst); err != nil {
+				return phrase, err
+			}
+		}
+		if weight, ok := v["weight"]; ok {
+			w, err := parseCount(weight)
+			if err != nil {
+				return phrase, &Error{Field: field + ".weight", Err: err}
+			}
+			phrase.Weight = w
+		}
+	default:
+		return phrase, &Error{Field: field, Err: fmt.Errorf("must be a template string, a table with text, romanization, pronunciation, meaning and weight, or a list of variants")}
+	}
+
+	if strings.TrimSpace(phrase.Text) == "" {
+		return phrase, &Error{Field: field, Err: fmt.Errorf("text is required")}
+	}
+
+	for name, template := range map[string]string{
+		"text":          phrase.Text,
+		"romanization":  phrase.Romanization,
+		"pronunciation": phrase.Pronunciation,
+		"meaning":       phrase.Meaning,
+	} {
+		if err := checkTemplate(template); err != nil {
+			return phrase, &Error{Field: field + "." + name, Err: err}
+		}
+	}
+
+	return phrase, nil
+}
+
+// parseCount validates a whole number of at least 1, which YAML, JSON and TOML
+// decode as different number types
+func parseCount(value any) (int, error) {
+	var w float64
+	switch n := value.(type) {
+	case int:
+		w = float64(n)
+	case int64:
+		w = float64(n)
+	case float64:
+		w = n
+	default:
+		return 0, fmt.Errorf("must be a number, got %T", value)
+	}
+	if w < 1 || w != float64(int(w)) {
+		return 0, fmt.Errorf("must be a whole number of at least 1, got %v", value)
+	}
+	return int(w), nil
+}
+
+// parseDayParts validates the time windows of the pack
+func parseDayParts(value any, phrases *greetings.Phrasebook) ([]greetings.DayPart, error) {
+	list, ok := value.([]any)
+	if !ok {
+		// TOML arrays of tables decode as []map[string]any
+		if tables, isTables := value.([]map[string]any); isTables {
+			for _, t := range tables {
+				list = append(list, t)
+			}
+			ok = true
+		}
+	}
+	if !ok {
+		return nil, &Error{Field: "day_parts", Err: fmt.Errorf("must be a list of {start, kind} entries")}
+	}
+
+	var parts []greetings.DayPart
+	for i, item := range list {
+		field := fmt.Sprintf("day_parts[%d]", i)
+		entry, ok := item.(map[string]any)
+		if !ok {
+			return nil, &Error{Field: field, Err: fmt.Errorf("must be a table with start and kind")}
+		}
+		if err := checkFields(field, entry, []string{"start", "kind"}); err != nil {
+			return nil, err
+		}
+
+		var start, kind string
+		if err := optionalString(entry, field, "start", &start); err != nil {
+			return nil, err
+		}
+		if err := optionalString(entry, field, "kind", &kind); err != nil {
+			return nil, err
+		}
+
+		m := clockFormat.FindStringSubmatch(start)
+		if m == nil {
+			return nil, &Error{Field: field + ".start", Err: fmt.Errorf("%q is not a HH:MM time", start)}
+		}
+		if _, exists := phrases.Greetings[kind]; !exists {
+			return nil, &Error{Field: field + ".kind", Err: fmt.Errorf("greeting %q is not defined in greetings", kind)}
+		}
+
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		parts = append(parts, greetings.DayPart{Start: greetings.At(hour, minute), Kind: kind})
+	}
+
+	return parts, nil
+}
+
+// parseOccasions validates the festivals and holidays of the pack
+func parseOccasions(value any, phrases *greetings.Phrasebook) ([]greetings.Occasion, error) {
+	list, ok := value.([]any)
+	if !ok {
+		// TOML arrays of tables decode as []map[string]any
+		if tables, isTables := value.([]map[string]any); isTables {
+			for _, t := range tables {
+				list = append(list, t)
+			}
+			ok = true
+		}
+	}
+	if !ok {
+		return nil, &Error{Field: "occasions", Err: fmt.Errorf("must be a list of {kind, name, rule, days} entries")}
+	}
+
+	var occasions []greetings.Occasion
+	for i, item := range list {
+		field := fmt.Sprintf("occasions[%d]", i)
+		entry, ok := item.(map[string]any)
+		if !ok {
+			return nil, &Error{Field: field, Err: fmt.Errorf("must be a table with kind, name, rule and days")}
+		}
+		if err := checkFields(field, entry, []string{"kind", "name", "rule", "days"}); err != nil {
+			return nil, err
+		}
+
+		var o greetings.Occasion
+		for name, dst := range map[string]*string{"kind": &o.Kind, "name": &o.Name, "rule": &o.Rule} {
+			if err := optionalString(entry, field, name, dst); err != nil {
+				return nil, err
+			}
+		}
+
+		if _, exists := phrases.Greetings[o.Kind]; !exists {
+			return nil, &Error{Field: field + ".kind", Err: fmt.Errorf("greeting %q is not defined in greetings", o.Kind)}
+		}
+		if _, err := calendar.ParseRule(o.Rule); err != nil {
+			return nil, &Error{Field: field + ".rule", Err: err}
+		}
+		if days, ok := entry["days"]; ok {
+			d, err := parseCount(days)
+			if err != nil {
+				return nil, &Error{Field: field + ".days", Err: err}
+			}
+			o.Days = d
+		}
+		if o.Name == "" {
+			o.Name = o.Kind
+		}
+
+		occasions = append(occasions, o)
+	}
+
+	return occasions, nil
+}
+
+// checkFields rejects unknown fields, which usually are typos
+func checkFields(prefix string, table map[string]any, known []string) error {
+	var unknown []string
+	for name := range table {
+		found := false
+		for _, k := range known {
+			if name == k {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	sort.Strings(unknown)
+	field := unknown[0]
+	if prefix != "" {
+		field = prefix + "." + field
+	}
+	return &Error{Field: field, Err: fmt.Errorf("unknown field (expected one of %s)", strings.Join(known, ", "))}
+}
+
+// sortedKeys returns the keys of a table in order, so errors are reported deterministically
+func sortedKeys(table map[string]any) []string {
+	keys := make([]string, 0, len(table))
+	for k := range table {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// optionalString reads a string field of the table at prefix if present
+func optionalString(table map[string]any, prefix, name string, dst *string) error {
+	value, ok := table[name]
+	if !ok {
+		return nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		field := name
+		if prefix != "" {
+			field = prefix + "." + name
+		}
+		return &Error{Field: field, Err: fmt.Errorf("must be a string, got %T", value)}
+	}
+	*dst = s
+	return nil
+}
+
+// checkTemplate rejects templates with unbalanced optional sections.
+// Doubled brackets are literal ones and balance nothing.
+func checkTemplate(template string) error {
+	open := false
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		if (c == '[' || c == ']') && i+1 < len(template) && template[i+1] == c {
+			i++
+			continue
+		}
+		switch c {
+		case '[':
+			if open {
+				return fmt.Errorf("nested '[' in template %q", template)
+			}
+			open = true
+		case ']':
+			if !open {
+				return fmt.Errorf("unmatched ']' in template %q", template)
+			}
+			open = false
+		}
+	}
+	if open {
+		return fmt.Errorf("unclosed '[' in template %q", template)
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package langpack
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/unsuman/greeter/pkg/greetings"
+)
+
+// writePack writes a language pack file to a temporary directory
+func writePack(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// The same French pack in every format
+var validPacks = map[string]string{
+	"french.yaml": `name: french
+display_name: Français
+version: 1.0.0
+locale: fr
+script: Latn
+day_parts:
+  - start: "05:00"
+    kind: hello
+  - start: "18:00"
+    kind: goodevening
+occasions:
+  - kind: christmas
+    name: Noël
+    rule: "12-25"
+    days: 2
+greetings:
+  hello:
+    neutral: "Bonjour[, {name}]!"
+    casual:
+      - text: "Salut[ {name}]!"
+        meaning: hi
+        weight: 3
+      - "Coucou[ {name}]!"
+  goodevening:
+    neutral: "Bonsoir[, {name}]!"
+  christmas:
+    neutral: "Joyeux Noël[, {name}]!"
+`,
+	"french.json": `{
+  "name": "french",
+  "display_name": "Français",
+  "version": "1.0.0",
+  "locale": "fr",
+  "script": "Latn",
+  "day_parts": [{"start": "05:00", "kind": "hello"}, {"start": "18:00", "kind": "goodevening"}],
+  "occasions": [{"kind": "christmas", "name": "Noël", "rule": "12-25", "days": 2}],
+  "greetings": {
+    "hello": {
+      "neutral": "Bonjour[, {name}]!",
+      "casual": [{"text": "Salut[ {name}]!", "meaning": "hi", "weight": 3}, "Coucou[ {name}]!"]
+    },
+    "goodevening": {"neutral": "Bonsoir[, {name}]!"},
+    "christmas": {"neutral": "Joyeux Noël[, {name}]!"}
+  }
+}
+`,
+	"french.toml": `name = "french"
+display_name = "Français"
+version = "1.0.0"
+locale = "fr"
+script = "Latn"
+
+[[day_parts]]
+start = "05:00"
+kind = "hello"
+
+[[day_parts]]
+start = "18:00"
+kind = "goodevening"
+
+[[occasions]]
+kind = "christmas"
+name = "Noël"
+rule = "12-25"
+days = 2
+
+[greetings.hello]
+neutral = "Bonjour[, {name}]!"
+casual = [{ text = "Salut[ {name}]!", meaning = "hi", weight = 3 }, "Coucou[ {name}]!"]
+
+[greetings.goodevening]
+neutral = "Bonsoir[, {name}]!"
+
+[greetings.christmas]
+neutral = "Joyeux Noël[, {name}]!"
+`,
+}
+
+func TestLoad(t *testing.T) {
+	wantInfo := greetings.Info{
+		Name:        "french",
+		DisplayName: "Français",
+		Version:     "1.0.0",
+		Locale:      "fr",
+		DayParts:    []greetings.DayPart{{Start: greetings.At(5, 0), Kind: "hello"}, {Start: greetings.At(18, 0), Kind: "goodevening"}},
+		Occasions:   []greetings.Occasion{{Kind: "christmas", Name: "Noël", Rule: "12-25", Days: 2}},
+	}
+	wantVariants := []greetings.Variant{
+		{Greeting: greetings.Greeting{Text: "Salut Priya!", Script: "Latn", Meaning: "hi"}, Weight: 3},
+		{Greeting: greetings.Greeting{Text: "Coucou Priya!", Script: "Latn"}},
+	}
+
+	for name, content := range validPacks {
+		t.Run(name, func(t *testing.T) {
+			path := writePack(t, name, content)
+			pack, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if pack.Path != path || pack.Name() != "french" {
+				t.Errorf("got pack %s from %s", pack.Name(), pack.Path)
+			}
+			if !reflect.DeepEqual(pack.Info(), wantInfo) {
+				t.Errorf("Info() = %+v, want %+v", pack.Info(), wantInfo)
+			}
+			if want := []string{"christmas", "goodevening", "hello"}; !reflect.DeepEqual(pack.Kinds(), want) {
+				t.Errorf("Kinds() = %q, want %q", pack.Kinds(), want)
+			}
+
+			g, err := pack.Greet(greetings.Request{Kind: "hello"})
+			if err != nil || g.Text != "Bonjour!" {
+				t.Errorf("Greet(hello) = %q, %v, want Bonjour!", g.Text, err)
+			}
+			variants, err := pack.Variants(greetings.Request{Kind: "hello", Formality: greetings.Casual, Recipient: "Priya"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(variants, wantVariants) {
+				t.Errorf("Variants(hello, casual) = %+v, want %+v", variants, wantVariants)
+			}
+		})
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		// field is the field reported, empty for syntax errors
+		field string
+	}{
+		{"YAML syntax", "bad.yaml", "name: [french\n", ""},
+		{"JSON syntax", "bad.json", `{"name": "french",}`, ""},
+		{"TOML syntax", "bad.toml", "name = french\n", ""},
+		{"unsupported type", "french.ini", "name=french\n", ""},
+		{"missing name", "bad.yaml", "greetings:\n  hello:\n    neutral: Bonjour\n", "name"},
+		{"invalid name", "bad.json", `{"name": "Français", "greetings": {"hello": {"neutral": "Bonjour"}}}`, "name"},
+		{"name not a string", "bad.toml", "name = 1\n", "name"},
+		{"unknown field", "bad.yaml", "name: french\nlanguage: fr\ngreetings:\n  hello:\n    neutral: Bonjour\n", "language"},
+		{"no greetings", "bad.json", `{"name": "french", "greetings": {}}`, "greetings"},
+		{"no registers", "bad.yaml", "name: french\ngreetings:\n  hello: Bonjour\n", "greetings.hello"},
+		{"unknown register", "bad.toml", "name = \"french\"\n[greetings.hello]\npolite = \"Bonjour\"\n", "greetings.hello.polite"},
+		{"unknown phrase field", "bad.yaml", "name: french\ngreetings:\n  hello:\n    neutral:\n      txt: Bonjour\n", "greetings.hello.neutral.txt"},
+		{"meaning not a string", "bad.json", `{"name": "french", "greetings": {"hello": {"neutral": {"text": "Bonjour", "meaning": 1}}}}`, "greetings.hello.neutral.meaning"},
+		{"missing text", "bad.toml", "name = \"french\"\n[greetings.hello.neutral]\nmeaning = \"hello\"\n", "greetings.hello.neutral"},
+		{"unbalanced template", "bad.yaml", "name: french\ngreetings:\n  hello:\n    neutral: \"Bonjour[, {name}!\"\n", "greetings.hello.neutral.text"},
+		{"bracket closing nothing", "bad.toml", "name = \"french\"\n[greetings.hello]\nneutral = \"Bonjour]]]\"\n", "greetings.hello.neutral.text"},
+		{"no variants", "bad.json", `{"name": "french", "greetings": {"hello": {"neutral": []}}}`, "greetings.hello.neutral"},
+		{"nested variants", "bad.yaml", "name: french\ngreetings:\n  hello:\n    neutral: [Bonjour, [Salut]]\n", "greetings.hello.neutral[1]"},
+		{"zero weight", "bad.toml", "name = \"french\"\n[greetings.hello]\nneutral = [{ text = \"Bonjour\", weight = 0 }]\n", "greetings.hello.neutral[0].weight"},
+		{"fractional weight", "bad.json", `{"name": "french", "greetings": {"hello": {"neutral": {"text": "Bonjour", "weight": 1.5}}}}`, "greetings.hello.neutral.weight"},
+		{"day part time", "bad.yaml", "name: french\nday_parts:\n  - start: \"25:00\"\n    kind: hello\ngreetings:\n  hello:\n    neutral: Bonjour\n", "day_parts[0].start"},
+		{"day part kind not a string", "bad.json", `{"name": "french", "day_parts": [{"start": "05:00", "kind": 1}], "greetings": {"hello": {"neutral": "Bonjour"}}}`, "day_parts[0].kind"},
+		{"day part unknown kind", "bad.toml", "name = \"french\"\n[[day_parts]]\nstart = \"05:00\"\nkind = \"goodmorning\"\n[greetings.hello]\nneutral = \"Bonjour\"\n", "day_parts[0].kind"},
+		{"day parts not a list", "bad.yaml", "name: french\nday_parts: morning\ngreetings:\n  hello:\n    neutral: Bonjour\n", "day_parts"},
+		{"occasion rule", "bad.yaml", "name: french\noccasions:\n  - kind: hello\n    rule: someday\ngreetings:\n  hello:\n    neutral: Bonjour\n", "occasions[0].rule"},
+		{"occasion name not a string", "bad.json", `{"name": "french", "occasions": [{"kind": "hello", "name": 1, "rule": "12-25"}], "greetings": {"hello": {"neutral": "Bonjour"}}}`, "occasions[0].name"},
+		{"occasion days", "bad.toml", "name = \"french\"\n[[occasions]]\nkind = \"hello\"\nrule = \"12-25\"\ndays = 0\n[greetings.hello]\nneutral = \"Bonjour\"\n", "occasions[0].days"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writePack(t, tt.file, tt.content)
+			_, err := Load(path)
+			var perr *Error
+			if !errors.As(err, &perr) {
+				t.Fatalf("got %v, want a language pack error", err)
+			}
+			if perr.File != path || perr.Field != tt.field {
+				t.Errorf("got error in %s at %q, want %s at %q: %v", perr.File, perr.Field, path, tt.field, err)
+			}
+		})
+	}
+}
+
+func TestLoadLiteralBrackets(t *testing.T) {
+	path := writePack(t, "notes.yaml", "name: notes\ngreetings:\n  hello:\n    neutral: \"[[note]] Hello[ [[{name}]]]!\"\n")
+	pack, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := pack.Greet(greetings.Request{Kind: "hello", Recipient: "Priya"})
+	if err != nil || g.Text != "[note] Hello [Priya]!" {
+		t.Errorf("Greet(hello) = %q, %v, want [note] Hello [Priya]!", g.Text, err)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"french.yaml": validPacks["french.yaml"],
+		"german.json": `{"name": "german", "greetings": {"hello": {"neutral": "Hallo[, {name}]!"}}}`,
+		"broken.toml": "name = \"broken\"\n",
+		"README.md":   "Language packs",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	packs, errs := LoadDir(dir)
+	var names []string
+	for _, pack := range packs {
+		names = append(names, pack.Name())
+	}
+	if want := []string{"french", "german"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("loaded %q, want %q", names, want)
+	}
+	var perr *Error
+	if len(errs) != 1 || !errors.As(errs[0], &perr) || perr.File != filepath.Join(dir, "broken.toml") {
+		t.Errorf("errors = %v, want broken.toml reported", errs)
+	}
+
+	// A missing directory holds no packs
+	if packs, errs := LoadDir(filepath.Join(dir, "missing")); packs != nil || errs != nil {
+		t.Errorf("LoadDir(missing) = %v, %v", packs, errs)
+	}
+}
@@ -0,0 +1,50 @@
+package locale
+
+import "strings"
+
+// FromEnvironment returns the locales preferred by the user, most preferred
+// first, following the gettext rules for message catalogs:
+//
+//   - the effective locale is the first non-empty of LC_ALL, LC_MESSAGES and LANG
+//   - unless the effective locale is C or POSIX, the colon separated priority
+//     list in LANGUAGE comes before it
+//
+// Each entry is reported with the variable it comes from.
+func FromEnvironment(getenv func(string) string) []Preference {
+	var effective Preference
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := getenv(name); value != "" {
+			effective = Preference{Locale: value, Source: name}
+			break
+		}
+	}
+
+	var prefs []Preference
+	if effective.Locale != "" && !isPOSIX(effective.Locale) {
+		for _, value := range strings.Split(getenv("LANGUAGE"), ":") {
+			if value != "" {
+				prefs = append(prefs, Preference{Locale: value, Source: "LANGUAGE"})
+			}
+		}
+	}
+
+	if effective.Locale != "" {
+		prefs = append(prefs, effective)
+	}
+	return prefs
+}
+
+// Preference is a locale requested by the user
+type Preference struct {
+	Locale string
+	// Source names where the preference comes from, e.g. "LANG"
+	Source string
+}
+
+// isPOSIX reports whether a locale is the untranslated C locale
+func isPOSIX(locale string) bool {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	return locale == "C" || locale == "POSIX"
+}
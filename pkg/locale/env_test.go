@@ -0,0 +1,49 @@
+package locale
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFromEnvironment(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want []Preference
+	}{
+		{nil, nil},
+		{
+			map[string]string{"LANG": "hi_IN.UTF-8"},
+			[]Preference{{"hi_IN.UTF-8", "LANG"}},
+		},
+		// LC_ALL overrides LC_MESSAGES, which overrides LANG
+		{
+			map[string]string{"LC_ALL": "ja_JP", "LC_MESSAGES": "de_DE", "LANG": "hi_IN"},
+			[]Preference{{"ja_JP", "LC_ALL"}},
+		},
+		{
+			map[string]string{"LC_MESSAGES": "de_DE", "LANG": "hi_IN"},
+			[]Preference{{"de_DE", "LC_MESSAGES"}},
+		},
+		// LANGUAGE comes first and skips empty entries
+		{
+			map[string]string{"LANGUAGE": "fr::hi", "LANG": "en_US.UTF-8"},
+			[]Preference{{"fr", "LANGUAGE"}, {"hi", "LANGUAGE"}, {"en_US.UTF-8", "LANG"}},
+		},
+		// LANGUAGE is ignored without a locale or with the C locale
+		{map[string]string{"LANGUAGE": "fr"}, nil},
+		{
+			map[string]string{"LANGUAGE": "fr", "LANG": "C.UTF-8"},
+			[]Preference{{"C.UTF-8", "LANG"}},
+		},
+		{
+			map[string]string{"LANGUAGE": "fr", "LC_ALL": "POSIX", "LANG": "hi_IN"},
+			[]Preference{{"POSIX", "LC_ALL"}},
+		},
+	}
+	for _, tt := range tests {
+		got := FromEnvironment(func(name string) string { return tt.env[name] })
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FromEnvironment(%v) = %v, want %v", tt.env, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Package locale resolves user supplied language names and BCP 47 tags,
+// such as "Hindi", "hi", "hi-IN" or "ja_JP.UTF-8", to available language plugins.
+package locale
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// Candidate is an available language plugin and the locale it serves
+type Candidate struct {
+	Name string
+	Tag  language.Tag
+}
+
+// Resolver maps requested languages to candidates
+type Resolver struct {
+	candidates []Candidate
+	matcher    language.Matcher
+}
+
+// NewResolver creates a resolver choosing among the given candidates.
+// Candidates listed first win ties, so built-in plugins should come first.
+func NewResolver(candidates []Candidate) *Resolver {
+	tags := make([]language.Tag, len(candidates))
+	for i, c := range candidates {
+		tags[i] = c.Tag
+	}
+
+	return &Resolver{
+		candidates: candidates,
+		matcher:    language.NewMatcher(tags),
+	}
+}
+
+// Candidates returns the candidates of the resolver
+func (r *Resolver) Candidates() []Candidate {
+	return r.candidates
+}
+
+// Resolve returns the name of the plugin serving the requested language.
+//
+// The request is first compared with the plugin names, ignoring case. It is
+// then read as a BCP 47 tag (POSIX locales like "ja_JP.UTF-8" are accepted)
+// or an English language name, and matched against the candidate tags. Region
+// and script variants fall back to their parent locale, so "hi-IN" is served
+// by a "hi" plugin and "en-AU" prefers an "en-GB" plugin over "en".
+func (r *Resolver) Resolve(requested string) (string, error) {
+	for _, c := range r.candidates {
+		if strings.EqualFold(c.Name, requested) {
+			return c.Name, nil
+		}
+	}
+
+	tag, err := Parse(requested)
+	if err != nil {
+		return "", fmt.Errorf("language %q is not available (available: %s)", requested, r.available())
+	}
+
+	if len(r.candidates) > 0 {
+		_, index, confidence := r.matcher.Match(tag)
+		if confidence >= language.High {
+			return r.candidates[index].Name, nil
+		}
+	}
+
+	return "", fmt.Errorf("no language plugin serves %s (available: %s)", tag, r.available())
+}
+
+// available lists the candidates for error messages
+func (r *Resolver) available() string {
+	var names []string
+	for _, c := range r.candidates {
+		names = append(names, fmt.Sprintf("%s [%s]", c.Name, c.Tag))
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+// Parse reads a BCP 47 tag, a POSIX locale or an English language name
+func Parse(s string) (language.Tag, error) {
+	s = strings.TrimSpace(s)
+
+	// POSIX locales: language[_territory][.codeset][@modifier]
+	if i := strings.IndexAny(s, ".@"); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.ReplaceAll(s, "_", "-")
+
+	if s == "" || strings.EqualFold(s, "C") || strings.EqualFold(s, "POSIX") {
+		return language.Und, fmt.Errorf("locale %q does not name a language", s)
+	}
+
+	if tag, err := language.Parse(s); err == nil {
+		return tag, nil
+	}
+
+	if tag, ok := englishNames()[strings.ToLower(s)]; ok {
+		return tag, nil
+	}
+
+	return language.Und, fmt.Errorf("unknown language %q", s)
+}
+
+// TagFor returns the tag of a plugin, taken from the locale it declares or,
+// failing that, from its name
+func TagFor(name, declared string) (language.Tag, bool) {
+	for _, s := range []string{declared, name} {
+		if s == "" {
+			continue
+		}
+		if tag, err := Parse(s); err == nil {
+			return tag, true
+		}
+	}
+	return language.Und, false
+}
+
+var (
+	namesOnce sync.Once
+	names     map[string]language.Tag
+)
+
+// englishNames maps lower-cased English language names, e.g. "hindi",
+// to their tags for every two-letter ISO 639-1 language
+func englishNames() map[string]language.Tag {
+	namesOnce.Do(func() {
+		names = make(map[string]language.Tag)
+		namer := display.English.Languages()
+		for a := 'a'; a <= 'z'; a++ {
+			for b := 'a'; b <= 'z'; b++ {
+				base, err := language.ParseBase(string([]rune{a, b}))
+				if err != nil {
+					continue
+				}
+				tag, err := language.Compose(base)
+				if err != nil {
+					continue
+				}
+				if name := namer.Name(base); name != "" {
+					names[strings.ToLower(name)] = tag
+				}
+			}
+		}
+	})
+	return names
+}
@@ -0,0 +1,143 @@
+package locale
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func newTestResolver() *Resolver {
+	return NewResolver([]Candidate{
+		{Name: "english", Tag: language.English},
+		{Name: "british", Tag: language.BritishEnglish},
+		{Name: "hindi", Tag: language.Hindi},
+		{Name: "japanese", Tag: language.Japanese},
+		{Name: "brazilian", Tag: language.BrazilianPortuguese},
+	})
+}
+
+func TestResolve(t *testing.T) {
+	r := newTestResolver()
+	tests := []struct {
+		requested string
+		want      string
+	}{
+		// Plugin names
+		{"hindi", "hindi"},
+		{"HINDI", "hindi"},
+		// English language names
+		{"Hindi", "hindi"},
+		{"japanese", "japanese"},
+		// BCP 47 tags
+		{"hi", "hindi"},
+		{"ja", "japanese"},
+		{"en", "english"},
+		{"en-GB", "british"},
+		// Regions fall back to their parent locale
+		{"hi-IN", "hindi"},
+		{"ja-JP", "japanese"},
+		{"en-US", "english"},
+		{"en-AU", "british"},
+		{"pt-PT", "brazilian"},
+		{"hi-Deva-IN", "hindi"},
+		// POSIX locales
+		{"ja_JP.UTF-8", "japanese"},
+		{"hi_IN@euro", "hindi"},
+		{" en_GB ", "british"},
+	}
+	for _, tt := range tests {
+		if got, err := r.Resolve(tt.requested); err != nil || got != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v, want %q", tt.requested, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	r := newTestResolver()
+	tests := []struct {
+		requested string
+		want      string
+	}{
+		{"fr", "no language plugin serves fr"},
+		{"French", "no language plugin serves fr"},
+		{"zh-Hant-TW", "no language plugin serves zh-Hant-TW"},
+		{"klingon", `language "klingon" is not available`},
+		{"C", `language "C" is not available`},
+		{"", `language "" is not available`},
+	}
+	for _, tt := range tests {
+		_, err := r.Resolve(tt.requested)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Resolve(%q): got %v, want an error containing %q", tt.requested, err, tt.want)
+		}
+	}
+
+	// Errors list what is available
+	_, err := r.Resolve("fr")
+	if err == nil || !strings.Contains(err.Error(), "hindi [hi]") {
+		t.Errorf("got %v, want the available languages listed", err)
+	}
+	_, err = NewResolver(nil).Resolve("hi")
+	if err == nil || !strings.Contains(err.Error(), "available: none") {
+		t.Errorf("got %v, want no language available", err)
+	}
+}
+
+func TestResolveTies(t *testing.T) {
+	// Candidates listed first win ties, e.g. built-in plugins over external ones
+	r := NewResolver([]Candidate{
+		{Name: "hindi", Tag: language.Hindi},
+		{Name: "hindi-pack", Tag: language.Hindi},
+	})
+	if got, err := r.Resolve("hi-IN"); err != nil || got != "hindi" {
+		t.Errorf("got %q, %v, want hindi", got, err)
+	}
+	if got, err := r.Resolve("hindi-pack"); err != nil || got != "hindi-pack" {
+		t.Errorf("got %q, %v, want the plugin named", got, err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"hi", "hi"},
+		{"hi_IN", "hi-IN"},
+		{"ja_JP.UTF-8", "ja-JP"},
+		{"de_DE@euro", "de-DE"},
+		{"Hindi", "hi"},
+		{"SPANISH", "es"},
+		{"sr-Latn", "sr-Latn"},
+	}
+	for _, tt := range tests {
+		if got, err := Parse(tt.s); err != nil || got.String() != tt.want {
+			t.Errorf("Parse(%q) = %s, %v, want %s", tt.s, got, err, tt.want)
+		}
+	}
+
+	for _, s := range []string{"", "C", "POSIX", "C.UTF-8", "posix", "klingon", "x"} {
+		if got, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) = %s, want an error", s, got)
+		}
+	}
+}
+
+func TestTagFor(t *testing.T) {
+	tests := []struct {
+		name, declared string
+		want           string
+		ok             bool
+	}{
+		{"hindi", "hi-IN", "hi-IN", true},
+		{"hindi", "", "hi", true},
+		{"hindi", "nonsense", "hi", true},
+		{"awadhi", "", "und", false},
+	}
+	for _, tt := range tests {
+		if got, ok := TagFor(tt.name, tt.declared); ok != tt.ok || got.String() != tt.want {
+			t.Errorf("TagFor(%q, %q) = %s, %v, want %s, %v", tt.name, tt.declared, got, ok, tt.want, tt.ok)
+		}
+	}
+}
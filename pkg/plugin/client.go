@@ -7,10 +7,10 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/unsuman/greeter/pkg/greetings"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/grpc/status"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	pb "github.com/unsuman/greeter/pkg/plugin/proto"
 )
@@ -21,6 +21,7 @@ type GRPCClient struct {
 	Stdout     io.ReadCloser
 	Conn       *grpc.ClientConn
 	GreeterSvc pb.GreeterServiceClient
+	HealthSvc  healthpb.HealthClient
 	logger     *logrus.Entry
 }
 
@@ -49,6 +50,7 @@ func NewGRPCClient(stdin io.WriteCloser, stdout io.ReadCloser, logger *logrus.En
 		Stdout:     stdout,
 		Conn:       conn,
 		GreeterSvc: greeterClient,
+		HealthSvc:  healthpb.NewHealthClient(conn),
 		logger:     logger,
 	}, nil
 }
@@ -58,36 +60,72 @@ func (c *GRPCClient) Close() error {
 	return c.Conn.Close()
 }
 
-// GetGreeting calls the appropriate gRPC method based on greeting type
-func (c *GRPCClient) GetGreeting(ctx context.Context, greetingType string) (string, error) {
-	var response *pb.GreetingResponse
-	var err error
+// GetGreeting requests a greeting from the plugin
+func (c *GRPCClient) GetGreeting(ctx context.Context, req greetings.Request) (greetings.Greeting, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	response, err := c.GreeterSvc.Greet(ctx, pb.FromRequest(req))
+	if err != nil {
+		c.logger.Errorf("gRPC call failed: %v", err)
+		return greetings.Greeting{}, err
+	}
+
+	return pb.ToGreeting(response), nil
+}
+
+// GetVariants requests the variants of a greeting from the plugin
+func (c *GRPCClient) GetVariants(ctx context.Context, req greetings.Request) ([]greetings.Variant, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	response, err := c.GreeterSvc.Variants(ctx, pb.FromRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	return pb.ToVariants(response), nil
+}
 
+// ListKinds returns the greeting kinds supported by the plugin
+func (c *GRPCClient) ListKinds(ctx context.Context) ([]string, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	switch greetingType {
-	case "hello":
-		response, err = c.GreeterSvc.Hello(ctx, &pb.Empty{})
-	case "goodmorning":
-		response, err = c.GreeterSvc.GoodMorning(ctx, &pb.Empty{})
-	case "goodafternoon":
-		response, err = c.GreeterSvc.GoodAfternoon(ctx, &pb.Empty{})
-	case "goodnight":
-		response, err = c.GreeterSvc.GoodNight(ctx, &pb.Empty{})
-	case "goodbye":
-		response, err = c.GreeterSvc.GoodBye(ctx, &pb.Empty{})
-	default:
-		c.logger.Errorf("Unknown greeting type: %s", greetingType)
-		return "", status.Errorf(codes.InvalidArgument, "unknown greeting type")
+	response, err := c.GreeterSvc.ListKinds(ctx, &pb.Empty{})
+	if err != nil {
+		c.logger.Errorf("gRPC call failed: %v", err)
+		return nil, err
 	}
 
+	return response.Kinds, nil
+}
+
+// GetInfo returns the plugin metadata
+func (c *GRPCClient) GetInfo(ctx context.Context) (*greetings.Info, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	response, err := c.GreeterSvc.GetInfo(ctx, &pb.Empty{})
 	if err != nil {
 		c.logger.Errorf("gRPC call failed: %v", err)
-		return "", err
+		return nil, err
+	}
+
+	return pb.ToInfo(response), nil
+}
+
+// CheckHealth returns the serving status reported by the plugin's health service
+func (c *GRPCClient) CheckHealth(ctx context.Context) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	response, err := c.HealthSvc.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return healthpb.HealthCheckResponse_UNKNOWN, err
 	}
 
-	return response.Message, nil
+	return response.Status, nil
 }
 
 // PipeConn implements net.Conn over stdin/stdout
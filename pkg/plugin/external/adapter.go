@@ -2,18 +2,29 @@ package external
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net"
 	"os"
 	"os/signal"
+	"sort"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin/handshake"
+	"github.com/unsuman/greeter/pkg/plugin/manifest"
+	"github.com/unsuman/greeter/pkg/version"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/unsuman/greeter/pkg/plugin/proto"
 )
@@ -21,18 +32,71 @@ import (
 // Server adapts a greetings.Plugin to serve over gRPC
 type Server struct {
 	pb.UnimplementedGreeterServiceServer
-	plugin greetings.Plugin
-	logger *logrus.Logger
+	plugin  greetings.Plugin
+	logger  *logrus.Logger
+	version int
 }
 
-// Run runs a plugin as a standalone executable
+// ServiceRegistrar registers the gRPC services making up one protocol version
+type ServiceRegistrar func(server *grpc.Server, plugin greetings.Plugin, logger *logrus.Logger)
+
+// ServeConfig configures how a plugin is served
+type ServeConfig struct {
+	// Versions maps every protocol version the plugin serves to the registrar
+	// of its services. Serving several versions lets a plugin keep working
+	// with older and newer hosts during a protocol transition.
+	Versions map[int]ServiceRegistrar
+}
+
+// DefaultVersions returns the protocol versions served by Run
+func DefaultVersions() map[int]ServiceRegistrar {
+	return map[int]ServiceRegistrar{
+		handshake.ProtocolVersion: RegisterGreeterService(handshake.ProtocolVersion),
+	}
+}
+
+// RegisterGreeterService returns a registrar serving the plugin as GreeterService,
+// reporting the given protocol version in GetInfo
+func RegisterGreeterService(version int) ServiceRegistrar {
+	return func(server *grpc.Server, plugin greetings.Plugin, logger *logrus.Logger) {
+		pb.RegisterGreeterServiceServer(server, &Server{
+			plugin:  plugin,
+			logger:  logger,
+			version: version,
+		})
+	}
+}
+
+// Run runs a plugin as a standalone executable, serving the current protocol version
 func Run(plugin greetings.Plugin) {
+	Serve(plugin, &ServeConfig{Versions: DefaultVersions()})
+}
+
+// Serve runs a plugin as a standalone executable. It negotiates the protocol
+// version with the host before serving the services of that version.
+func Serve(plugin greetings.Plugin, config *ServeConfig) {
+	// Release pipelines generate the manifest installed next to the binary
+	if len(os.Args) > 1 && os.Args[1] == "--manifest" {
+		if err := writeManifest(os.Stdout, plugin, config); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write manifest: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.TextFormatter{
 		FullTimestamp: true,
 	})
 	logger.Info("Starting plugin: ", plugin.Name())
 
+	version, err := negotiate(config)
+	if err != nil {
+		handshake.WriteError(os.Stdout, err.Error())
+		logger.Fatalf("Protocol negotiation failed: %v", err)
+	}
+	logger.Infof("Negotiated protocol version %d", version)
+
 	if err := plugin.Init(); err != nil {
 		logger.Fatalf("Failed to initialize plugin: %v", err)
 	}
@@ -65,10 +129,15 @@ func Run(plugin greetings.Plugin) {
 		grpc.KeepaliveEnforcementPolicy(kaPolicy),
 	)
 
-	pb.RegisterGreeterServiceServer(server, &Server{
-		plugin: plugin,
-		logger: logger,
-	})
+	config.Versions[version](server, plugin, logger)
+
+	// Report readiness through the standard gRPC health service
+	healthSrv := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthSrv)
+	for service := range server.GetServiceInfo() {
+		healthSrv.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+	}
+	healthSrv.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
 
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
@@ -76,40 +145,94 @@ func Run(plugin greetings.Plugin) {
 	go func() {
 		<-sigs
 		logger.Info("Received shutdown signal, stopping server...")
+		healthSrv.Shutdown()
 		server.Stop()
 		os.Exit(0)
 	}()
 
+	// Announce the protocol version, the gRPC connection follows on stdout
+	if err := handshake.WriteVersion(os.Stdout, version); err != nil {
+		logger.Fatalf("Failed to write handshake: %v", err)
+	}
+
 	logger.Info("Server starting...")
 	if err := server.Serve(listener); err != nil {
 		logger.Fatalf("Failed to serve: %v", err)
 	}
 }
 
-// Implement the gRPC service methods
-func (s *Server) Hello(ctx context.Context, empty *pb.Empty) (*pb.GreetingResponse, error) {
-	s.logger.Debug("Received Hello request")
-	return &pb.GreetingResponse{Message: s.plugin.Hello()}, nil
+// negotiate picks the protocol version to serve from the versions offered by the host
+func negotiate(config *ServeConfig) (int, error) {
+	offered, ok := os.LookupEnv(handshake.EnvProtocolVersions)
+	if !ok {
+		return 0, fmt.Errorf("%s is not set: this is a greeter plugin and must be launched by greeter", handshake.EnvProtocolVersions)
+	}
+
+	hostVersions, err := handshake.ParseVersions(offered)
+	if err != nil {
+		return 0, err
+	}
+
+	var served []int
+	for v := range config.Versions {
+		served = append(served, v)
+	}
+	sort.Ints(served)
+
+	version, ok := handshake.Negotiate(hostVersions, served)
+	if !ok {
+		return 0, fmt.Errorf("plugin serves protocol versions %s, host supports %s",
+			handshake.FormatVersions(served), handshake.FormatVersions(hostVersions))
+	}
+
+	return version, nil
+}
+
+// Greet serves a greeting of the requested kind
+func (s *Server) Greet(ctx context.Context, req *pb.GreetingRequest) (*pb.GreetingResponse, error) {
+	s.logger.Debugf("Received Greet request for %q", req.GetKind())
+
+	greeting, err := s.plugin.Greet(pb.ToRequest(req))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return pb.FromGreeting(greeting), nil
 }
 
-func (s *Server) GoodMorning(ctx context.Context, empty *pb.Empty) (*pb.GreetingResponse, error) {
-	s.logger.Debug("Received GoodMorning request")
-	return &pb.GreetingResponse{Message: s.plugin.GoodMorning()}, nil
+func (s *Server) Variants(ctx context.Context, req *pb.GreetingRequest) (*pb.VariantList, error) {
+	s.logger.Debugf("Received Variants request for %q", req.GetKind())
+
+	variants, err := greetings.VariantsOf(s.plugin, pb.ToRequest(req))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return pb.FromVariants(variants), nil
 }
 
-func (s *Server) GoodAfternoon(ctx context.Context, empty *pb.Empty) (*pb.GreetingResponse, error) {
-	s.logger.Debug("Received GoodAfternoon request")
-	return &pb.GreetingResponse{Message: s.plugin.GoodAfternoon()}, nil
+// toStatus maps plugin errors to gRPC status codes
+func toStatus(err error) error {
+	var unsupported *greetings.UnsupportedKindError
+	if errors.As(err, &unsupported) {
+		return status.Error(codes.Unimplemented, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
 }
 
-func (s *Server) GoodNight(ctx context.Context, empty *pb.Empty) (*pb.GreetingResponse, error) {
-	s.logger.Debug("Received GoodNight request")
-	return &pb.GreetingResponse{Message: s.plugin.GoodNight()}, nil
+// ListKinds serves the greeting kinds supported by the plugin
+func (s *Server) ListKinds(ctx context.Context, empty *pb.Empty) (*pb.KindList, error) {
+	s.logger.Debug("Received ListKinds request")
+	return &pb.KindList{Kinds: s.plugin.Kinds()}, nil
 }
 
-func (s *Server) GoodBye(ctx context.Context, empty *pb.Empty) (*pb.GreetingResponse, error) {
-	s.logger.Debug("Received GoodBye request")
-	return &pb.GreetingResponse{Message: s.plugin.GoodBye()}, nil
+// GetInfo serves the plugin metadata
+func (s *Server) GetInfo(ctx context.Context, empty *pb.Empty) (*pb.PluginInfo, error) {
+	s.logger.Debug("Received GetInfo request")
+
+	info := greetings.Describe(s.plugin)
+	info.ProtocolVersion = s.version
+	return pb.FromInfo(info), nil
 }
 
 // PipeConn implements the net.Conn interface over stdin/stdout
@@ -200,3 +323,37 @@ func (l *PipeListener) Close() error {
 func (l *PipeListener) Addr() net.Addr {
 	return pipeAddr{}
 }
+
+// writeManifest prints the manifest describing the running plugin binary
+func writeManifest(w io.Writer, plugin greetings.Plugin, config *ServeConfig) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	checksum, err := manifest.Checksum(exe)
+	if err != nil {
+		return err
+	}
+
+	info := greetings.Describe(plugin)
+	m := manifest.Manifest{
+		Name:           info.Name,
+		Version:        info.Version,
+		Checksum:       checksum,
+		Description:    info.Description,
+		Greetings:      info.Kinds,
+		MinHostVersion: version.Version,
+	}
+	for v := range config.Versions {
+		if v > m.ProtocolVersion {
+			m.ProtocolVersion = v
+		}
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
@@ -0,0 +1,89 @@
+package external
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin/handshake"
+	pb "github.com/unsuman/greeter/pkg/plugin/proto"
+)
+
+// servedVersions returns a ServeConfig serving the given protocol versions
+func servedVersions(versions ...int) *ServeConfig {
+	config := &ServeConfig{Versions: make(map[int]ServiceRegistrar)}
+	for _, v := range versions {
+		config.Versions[v] = RegisterGreeterService(v)
+	}
+	return config
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name    string
+		offered string
+		served  *ServeConfig
+		want    int
+		// err is part of the expected error, empty for none
+		err string
+	}{
+		{"current version", "1", &ServeConfig{Versions: DefaultVersions()}, handshake.ProtocolVersion, ""},
+		{"highest common version", "1,2", servedVersions(1, 2), 2, ""},
+		{"older host", "1", servedVersions(1, 2), 1, ""},
+		{"newer host", "1,2,3", servedVersions(1, 2), 2, ""},
+		{"unordered offer", "3, 1", servedVersions(1, 3), 3, ""},
+		{"no common version", "1", servedVersions(2, 3), 0, "plugin serves protocol versions 2,3, host supports 1"},
+		{"nothing offered", "", servedVersions(1), 0, "plugin serves protocol versions 1, host supports "},
+		{"malformed offer", "1,x", servedVersions(1), 0, `invalid protocol version "x"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(handshake.EnvProtocolVersions, tt.offered)
+			got, err := negotiate(tt.served)
+			if tt.err == "" {
+				if err != nil || got != tt.want {
+					t.Errorf("got %d, %v, want %d", got, err, tt.want)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got %d, %v, want an error containing %q", got, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestNegotiateOutsideHost(t *testing.T) {
+	// Unset the variable for the test, restoring it afterwards
+	t.Setenv(handshake.EnvProtocolVersions, "")
+	os.Unsetenv(handshake.EnvProtocolVersions)
+
+	if _, err := negotiate(servedVersions(1)); err == nil || !strings.Contains(err.Error(), "must be launched by greeter") {
+		t.Errorf("got %v, want the plugin to ask to be launched by greeter", err)
+	}
+}
+
+type testPlugin struct{}
+
+func (testPlugin) Greet(greetings.Request) (greetings.Greeting, error) {
+	return greetings.Greeting{Text: "Hello"}, nil
+}
+func (testPlugin) Kinds() []string      { return []string{"hello"} }
+func (testPlugin) Name() string         { return "test" }
+func (testPlugin) Info() greetings.Info { return greetings.Info{Version: "1.0.0"} }
+func (testPlugin) Init() error          { return nil }
+func (testPlugin) Close() error         { return nil }
+
+func TestGetInfoReportsNegotiatedVersion(t *testing.T) {
+	s := &Server{plugin: testPlugin{}, logger: logrus.New(), version: 2}
+	info, err := s.GetInfo(context.Background(), &pb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.GetProtocolVersion() != 2 || info.GetName() != "test" {
+		t.Errorf("GetInfo = %v, want test speaking protocol version 2", info)
+	}
+}
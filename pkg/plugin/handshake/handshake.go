@@ -0,0 +1,134 @@
+// Package handshake implements the protocol version negotiation performed
+// between the host and an external plugin before any gRPC traffic.
+//
+// The host launches a plugin with EnvProtocolVersions listing the protocol
+// versions it speaks. The plugin picks the highest version it also serves and
+// announces it as the first line written to stdout:
+//
+//	GREETER_PLUGIN|<version>|grpc
+//
+// or refuses the host with
+//
+//	GREETER_PLUGIN|error|<reason>
+//
+// Everything after that line belongs to the gRPC connection.
+package handshake
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ProtocolVersion is the current version of the plugin protocol
+	ProtocolVersion = 1
+
+	// EnvProtocolVersions is set by the host to the comma separated protocol versions it supports
+	EnvProtocolVersions = "GREETER_PLUGIN_PROTOCOL_VERSIONS"
+
+	// MagicCookie starts every handshake line
+	MagicCookie = "GREETER_PLUGIN"
+
+	// Transport is the only transport plugins are served over
+	Transport = "grpc"
+)
+
+// IncompatibleError reports a plugin that cannot talk to the host
+type IncompatibleError struct {
+	Plugin string
+	// HostVersions lists the protocol versions supported by the host
+	HostVersions []int
+	Reason       string
+}
+
+func (e *IncompatibleError) Error() string {
+	return fmt.Sprintf("plugin %s is incompatible with this greeter (host protocol versions %s): %s",
+		e.Plugin, FormatVersions(e.HostVersions), e.Reason)
+}
+
+// Negotiate returns the highest protocol version supported by both sides
+func Negotiate(host, plugin []int) (int, bool) {
+	best, found := 0, false
+	for _, h := range host {
+		for _, p := range plugin {
+			if h == p && (!found || h > best) {
+				best, found = h, true
+			}
+		}
+	}
+	return best, found
+}
+
+// FormatVersions formats protocol versions as a comma separated list
+func FormatVersions(versions []int) string {
+	parts := make([]string, len(versions))
+	for i, v := range versions {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseVersions parses a comma separated list of protocol versions
+func ParseVersions(s string) ([]int, error) {
+	var versions []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid protocol version %q", part)
+		}
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// WriteVersion announces the negotiated protocol version
+func WriteVersion(w io.Writer, version int) error {
+	_, err := fmt.Fprintf(w, "%s|%d|%s\n", MagicCookie, version, Transport)
+	return err
+}
+
+// WriteError tells the host the plugin refuses to serve it
+func WriteError(w io.Writer, reason string) error {
+	reason = strings.ReplaceAll(reason, "\n", " ")
+	_, err := fmt.Fprintf(w, "%s|error|%s\n", MagicCookie, reason)
+	return err
+}
+
+// ReadVersion reads the handshake line written by a plugin and returns the
+// negotiated protocol version. The returned error describes why the plugin
+// cannot be used; it does not carry the plugin name.
+func ReadVersion(r *bufio.Reader) (int, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return 0, fmt.Errorf("no handshake received: %w", err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+
+	parts := strings.SplitN(line, "|", 3)
+	if len(parts) != 3 || parts[0] != MagicCookie {
+		return 0, fmt.Errorf("no handshake received, the plugin was probably built for an older greeter")
+	}
+
+	if parts[1] == "error" {
+		return 0, fmt.Errorf("plugin refused the connection: %s", parts[2])
+	}
+
+	version, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, fmt.Errorf("malformed handshake %q", line)
+	}
+	if parts[2] != Transport {
+		return 0, fmt.Errorf("unsupported transport %q", parts[2])
+	}
+
+	return version, nil
+}
@@ -0,0 +1,127 @@
+package handshake
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		host, plugin []int
+		want         int
+		ok           bool
+	}{
+		{[]int{1}, []int{1}, 1, true},
+		{[]int{1, 2}, []int{1, 2}, 2, true},
+		{[]int{1, 2}, []int{1}, 1, true},
+		{[]int{1}, []int{1, 2}, 1, true},
+		{[]int{2, 3}, []int{1, 2}, 2, true},
+		{[]int{3, 1}, []int{1, 3}, 3, true},
+		{[]int{1}, []int{2}, 0, false},
+		{[]int{1}, nil, 0, false},
+		{nil, []int{1}, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := Negotiate(tt.host, tt.plugin)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Negotiate(%v, %v) = %d, %v, want %d, %v", tt.host, tt.plugin, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseVersions(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []int
+		wantErr bool
+	}{
+		{"1", []int{1}, false},
+		{"2,1", []int{1, 2}, false},
+		{" 1 , 3 ", []int{1, 3}, false},
+		{"1,,2,", []int{1, 2}, false},
+		{"", nil, false},
+		{"1,two", nil, true},
+		{"1.5", nil, true},
+		{"v1", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseVersions(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseVersions(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseVersions(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	// Formatted versions parse back
+	versions := []int{1, 2, 10}
+	if got, err := ParseVersions(FormatVersions(versions)); err != nil || !reflect.DeepEqual(got, versions) {
+		t.Errorf("ParseVersions(FormatVersions(%v)) = %v, %v", versions, got, err)
+	}
+}
+
+func TestReadVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want int
+		// err is part of the expected error, empty for none
+		err string
+	}{
+		{"version", "GREETER_PLUGIN|1|grpc\n", 1, ""},
+		{"newer version", "GREETER_PLUGIN|2|grpc\r\n", 2, ""},
+		{"refusal", "GREETER_PLUGIN|error|plugin serves protocol versions 2, host supports 1\n", 0, "plugin refused the connection: plugin serves protocol versions 2, host supports 1"},
+		{"no line", "", 0, "no handshake received"},
+		{"unterminated line", "GREETER_PLUGIN|1|grpc", 0, "no handshake received"},
+		{"other program", "Hello, world!\n", 0, "built for an older greeter"},
+		{"wrong cookie", "GREETER|1|grpc\n", 0, "built for an older greeter"},
+		{"missing field", "GREETER_PLUGIN|1\n", 0, "built for an older greeter"},
+		{"malformed version", "GREETER_PLUGIN|one|grpc\n", 0, "malformed handshake"},
+		{"other transport", "GREETER_PLUGIN|1|netrpc\n", 0, `unsupported transport "netrpc"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadVersion(bufio.NewReader(strings.NewReader(tt.in)))
+			if tt.err == "" {
+				if err != nil || got != tt.want {
+					t.Errorf("got %d, %v, want %d", got, err, tt.want)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got %d, %v, want an error containing %q", got, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestWriteHandshake(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteVersion(&b, 2); err != nil {
+		t.Fatal(err)
+	}
+	b.WriteString("gRPC frames")
+
+	// The reader keeps what follows the handshake line
+	r := bufio.NewReader(&b)
+	if version, err := ReadVersion(r); err != nil || version != 2 {
+		t.Errorf("ReadVersion = %d, %v, want 2", version, err)
+	}
+	if rest, _ := r.ReadString(0); rest != "gRPC frames" {
+		t.Errorf("left %q after the handshake", rest)
+	}
+
+	// A refusal stays on one line
+	b.Reset()
+	if err := WriteError(&b, "no common version\nbye"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadVersion(bufio.NewReader(&b)); err == nil || !strings.HasSuffix(err.Error(), "no common version bye") {
+		t.Errorf("ReadVersion = %v, want the refusal", err)
+	}
+}
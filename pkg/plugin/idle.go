@@ -0,0 +1,132 @@
+package plugin
+
+import (
+	"time"
+)
+
+// minIdleCheck bounds how often the reaper checks a plugin in use
+const minIdleCheck = time.Second
+
+// SetIdleTimeout sets how long a running plugin may stay unused before it
+// is stopped, unless its PluginConfig says otherwise. 0 keeps idle plugins running.
+func (pm *PluginManager) SetIdleTimeout(timeout time.Duration) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.idleTimeout = timeout
+}
+
+// idlePolicy returns how long a plugin may stay unused, 0 if it is kept warm
+func (pm *PluginManager) idlePolicy(name string) time.Duration {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	cfg := pm.configs[name]
+	switch {
+	case cfg.KeepWarm:
+		return 0
+	case cfg.IdleTimeout > 0:
+		return cfg.IdleTimeout
+	default:
+		return pm.idleTimeout
+	}
+}
+
+// LastUsed returns when the plugin last answered or was asked something
+func (pi *PluginInstance) LastUsed() time.Time {
+	return time.Unix(0, pi.lastUsed.Load())
+}
+
+// Busy reports whether calls to the plugin are in progress
+func (pi *PluginInstance) Busy() bool {
+	return pi.inFlight.Load() > 0
+}
+
+// begin records the start of a call to the plugin
+func (pi *PluginInstance) begin() {
+	pi.inFlight.Add(1)
+	pi.lastUsed.Store(time.Now().UnixNano())
+}
+
+// end records the end of a call to the plugin
+func (pi *PluginInstance) end() {
+	pi.lastUsed.Store(time.Now().UnixNano())
+	pi.inFlight.Add(-1)
+}
+
+// reapWhenIdle stops a running plugin once none of its instances was used
+// for the idle timeout of its policy. The policy is read on every check, so that a plugin
+// kept warm by a configuration change is spared. An instance beyond the
+// minimum size of its pool is stopped alone, once unused for the scale down delay.
+func (pm *PluginManager) reapWhenIdle(instance *PluginInstance) {
+	timer := time.NewTimer(minIdleCheck)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-instance.ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		// Instances beyond the minimum size of the pool go first
+		if after := pm.scaleDownAfter(instance); after > 0 {
+			idle := time.Since(instance.LastUsed())
+			if !instance.Busy() && idle >= after && pm.shrinkPool(instance) {
+				instance.Logger.Infof("Stopped instance unused for %s, scaling down", idle.Round(time.Second))
+				return
+			}
+			timer.Reset(max(after-idle, minIdleCheck))
+			continue
+		}
+
+		ttl := pm.idlePolicy(instance.Name)
+		if ttl <= 0 {
+			// Kept warm or no timeout, check again in case the policy changes
+			timer.Reset(time.Minute)
+			continue
+		}
+
+		// The plugin is stopped as a whole, once none of its instances is used
+		idle, stopped := pm.stopIfIdle(instance.pool, ttl)
+		if stopped {
+			instance.Logger.Infof("Stopped plugin unused for %s", idle.Round(time.Second))
+			return
+		}
+		timer.Reset(max(ttl-idle, minIdleCheck))
+	}
+}
+
+// stopIfIdle stops the instances of a pool if none of them was used for
+// ttl, reporting for how long the pool has been unused and whether it was stopped
+func (pm *PluginManager) stopIfIdle(p *pool, ttl time.Duration) (time.Duration, bool) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	idle := p.idle()
+	if idle < ttl || p.starting > 0 {
+		return idle, false
+	}
+	pluginKey := p.category + "-" + p.name
+	if pm.pools[pluginKey] != p {
+		// Already stopped
+		return idle, false
+	}
+
+	pm.stopPool(pluginKey, p)
+	return idle, true
+}
+
+// idle returns for how long none of the instances of a pool was used, 0 if
+// one of them is busy. The caller holds mutex.
+func (p *pool) idle() time.Duration {
+	var lastUsed time.Time
+	for _, instance := range p.instances {
+		if instance.Busy() {
+			return 0
+		}
+		if used := instance.LastUsed(); used.After(lastUsed) {
+			lastUsed = used
+		}
+	}
+	return time.Since(lastUsed)
+}
@@ -0,0 +1,100 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/unsuman/greeter/pkg/plugin/searchpath"
+)
+
+func TestIdlePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     PluginConfig
+		timeout time.Duration
+		want    time.Duration
+	}{
+		{"manager timeout", PluginConfig{}, time.Minute, time.Minute},
+		{"plugin timeout", PluginConfig{IdleTimeout: time.Hour}, time.Minute, time.Hour},
+		{"no timeout", PluginConfig{}, 0, 0},
+		{"kept warm", PluginConfig{KeepWarm: true, IdleTimeout: time.Hour}, time.Minute, 0},
+	}
+	for _, tt := range tests {
+		logger, _ := logtest.NewNullLogger()
+		pm := NewPluginManager(logger, searchpath.Path{})
+		pm.SetIdleTimeout(tt.timeout)
+		pm.SetPluginConfig("fake", tt.cfg)
+		if got := pm.idlePolicy("fake"); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIdleReaper(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     PluginConfig
+		timeout time.Duration
+		stopped bool
+	}{
+		{"plugin timeout", PluginConfig{IdleTimeout: 100 * time.Millisecond}, 0, true},
+		{"manager timeout", PluginConfig{}, 100 * time.Millisecond, true},
+		{"kept warm", PluginConfig{KeepWarm: true, IdleTimeout: 100 * time.Millisecond}, 100 * time.Millisecond, false},
+		{"no timeout", PluginConfig{}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pm, _, _ := newTestManager(t, tt.cfg)
+			pm.SetIdleTimeout(tt.timeout)
+			if err := pm.StartPlugin("lang", "fake"); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.stopped {
+				eventually(t, "the idle plugin to stop", func() bool { return len(instances(pm)) == 0 })
+				return
+			}
+			// The reaper checks every minIdleCheck at most
+			time.Sleep(minIdleCheck + 500*time.Millisecond)
+			if len(instances(pm)) != 1 {
+				t.Error("the plugin was stopped")
+			}
+		})
+	}
+}
+
+func TestStopIfIdle(t *testing.T) {
+	pm, _, _ := newTestManager(t, PluginConfig{MinInstances: 2})
+	if err := pm.StartPlugin("lang", "fake"); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the pool to fill", func() bool { return len(instances(pm)) == 2 })
+	running := instances(pm)
+	p := running[0].pool
+	long := time.Now().Add(-time.Hour).UnixNano()
+
+	// A busy instance keeps the pool running
+	running[0].lastUsed.Store(long)
+	running[1].begin()
+	running[1].lastUsed.Store(long)
+	if _, stopped := pm.stopIfIdle(p, time.Minute); stopped {
+		t.Error("stopped a pool with a busy instance")
+	}
+
+	// So does an instance used recently, however long the others were unused
+	running[1].end()
+	if idle, stopped := pm.stopIfIdle(p, time.Minute); stopped || idle >= time.Minute {
+		t.Errorf("stopped a pool used %s ago", idle)
+	}
+
+	running[1].lastUsed.Store(long)
+	if idle, stopped := pm.stopIfIdle(p, time.Minute); !stopped || idle < time.Hour {
+		t.Errorf("got %s, %v, want the pool unused for an hour stopped", idle, stopped)
+	}
+	eventually(t, "the pool to stop", func() bool { return len(instances(pm)) == 0 })
+	if _, stopped := pm.stopIfIdle(p, time.Minute); stopped {
+		t.Error("stopped a pool twice")
+	}
+}
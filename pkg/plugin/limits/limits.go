@@ -0,0 +1,199 @@
+// Package limits bounds the resources of plugin processes on Linux: rlimits
+// on the address space, CPU time, open files and processes, and an optional
+// cgroup v2 subtree for memory and CPU quotas.
+package limits
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Limits are the resource limits of a plugin process, 0 for the ones not set
+type Limits struct {
+	// AddressSpace bounds the virtual memory of the process in bytes (RLIMIT_AS)
+	AddressSpace uint64
+	// CPUTime bounds the CPU time of the process, rounded up to seconds (RLIMIT_CPU)
+	CPUTime time.Duration
+	// OpenFiles bounds the file descriptors of the process (RLIMIT_NOFILE)
+	OpenFiles uint64
+	// Processes bounds the processes and threads of the user running the
+	// plugin, the plugin included (RLIMIT_NPROC)
+	Processes uint64
+
+	// Memory bounds the memory of the process in bytes, swap excluded (cgroup memory.max)
+	Memory uint64
+	// CPU bounds the CPU usage of the process in CPUs, e.g. 0.5 (cgroup cpu.max)
+	CPU float64
+}
+
+// IsZero reports whether no limit is set
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
+// NeedsCgroup reports whether limits are enforced by a cgroup
+func (l Limits) NeedsCgroup() bool {
+	return l.Memory > 0 || l.CPU > 0
+}
+
+// ExceededError reports a plugin process killed or failing for exceeding one of its limits
+type ExceededError struct {
+	Plugin string
+	// Limit is the exceeded limit, e.g. "memory", and Value its setting
+	Limit string
+	Value string
+	// Err is how the process exited
+	Err error
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("plugin %s exceeded its %s limit of %s: %v", e.Plugin, e.Limit, e.Value, e.Err)
+}
+
+func (e *ExceededError) Unwrap() error {
+	return e.Err
+}
+
+// Process applies the limits of a plugin to its process and tells whether
+// the process exited because of them. A Process without limits does nothing.
+type Process struct {
+	limits Limits
+	// root is the cgroup v2 directory the cgroup of the process is created
+	// in, cgroup the directory of that cgroup once created
+	root      string
+	name      string
+	cgroup    string
+	cgroupDir *os.File
+
+	// hint is the limit named by the last error the process reported on stderr
+	hint atomic.Pointer[string]
+}
+
+// New returns a Process applying limits. The cgroup of the process, when
+// needed, is the directory name of root.
+func New(l Limits, root, name string) *Process {
+	return &Process{limits: l, root: root, name: name}
+}
+
+// Observe looks at a line the process wrote on stderr for the errors the Go
+// runtime and libraries report when a limit is hit
+func (p *Process) Observe(line string) {
+	line = strings.ToLower(line)
+	for _, hint := range []struct {
+		limit   string
+		set     bool
+		message string
+	}{
+		{"address space", p.limits.AddressSpace > 0, "out of memory"},
+		{"address space", p.limits.AddressSpace > 0, "cannot allocate memory"},
+		{"address space", p.limits.AddressSpace > 0, "failed to reserve"},
+		{"processes", p.limits.Processes > 0, "failed to create new os thread"},
+		{"processes", p.limits.Processes > 0, "pthread_create failed"},
+		{"open files", p.limits.OpenFiles > 0, "too many open files"},
+	} {
+		if hint.set && strings.Contains(line, hint.message) {
+			p.hint.Store(&hint.limit)
+			return
+		}
+	}
+}
+
+// Check returns an ExceededError wrapping err if the process, which exited
+// with state, exceeded one of its limits, and err otherwise. It is called
+// before Release.
+func (p *Process) Check(plugin string, state *os.ProcessState, err error) error {
+	if p.limits.IsZero() || state == nil || state.Success() {
+		return err
+	}
+	if err == nil {
+		err = fmt.Errorf("exited with %s", state)
+	}
+
+	limit := p.exceeded(state)
+	if limit == "" {
+		if hint := p.hint.Load(); hint != nil {
+			limit = *hint
+		}
+	}
+	if limit == "" {
+		return err
+	}
+	return &ExceededError{Plugin: plugin, Limit: limit, Value: p.value(limit), Err: err}
+}
+
+// value returns the setting of a limit for display
+func (p *Process) value(limit string) string {
+	switch limit {
+	case "address space":
+		return FormatSize(p.limits.AddressSpace)
+	case "cpu time":
+		return p.limits.CPUTime.String()
+	case "open files":
+		return fmt.Sprint(p.limits.OpenFiles)
+	case "processes":
+		return fmt.Sprint(p.limits.Processes)
+	case "memory":
+		return FormatSize(p.limits.Memory)
+	}
+	return "?"
+}
+
+// cpuSeconds returns the RLIMIT_CPU value of a CPU time limit
+func cpuSeconds(d time.Duration) uint64 {
+	return uint64((d + time.Second - 1) / time.Second)
+}
+
+// FormatSize formats a number of bytes with a binary unit, e.g. 256MiB
+func FormatSize(bytes uint64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	value, unit := float64(bytes), 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%g%s", float64(int(value*10))/10, units[unit])
+}
+
+// ParseSize parses a number of bytes with an optional binary unit: K, M, G
+// or T, possibly followed by iB or B, e.g. "512M" or "1GiB"
+func ParseSize(s string) (uint64, error) {
+	number := strings.TrimSpace(s)
+	for _, suffix := range []string{"iB", "B"} {
+		if trimmed, ok := strings.CutSuffix(number, suffix); ok {
+			number = trimmed
+			break
+		}
+	}
+
+	multiplier := uint64(1)
+	if n := len(number); n > 0 {
+		if i := strings.Index("KMGT", strings.ToUpper(number[n-1:])); i >= 0 {
+			multiplier = 1 << (10 * (i + 1))
+			number = number[:n-1]
+		}
+	}
+
+	value, err := strconv.ParseUint(strings.TrimSpace(number), 10, 64)
+	if err != nil || value == 0 || value > math.MaxUint64/multiplier {
+		return 0, fmt.Errorf("invalid size %q (expected e.g. 512M or 2G)", s)
+	}
+	return value * multiplier, nil
+}
+
+// ParseCPU parses a CPU quota given in CPUs, e.g. "1.5", or as a percentage of one CPU, e.g. "50%"
+func ParseCPU(s string) (float64, error) {
+	number, percent := strings.CutSuffix(strings.TrimSpace(s), "%")
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value <= 0 || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("invalid CPU quota %q (expected e.g. 50%% or 1.5)", s)
+	}
+	if percent {
+		value /= 100
+	}
+	return value, nil
+}
@@ -0,0 +1,245 @@
+//go:build linux
+
+package limits
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// cpuPeriod is the cgroup cpu.max period in microseconds
+	cpuPeriod = 100000
+
+	// selfExe is the executable of the host, started in place of the plugin
+	selfExe = "/proc/self/exe"
+	// envTrampoline is the environment variable passing the trampoline to
+	// the host executable started in place of the plugin
+	envTrampoline = "GREETER_RLIMITS"
+)
+
+// trampoline is what the host executable started in place of the plugin
+// does: set the rlimits of the process, then execute Path
+type trampoline struct {
+	Path string `json:"path"`
+	// FD is the descriptor Path refers to, 0 for none
+	FD      int      `json:"fd,omitempty"`
+	Rlimits []rlimit `json:"rlimits"`
+}
+
+type rlimit struct {
+	Resource int    `json:"resource"`
+	Name     string `json:"name"`
+	Value    uint64 `json:"value"`
+}
+
+// RunTrampoline sets the rlimits of the process and executes the plugin
+// when the host executable was started in place of the plugin by Prepare,
+// and returns right away otherwise. See plugin.RunTrampolines.
+func RunTrampoline() {
+	encoded, ok := os.LookupEnv(envTrampoline)
+	if !ok {
+		return
+	}
+
+	var t trampoline
+	err := json.Unmarshal([]byte(encoded), &t)
+	if err == nil {
+		err = t.exec()
+	}
+	// Only reached on failure
+	fmt.Fprintf(os.Stderr, "limits: %v\n", err)
+	os.Exit(126)
+}
+
+// exec sets the rlimits of the process and executes the plugin
+func (t trampoline) exec() error {
+	for _, r := range t.Rlimits {
+		// The soft and hard limits are the same, so that a plugin exceeding
+		// its CPU time is killed rather than sent SIGXCPU, that Go ignores
+		limit := unix.Rlimit{Cur: r.Value, Max: r.Value}
+		if err := unix.Setrlimit(r.Resource, &limit); err != nil {
+			return fmt.Errorf("failed to limit %s: %w", r.Name, err)
+		}
+	}
+
+	env := make([]string, 0, len(os.Environ()))
+	for _, variable := range os.Environ() {
+		if !strings.HasPrefix(variable, envTrampoline+"=") {
+			env = append(env, variable)
+		}
+	}
+	// The plugin does not keep the descriptor of its binary
+	if t.FD > 0 {
+		unix.CloseOnExec(t.FD)
+	}
+	return unix.Exec(t.Path, os.Args, env)
+}
+
+// rlimits returns the rlimits to set
+func (p *Process) rlimits() []rlimit {
+	var rlimits []rlimit
+	for _, r := range []rlimit{
+		{unix.RLIMIT_AS, "address space", p.limits.AddressSpace},
+		{unix.RLIMIT_CPU, "cpu time", cpuSeconds(p.limits.CPUTime)},
+		{unix.RLIMIT_NOFILE, "open files", p.limits.OpenFiles},
+		{unix.RLIMIT_NPROC, "processes", p.limits.Processes},
+	} {
+		if r.Value > 0 {
+			rlimits = append(rlimits, r)
+		}
+	}
+	return rlimits
+}
+
+// Prepare sets cmd up to start in the cgroup of the process, creating it
+// with its memory and CPU quotas, and to set the rlimits of the process
+// before it executes the plugin, if such limits are set. binary, if not
+// nil, is the open plugin binary, executed rather than cmd.Path.
+func (p *Process) Prepare(cmd *exec.Cmd, binary *os.File) error {
+	if rlimits := p.rlimits(); len(rlimits) > 0 {
+		// Limits set by the host would only apply after the start of the
+		// plugin, so the process sets them itself through the host executable
+		t := trampoline{Path: cmd.Path, Rlimits: rlimits}
+		if binary != nil {
+			t.FD = 3 + len(cmd.ExtraFiles)
+			t.Path = fmt.Sprintf("/proc/self/fd/%d", t.FD)
+		}
+		encoded, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, envTrampoline+"="+string(encoded))
+		cmd.Path = selfExe
+		if binary != nil {
+			cmd.ExtraFiles = append(cmd.ExtraFiles, binary)
+		}
+	}
+
+	if !p.limits.NeedsCgroup() {
+		return nil
+	}
+	if p.root == "" {
+		return errors.New("memory and cpu limits need a cgroup v2 directory delegated to greeter, see plugin_cgroup")
+	}
+
+	// Controllers are only available in the children of the cgroups enabling them
+	var controllers []string
+	if p.limits.Memory > 0 {
+		controllers = append(controllers, "+memory")
+	}
+	if p.limits.CPU > 0 {
+		controllers = append(controllers, "+cpu")
+	}
+	if err := os.WriteFile(filepath.Join(p.root, "cgroup.subtree_control"), []byte(strings.Join(controllers, " ")), 0); err != nil {
+		return fmt.Errorf("failed to enable cgroup controllers in %s: %w", p.root, err)
+	}
+
+	dir := filepath.Join(p.root, p.name)
+	if err := os.Mkdir(dir, 0o755); err != nil && !errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("failed to create cgroup: %w", err)
+	}
+	settings := map[string]string{}
+	if p.limits.Memory > 0 {
+		settings["memory.max"] = strconv.FormatUint(p.limits.Memory, 10)
+		settings["memory.swap.max"] = "0"
+	}
+	if p.limits.CPU > 0 {
+		settings["cpu.max"] = fmt.Sprintf("%d %d", max(int(p.limits.CPU*cpuPeriod), 1000), cpuPeriod)
+	}
+	for file, value := range settings {
+		err := os.WriteFile(filepath.Join(dir, file), []byte(value), 0)
+		if err != nil && !(file == "memory.swap.max" && errors.Is(err, os.ErrNotExist)) {
+			os.Remove(dir)
+			return fmt.Errorf("failed to set %s of cgroup %s: %w", file, dir, err)
+		}
+	}
+
+	cgroupDir, err := os.Open(dir)
+	if err != nil {
+		os.Remove(dir)
+		return fmt.Errorf("failed to open cgroup: %w", err)
+	}
+	p.cgroup, p.cgroupDir = dir, cgroupDir
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(cgroupDir.Fd())
+	return nil
+}
+
+// Started closes the cgroup directory kept open for the start of the process
+func (p *Process) Started() {
+	if p.cgroupDir != nil {
+		p.cgroupDir.Close()
+		p.cgroupDir = nil
+	}
+}
+
+// exceeded returns the limit that got the process killed, "" if none did
+func (p *Process) exceeded(state *os.ProcessState) string {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() || status.Signal() != syscall.SIGKILL && status.Signal() != syscall.SIGXCPU {
+		return ""
+	}
+
+	// The kernel checks the CPU time on clock ticks, the accounting of the
+	// process may be slightly behind
+	if limit := time.Duration(cpuSeconds(p.limits.CPUTime)) * time.Second; limit > 0 && state.UserTime()+state.SystemTime() >= limit*9/10 {
+		return "cpu time"
+	}
+	if p.cgroup != "" && p.limits.Memory > 0 && p.oomKills() > 0 {
+		return "memory"
+	}
+	return ""
+}
+
+// oomKills returns the number of processes of the cgroup killed for exceeding its memory limit
+func (p *Process) oomKills() int {
+	events, err := os.Open(filepath.Join(p.cgroup, "memory.events"))
+	if err != nil {
+		return 0
+	}
+	defer events.Close()
+
+	scanner := bufio.NewScanner(events)
+	for scanner.Scan() {
+		if count, ok := strings.CutPrefix(scanner.Text(), "oom_kill "); ok {
+			n, _ := strconv.Atoi(count)
+			return n
+		}
+	}
+	return 0
+}
+
+// Release removes the cgroup of the exited process
+func (p *Process) Release() error {
+	if p.cgroupDir != nil {
+		p.cgroupDir.Close()
+		p.cgroupDir = nil
+	}
+	if p.cgroup == "" {
+		return nil
+	}
+	if err := os.Remove(p.cgroup); err != nil {
+		return fmt.Errorf("failed to remove cgroup: %w", err)
+	}
+	p.cgroup = ""
+	return nil
+}
@@ -0,0 +1,196 @@
+package limits
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestMain(m *testing.M) {
+	// Prepared commands start the test binary in place of the plugin
+	RunTrampoline()
+	os.Exit(m.Run())
+}
+
+func TestRlimits(t *testing.T) {
+	p := New(Limits{AddressSpace: 4 << 30, CPUTime: 1500 * time.Millisecond, OpenFiles: 64, Memory: 256 << 20}, "", "hindi")
+	want := []rlimit{
+		{unix.RLIMIT_AS, "address space", 4 << 30},
+		{unix.RLIMIT_CPU, "cpu time", 2},
+		{unix.RLIMIT_NOFILE, "open files", 64},
+	}
+	if got := p.rlimits(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got := New(Limits{Processes: 32}, "", "hindi").rlimits(); len(got) != 1 || got[0].Resource != unix.RLIMIT_NPROC {
+		t.Errorf("got %+v, want RLIMIT_NPROC", got)
+	}
+}
+
+// shell returns a command running script, and the open shell binary
+func shell(t *testing.T, script string) (*exec.Cmd, *os.File) {
+	t.Helper()
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell")
+	}
+	binary, err := os.Open(sh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { binary.Close() })
+	return exec.Command(sh, "-c", script), binary
+}
+
+func TestPrepareTrampoline(t *testing.T) {
+	for _, open := range []bool{false, true} {
+		cmd, binary := shell(t, "ulimit -n; ulimit -t")
+		if !open {
+			binary = nil
+		}
+		p := New(Limits{OpenFiles: 64, CPUTime: 10 * time.Minute}, "", "hindi")
+		if err := p.Prepare(cmd, binary); err != nil {
+			t.Fatal(err)
+		}
+
+		var tr trampoline
+		for _, variable := range cmd.Env {
+			if encoded, ok := strings.CutPrefix(variable, envTrampoline+"="); ok {
+				if err := json.Unmarshal([]byte(encoded), &tr); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		if cmd.Path != selfExe || len(tr.Rlimits) != 2 {
+			t.Fatalf("got %s with %+v, want the host executable setting 2 rlimits", cmd.Path, tr)
+		}
+		if open && (tr.FD != 3 || tr.Path != "/proc/self/fd/3" || len(cmd.ExtraFiles) != 1) {
+			t.Errorf("got %+v, want the open binary executed as descriptor 3", tr)
+		}
+
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("the trampoline failed: %v", err)
+		}
+		if string(out) != "64\n600\n" {
+			t.Errorf("limits in the process: %q, want 64 open files and 600s", out)
+		}
+	}
+}
+
+func TestPrepareWithoutLimits(t *testing.T) {
+	cmd, binary := shell(t, "true")
+	path := cmd.Path
+	if err := New(Limits{}, "", "hindi").Prepare(cmd, binary); err != nil {
+		t.Fatal(err)
+	}
+	if cmd.Path != path || cmd.Env != nil || cmd.ExtraFiles != nil || cmd.SysProcAttr != nil {
+		t.Errorf("the command was changed: %+v", cmd)
+	}
+}
+
+func TestCPUTimeExceeded(t *testing.T) {
+	cmd, _ := shell(t, "while :; do :; done")
+	p := New(Limits{CPUTime: time.Second}, "", "hindi")
+	if err := p.Prepare(cmd, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	err := p.Check("hindi", cmd.ProcessState, cmd.Run())
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) || exceeded.Limit != "cpu time" || exceeded.Value != "1s" {
+		t.Errorf("got %v, want the cpu time limit reported", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits Limits
+		script string
+		// line is written by the plugin on stderr
+		line string
+		want string
+	}{
+		{"success", Limits{OpenFiles: 64}, "true", "", ""},
+		{"own failure", Limits{OpenFiles: 64}, "exit 2", "", ""},
+		{"without limits", Limits{}, "exit 2", "too many open files", ""},
+		{"reported on stderr", Limits{OpenFiles: 64}, "exit 2", "open lang: too many open files", "open files"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, _ := shell(t, tt.script)
+			p := New(tt.limits, "", "hindi")
+			runErr := cmd.Run()
+			p.Observe(tt.line)
+
+			err := p.Check("hindi", cmd.ProcessState, runErr)
+			var exceeded *ExceededError
+			switch {
+			case tt.want != "":
+				if !errors.As(err, &exceeded) || exceeded.Limit != tt.want || !errors.Is(err, runErr) {
+					t.Errorf("got %v, want the %s limit reported", err, tt.want)
+				}
+			case errors.As(err, &exceeded):
+				t.Errorf("got %v, want no limit reported", err)
+			case err != runErr:
+				t.Errorf("got %v, want %v", err, runErr)
+			}
+		})
+	}
+}
+
+func TestPrepareCgroup(t *testing.T) {
+	cmd, _ := shell(t, "true")
+	if err := New(Limits{Memory: 256 << 20}, "", "hindi").Prepare(cmd, nil); err == nil || !strings.Contains(err.Error(), "plugin_cgroup") {
+		t.Errorf("got %v, want a cgroup directory required", err)
+	}
+
+	// The files of a cgroup are written as to a delegated cgroup v2 directory
+	root := t.TempDir()
+	p := New(Limits{Memory: 256 << 20, CPU: 0.5}, root, "hindi-1")
+	if err := p.Prepare(cmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer p.Started()
+
+	for file, want := range map[string]string{
+		"cgroup.subtree_control":  "+memory +cpu",
+		"hindi-1/memory.max":      "268435456",
+		"hindi-1/memory.swap.max": "0",
+		"hindi-1/cpu.max":         "50000 100000",
+	} {
+		data, err := os.ReadFile(filepath.Join(root, file))
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v, want %q", file, data, err, want)
+		}
+	}
+	if cmd.SysProcAttr == nil || !cmd.SysProcAttr.UseCgroupFD || p.cgroup != filepath.Join(root, "hindi-1") {
+		t.Errorf("the process is not started in the cgroup: %+v", cmd.SysProcAttr)
+	}
+}
+
+func TestPrepareCgroupMinimumCPU(t *testing.T) {
+	cmd, _ := shell(t, "true")
+	root := t.TempDir()
+	p := New(Limits{CPU: 0.001}, root, "hindi-1")
+	if err := p.Prepare(cmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer p.Started()
+
+	if data, _ := os.ReadFile(filepath.Join(root, "hindi-1", "cpu.max")); string(data) != "1000 100000" {
+		t.Errorf("cpu.max = %q, want the 1ms minimum quota", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "cgroup.subtree_control")); string(data) != "+cpu" {
+		t.Errorf("cgroup.subtree_control = %q, want the cpu controller only", data)
+	}
+}
@@ -0,0 +1,33 @@
+//go:build !linux
+
+package limits
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// RunTrampoline does nothing, limits are only enforced on Linux
+func RunTrampoline() {}
+
+// Prepare fails if limits are set, they are only enforced on Linux
+func (p *Process) Prepare(cmd *exec.Cmd, binary *os.File) error {
+	if !p.limits.IsZero() {
+		return errors.New("plugin resource limits are only supported on Linux")
+	}
+	return nil
+}
+
+// Started does nothing, limits are only enforced on Linux
+func (p *Process) Started() {}
+
+// exceeded returns "", limits are only enforced on Linux
+func (p *Process) exceeded(state *os.ProcessState) string {
+	return ""
+}
+
+// Release does nothing, limits are only enforced on Linux
+func (p *Process) Release() error {
+	return nil
+}
@@ -0,0 +1,139 @@
+package limits
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		s    string
+		want uint64
+	}{
+		{"512", 512},
+		{"1K", 1 << 10},
+		{"256M", 256 << 20},
+		{"256m", 256 << 20},
+		{"4G", 4 << 30},
+		{"1GiB", 1 << 30},
+		{"2GB", 2 << 30},
+		{" 1T ", 1 << 40},
+	}
+	for _, tt := range tests {
+		if got, err := ParseSize(tt.s); err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", tt.s, got, err, tt.want)
+		}
+	}
+
+	for _, s := range []string{"", "0", "-1M", "1.5G", "1P", "G", "16777216T"} {
+		if got, err := ParseSize(s); err == nil {
+			t.Errorf("ParseSize(%q) = %d, want an error", s, got)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		bytes uint64
+		want  string
+	}{
+		{512, "512B"},
+		{1 << 10, "1KiB"},
+		{1536, "1.5KiB"},
+		{256 << 20, "256MiB"},
+		{4 << 30, "4GiB"},
+		{2 << 40, "2TiB"},
+		{2048 << 40, "2048TiB"},
+	}
+	for _, tt := range tests {
+		if got := FormatSize(tt.bytes); got != tt.want {
+			t.Errorf("FormatSize(%d) = %q, want %q", tt.bytes, got, tt.want)
+		}
+	}
+}
+
+func TestParseCPU(t *testing.T) {
+	tests := []struct {
+		s    string
+		want float64
+	}{
+		{"1", 1},
+		{"1.5", 1.5},
+		{"50%", 0.5},
+		{"250%", 2.5},
+	}
+	for _, tt := range tests {
+		if got, err := ParseCPU(tt.s); err != nil || got != tt.want {
+			t.Errorf("ParseCPU(%q) = %g, %v, want %g", tt.s, got, err, tt.want)
+		}
+	}
+
+	for _, s := range []string{"", "0", "-1", "0%", "half", "Inf", "%"} {
+		if got, err := ParseCPU(s); err == nil {
+			t.Errorf("ParseCPU(%q) = %g, want an error", s, got)
+		}
+	}
+}
+
+func TestCPUSeconds(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want uint64
+	}{
+		{0, 0},
+		{time.Millisecond, 1},
+		{time.Second, 1},
+		{1500 * time.Millisecond, 2},
+		{10 * time.Minute, 600},
+	}
+	for _, tt := range tests {
+		if got := cpuSeconds(tt.d); got != tt.want {
+			t.Errorf("cpuSeconds(%s) = %d, want %d", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestLimitsKinds(t *testing.T) {
+	tests := []struct {
+		limits Limits
+		zero   bool
+		cgroup bool
+	}{
+		{Limits{}, true, false},
+		{Limits{OpenFiles: 64}, false, false},
+		{Limits{Memory: 256 << 20}, false, true},
+		{Limits{CPU: 0.5}, false, true},
+	}
+	for _, tt := range tests {
+		if tt.limits.IsZero() != tt.zero || tt.limits.NeedsCgroup() != tt.cgroup {
+			t.Errorf("%+v: IsZero = %v, NeedsCgroup = %v, want %v, %v", tt.limits, tt.limits.IsZero(), tt.limits.NeedsCgroup(), tt.zero, tt.cgroup)
+		}
+	}
+}
+
+func TestObserve(t *testing.T) {
+	tests := []struct {
+		limits Limits
+		line   string
+		want   string
+	}{
+		{Limits{AddressSpace: 1 << 30}, "fatal error: runtime: out of memory", "address space"},
+		{Limits{AddressSpace: 1 << 30}, "runtime: mmap: Cannot allocate memory", "address space"},
+		{Limits{Processes: 64}, "runtime: failed to create new OS thread (have 12 already; errno=11)", "processes"},
+		{Limits{OpenFiles: 16}, "open /usr/share/greeter: too many open files", "open files"},
+		// Errors of limits that are not set are the plugin's own
+		{Limits{OpenFiles: 16}, "fatal error: runtime: out of memory", ""},
+		{Limits{AddressSpace: 1 << 30}, "Server starting...", ""},
+	}
+	for _, tt := range tests {
+		p := New(tt.limits, "", "hindi")
+		p.Observe(tt.line)
+		got := ""
+		if hint := p.hint.Load(); hint != nil {
+			got = *hint
+		}
+		if got != tt.want {
+			t.Errorf("%q with %+v: hint = %q, want %q", tt.line, tt.limits, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin/external"
+	"github.com/unsuman/greeter/pkg/plugin/handshake"
+	"github.com/unsuman/greeter/pkg/plugin/searchpath"
+)
+
+// envTestPlugin makes the test binary serve testPlugin instead of running
+// the tests. It names the directory of the files steering the plugin.
+const envTestPlugin = "GREETER_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	RunTrampolines()
+	if dir := os.Getenv(envTestPlugin); dir != "" {
+		runTestPlugin(dir)
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// runTestPlugin serves testPlugin, failing to start while the file "fail"
+// exists. The file "versions" lists the protocol versions served instead of
+// the current one; the file "handshake" is written in place of the handshake.
+func runTestPlugin(dir string) {
+	if _, err := os.Stat(filepath.Join(dir, "fail")); err == nil {
+		fmt.Fprintln(os.Stderr, "failing to start")
+		os.Exit(1)
+	}
+	if line, err := os.ReadFile(filepath.Join(dir, "handshake")); err == nil {
+		os.Stdout.Write(line)
+		// Wait for the host to give up
+		io.Copy(io.Discard, os.Stdin)
+		os.Exit(1)
+	}
+
+	config := &external.ServeConfig{Versions: external.DefaultVersions()}
+	if data, err := os.ReadFile(filepath.Join(dir, "versions")); err == nil {
+		versions, err := handshake.ParseVersions(string(data))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		config.Versions = make(map[int]external.ServiceRegistrar)
+		for _, v := range versions {
+			config.Versions[v] = external.RegisterGreeterService(v)
+		}
+	}
+	external.Serve(testPlugin{dir: dir}, config)
+}
+
+// testPlugin greets with its pid. The "crash" kind exits the plugin during
+// the call the first time, the "slow" kind takes a while to answer.
+type testPlugin struct {
+	dir string
+}
+
+func (p testPlugin) Greet(req greetings.Request) (greetings.Greeting, error) {
+	switch req.Kind {
+	case "crash":
+		marker := filepath.Join(p.dir, "crashed")
+		if _, err := os.Stat(marker); err != nil {
+			os.WriteFile(marker, nil, 0o644)
+			os.Exit(3)
+		}
+	case "slow":
+		time.Sleep(300 * time.Millisecond)
+	}
+	return greetings.Greeting{Text: fmt.Sprintf("Hello from %d", os.Getpid())}, nil
+}
+
+func (testPlugin) Kinds() []string      { return []string{"hello", "crash", "slow"} }
+func (testPlugin) Name() string         { return "fake" }
+func (testPlugin) Info() greetings.Info { return greetings.Info{Name: "fake"} }
+func (testPlugin) Init() error          { return nil }
+func (testPlugin) Close() error         { return nil }
+
+// fakeClock is a clock whose timers fire at once, moving the time forward.
+// It records the delays waited for.
+type fakeClock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	delays []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+	c.delays = append(c.delays, d)
+	fired := make(chan time.Time, 1)
+	fired <- c.now
+	return fired
+}
+
+// Advance moves the time forward by d
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Delays returns the delays waited for so far
+func (c *fakeClock) Delays() []time.Duration {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]time.Duration(nil), c.delays...)
+}
+
+// newTestManager returns a manager serving the test binary as the "fake"
+// language plugin with cfg, and the directory steering the plugin
+func newTestManager(t *testing.T, cfg PluginConfig) (*PluginManager, *fakeClock, string) {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "lang"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(exe, filepath.Join(root, "lang", "fake")); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	cfg.Env = map[string]string{envTestPlugin: dir}
+
+	logger, _ := logtest.NewNullLogger()
+	pm := NewPluginManager(logger, searchpath.Path{{Path: root, Source: "test"}})
+	clock := &fakeClock{now: time.Now()}
+	pm.clock = clock
+	pm.SetPluginConfig("fake", cfg)
+	t.Cleanup(pm.CleanupPlugins)
+	return pm, clock, dir
+}
+
+// instances returns the running instances of the "fake" plugin
+func instances(pm *PluginManager) []*PluginInstance {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+	if p, ok := pm.pools["lang-fake"]; ok {
+		return append([]*PluginInstance(nil), p.instances...)
+	}
+	return nil
+}
+
+// kill kills an instance as a crash would and waits for the supervisor to handle it
+func kill(t *testing.T, instance *PluginInstance) {
+	t.Helper()
+	if err := instance.Command.Process.Kill(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-instance.exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the crash was not handled")
+	}
+}
+
+// eventually waits for cond to hold
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
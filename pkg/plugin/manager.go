@@ -3,27 +3,128 @@ package plugin
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin/handshake"
+	"github.com/unsuman/greeter/pkg/plugin/limits"
+	"github.com/unsuman/greeter/pkg/plugin/manifest"
+	"github.com/unsuman/greeter/pkg/plugin/sandbox"
+	"github.com/unsuman/greeter/pkg/plugin/searchpath"
+	"github.com/unsuman/greeter/pkg/plugin/signing"
+	"github.com/unsuman/greeter/pkg/version"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// SupportedProtocolVersions lists the plugin protocol versions spoken by the host
+var SupportedProtocolVersions = []int{handshake.ProtocolVersion}
+
+const (
+	// handshakeTimeout bounds the time a plugin may take to announce its
+	// protocol version, unless the plugin has a start timeout
+	handshakeTimeout = 10 * time.Second
+
+	// readyTimeout bounds the time a plugin may take to report SERVING after
+	// the handshake, unless the plugin has a start timeout
+	readyTimeout = 10 * time.Second
+
+	// defaultHealthCheckInterval is the period between health probes of running plugins
+	defaultHealthCheckInterval = 30 * time.Second
 )
 
 // PluginManager manages the lifecycle of plugins
 type PluginManager struct {
-	pluginsDir string
-	plugins    map[string]*PluginInstance
-	logger     *logrus.Logger
-	mutex      sync.RWMutex
+	searchPath          searchpath.Path
+	pools               map[string]*pool
+	logger              *logrus.Logger
+	mutex               sync.RWMutex
+	healthCheckInterval time.Duration
+	// idleTimeout is how long plugins may stay unused, see SetIdleTimeout
+	idleTimeout time.Duration
+
+	// vetted caches the signature and manifest checks of plugin binaries, see vetPlugin
+	vetted   map[string]vetResult
+	vetMutex sync.Mutex
+
+	// signatureMode and keyring configure the verification of plugin signatures
+	signatureMode signing.Mode
+	keyring       signing.Keyring
+
+	// configs holds the settings of plugins by name, see SetPluginConfig
+	configs map[string]PluginConfig
+
+	// remote serves the plugins instead of processes of the manager, see SetRemote
+	remote Remote
+
+	// breakers keeps the crash history of plugins, restarts the pending
+	// restarts of crashed plugins by plugin key, see supervisor.go
+	breakers *BreakerStore
+	restarts map[string]*restart
+	clock    clock
+
+	// cgroupRoot is the cgroup v2 directory the cgroups of plugins are
+	// created in, see SetCgroupRoot
+	cgroupRoot string
+	cgroupSeq  atomic.Uint64
+}
+
+// PluginConfig holds the settings of a plugin
+type PluginConfig struct {
+	// StartTimeout bounds the handshake and the wait for SERVING, 0 for the defaults
+	StartTimeout time.Duration
+	// Timeout bounds every call to the plugin, 0 for none
+	Timeout time.Duration
+	// Env is added to the environment the plugin is started with
+	Env map[string]string
+	// Restart is the supervision policy applied when the plugin crashes
+	Restart RestartPolicy
+	// IdleTimeout is how long the plugin may stay unused before it is
+	// stopped, 0 for the timeout of the manager
+	IdleTimeout time.Duration
+	// KeepWarm keeps the plugin running however long it stays unused
+	KeepWarm bool
+	// MinInstances and MaxInstances bound the number of processes of the
+	// plugin serving calls concurrently, 1 for both by default
+	MinInstances int
+	MaxInstances int
+	// ScaleDownAfter is how long an instance beyond MinInstances may stay
+	// unused before it is stopped, 0 for the default
+	ScaleDownAfter time.Duration
+	// Limits bounds the resources of every process of the plugin
+	Limits limits.Limits
+	// Sandbox confines every process of the plugin
+	Sandbox sandbox.Config
+}
+
+// vetResult is the outcome of checking a plugin binary against its signature and manifest
+type vetResult struct {
+	modTime  time.Time
+	size     int64
+	manifest *manifest.Manifest
+	err      error
 }
 
 // PluginInstance represents a running plugin instance
 type PluginInstance struct {
-	Name       string
+	Category string
+	Name     string
+	// Path is the plugin binary, found in the search path directory Dir
+	Path       string
+	Dir        searchpath.Dir
 	Command    *exec.Cmd
 	Stdin      io.WriteCloser
 	Stdout     io.ReadCloser
@@ -31,115 +132,472 @@ type PluginInstance struct {
 	Logger     *logrus.Entry
 	ctx        context.Context
 	cancelFunc context.CancelFunc
+
+	// ProtocolVersion is the protocol version negotiated with the plugin
+	ProtocolVersion int
+
+	// exited is closed once the process exited and the manager handled it,
+	// exitErr is then how the process exited if it crashed
+	exited  chan struct{}
+	exitErr error
+	// limits applies the resource limits of the plugin to the process,
+	// logged is closed once all the stderr output of the process was logged
+	limits *limits.Process
+	logged chan struct{}
+	// pool is the pool of instances of the plugin the instance belongs to
+	pool *pool
+
+	// lastUsed is the time of the last call in Unix nanoseconds, inFlight
+	// the number of calls in progress, see reapWhenIdle
+	lastUsed atomic.Int64
+	inFlight atomic.Int32
+
+	health      healthpb.HealthCheckResponse_ServingStatus
+	healthMutex sync.RWMutex
 }
 
-// NewPluginManager creates a new plugin manager
-func NewPluginManager(logger *logrus.Logger, pluginsDir string) *PluginManager {
+// PluginDescription is the metadata of a discovered plugin along with its health
+type PluginDescription struct {
+	greetings.Info
+	Health healthpb.HealthCheckResponse_ServingStatus
+	// Path is the plugin binary, found in the search path directory Dir
+	Path string
+	Dir  searchpath.Dir
+}
+
+// NotFoundError reports a plugin missing from every directory of the search path
+type NotFoundError struct {
+	Category   string
+	Name       string
+	SearchPath searchpath.Path
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("plugin '%s' not found in %s", e.Name, strings.Join(e.SearchPath.Sub(e.Category), ", "))
+}
+
+// Health returns the serving status last reported by the plugin
+func (pi *PluginInstance) Health() healthpb.HealthCheckResponse_ServingStatus {
+	pi.healthMutex.RLock()
+	defer pi.healthMutex.RUnlock()
+	return pi.health
+}
+
+// setHealth records a serving status and returns the previous one
+func (pi *PluginInstance) setHealth(status healthpb.HealthCheckResponse_ServingStatus) healthpb.HealthCheckResponse_ServingStatus {
+	pi.healthMutex.Lock()
+	defer pi.healthMutex.Unlock()
+	previous := pi.health
+	pi.health = status
+	return previous
+}
+
+// NewPluginManager creates a plugin manager looking up plugins in the
+// directories of searchPath, in order
+func NewPluginManager(logger *logrus.Logger, searchPath searchpath.Path) *PluginManager {
 	return &PluginManager{
-		pluginsDir: pluginsDir,
-		plugins:    make(map[string]*PluginInstance),
-		logger:     logger,
+		searchPath:          searchPath,
+		pools:               make(map[string]*pool),
+		logger:              logger,
+		healthCheckInterval: defaultHealthCheckInterval,
+		vetted:              make(map[string]vetResult),
+		signatureMode:       signing.ModeOff,
+		configs:             make(map[string]PluginConfig),
+		breakers:            NewBreakerStore("", logger),
+		clock:               systemClock{},
+		restarts:            make(map[string]*restart),
 	}
 }
 
-// DiscoverPlugins finds all available plugins in the plugins directory
-func (pm *PluginManager) DiscoverPlugins(category string) ([]string, error) {
-	pm.logger.Infof("Discovering plugins in category: %s", category)
+// SetHealthCheckInterval sets the period between health probes of running plugins
+func (pm *PluginManager) SetHealthCheckInterval(interval time.Duration) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.healthCheckInterval = interval
+}
 
-	pluginPath := filepath.Join(pm.pluginsDir, category)
-	entries, err := os.ReadDir(pluginPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			// No plugins directory or empty
-			return []string{}, nil
+// SetPluginConfig sets the settings of the plugin named name, applied when it is next started
+func (pm *PluginManager) SetPluginConfig(name string, cfg PluginConfig) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.configs[name] = cfg
+}
+
+// SetCgroupRoot sets the cgroup v2 directory delegated to the manager, in
+// which the plugins with memory or CPU limits get a cgroup per process
+func (pm *PluginManager) SetCgroupRoot(dir string) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.cgroupRoot = dir
+}
+
+// newLimits returns the resource limits of a new process of a plugin. The caller holds mutex.
+func (pm *PluginManager) newLimits(category, name string) *limits.Process {
+	cgroup := fmt.Sprintf("%s-%s-%d-%d", category, name, os.Getpid(), pm.cgroupSeq.Add(1))
+	return limits.New(pm.configs[name].Limits, pm.cgroupRoot, cgroup)
+}
+
+// callContext bounds a call to a plugin by its configured timeout
+func (pm *PluginManager) callContext(ctx context.Context, name string) (context.Context, context.CancelFunc) {
+	pm.mutex.RLock()
+	timeout := pm.configs[name].Timeout
+	pm.mutex.RUnlock()
+
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// SetSignatureVerification sets how plugin binaries are checked against the
+// signatures made by the keys of keyring before they are executed
+func (pm *PluginManager) SetSignatureVerification(mode signing.Mode, keyring signing.Keyring) {
+	pm.vetMutex.Lock()
+	defer pm.vetMutex.Unlock()
+	pm.signatureMode = mode
+	pm.keyring = keyring
+	clear(pm.vetted)
+}
+
+// SearchPath returns the directories plugins are looked up in, in order
+func (pm *PluginManager) SearchPath() searchpath.Path {
+	return pm.searchPath
+}
+
+// Locate returns the binary of a plugin and the search path directory it
+// was found in, the first one holding it. A missing plugin is reported as a
+// *NotFoundError.
+func (pm *PluginManager) Locate(category, name string) (string, searchpath.Dir, error) {
+	for _, dir := range pm.searchPath {
+		execPath := filepath.Join(dir.Path, category, name)
+		if isPluginBinary(execPath) {
+			return execPath, dir, nil
 		}
-		pm.logger.Errorf("Failed to read plugins directory: %v", err)
-		return nil, fmt.Errorf("failed to read plugins directory: %w", err)
 	}
+	return "", searchpath.Dir{}, &NotFoundError{Category: category, Name: name, SearchPath: pm.searchPath}
+}
+
+// isPluginBinary reports whether a file is an executable plugin, rather than
+// a directory or the manifest or signature of one
+func isPluginBinary(path string) bool {
+	name := filepath.Base(path)
+	if manifest.IsManifest(name) || signing.IsSignature(name) {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode()&0111 != 0
+}
+
+// DiscoverPlugins finds all available plugins in the directories of the
+// search path. A plugin found in several directories is only reported once,
+// see Locate for the one that wins.
+func (pm *PluginManager) DiscoverPlugins(category string) ([]string, error) {
+	pm.logger.Infof("Discovering plugins in category: %s", category)
 
 	var plugins []string
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue // Skip directories
+	found := make(map[string]searchpath.Dir)
+	for _, dir := range pm.searchPath {
+		pluginPath := filepath.Join(dir.Path, category)
+		entries, err := os.ReadDir(pluginPath)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				pm.logger.Warnf("Failed to read plugins directory: %v", err)
+			}
+			continue
 		}
 
-		pluginName := entry.Name()
-		execPath := filepath.Join(pluginPath, pluginName)
+		for _, entry := range entries {
+			pluginName := entry.Name()
+			execPath := filepath.Join(pluginPath, pluginName)
+			if !isPluginBinary(execPath) {
+				continue
+			}
+			if winner, ok := found[pluginName]; ok {
+				pm.logger.Debugf("Ignoring plugin %s at %s, shadowed by %s", pluginName, execPath, winner)
+				continue
+			}
+			found[pluginName] = dir
 
-		// Check if the plugin binary exists and is executable
-		if info, err := os.Stat(execPath); err == nil && !info.IsDir() {
-			if info.Mode()&0111 != 0 { // Check if executable
-				plugins = append(plugins, pluginName)
-				pm.logger.Debugf("Found plugin: %s at %s", pluginName, execPath)
+			// Refused and rejected plugins stay listed so that using them reports why
+			var incompatible *manifest.IncompatibleError
+			if _, err := pm.vetPlugin(category, pluginName); errors.As(err, &incompatible) {
+				continue
 			}
+			plugins = append(plugins, pluginName)
+			pm.logger.Debugf("Found plugin: %s at %s", pluginName, execPath)
 		}
 	}
 
 	return plugins, nil
 }
 
-// StartPlugin launches a plugin process
+// DescribePlugins discovers the plugins of a category and queries their metadata.
+// Plugins that were not running are started for the query and stopped afterwards,
+// unless they are served by a remote; plugins that fail to answer are logged and skipped.
+func (pm *PluginManager) DescribePlugins(ctx context.Context, category string) ([]PluginDescription, error) {
+	names, err := pm.DiscoverPlugins(category)
+	if err != nil {
+		return nil, err
+	}
+
+	var descriptions []PluginDescription
+	for _, name := range names {
+		pm.mutex.RLock()
+		_, running := pm.pools[category+"-"+name]
+		remote := pm.remote
+		pm.mutex.RUnlock()
+
+		info, err := pm.PluginInfo(ctx, category, name)
+		health := pm.Health(category, name)
+		if !running && remote == nil {
+			pm.StopPlugin(category, name)
+		}
+		if err != nil {
+			pm.logger.Warnf("Failed to query plugin %s: %v", name, err)
+			continue
+		}
+
+		execPath, dir, _ := pm.Locate(category, name)
+		descriptions = append(descriptions, PluginDescription{Info: *info, Health: health, Path: execPath, Dir: dir})
+	}
+
+	return descriptions, nil
+}
+
+// StartPlugin launches a plugin process, unless the plugin is running. The
+// pool of the plugin then grows to its minimum size in the background. The
+// manager is not locked while the plugin starts: concurrent callers wait
+// for the same start.
 func (pm *PluginManager) StartPlugin(category, name string) error {
+	pluginKey := category + "-" + name
+
+	pm.mutex.Lock()
+	if p, exists := pm.pools[pluginKey]; exists && len(p.instances) > 0 {
+		pm.fillPool(p)
+		pm.mutex.Unlock()
+		return nil // Plugin already running
+	}
+	pm.mutex.Unlock()
+
+	execPath, dir, pluginManifest, err := pm.prepare(category, name)
+	if err != nil {
+		return err
+	}
+
+	if pm.Remote() != nil {
+		// The remote starts the plugin on its first call
+		return nil
+	}
+
+	pm.mutex.Lock()
+	p, exists := pm.pools[pluginKey]
+	switch {
+	case exists && len(p.instances) > 0:
+		pm.fillPool(p)
+		pm.mutex.Unlock()
+		return nil
+	case exists && p.launch != nil:
+		// Another caller is starting the plugin
+		launch := p.launch
+		pm.mutex.Unlock()
+		<-launch.done
+		return launch.err
+	case !exists:
+		p = &pool{category: category, name: name}
+		pm.pools[pluginKey] = p
+	}
+	launch := &launch{done: make(chan struct{})}
+	p.launch = launch
+	p.starting++
+	pluginConfig := pm.configs[name]
+	limiter := pm.newLimits(category, name)
+	pm.mutex.Unlock()
+
+	// Never execute a plugin quarantined for crashing too often
+	var instance *PluginInstance
+	err = pm.checkQuarantine(category, name, pluginConfig.Restart.withDefaults())
+	if err == nil {
+		instance, err = pm.spawn(category, name, execPath, dir, pluginManifest, pluginConfig, limiter)
+	}
+
 	pm.mutex.Lock()
 	defer pm.mutex.Unlock()
 
-	pluginKey := category + "-" + name
+	p.starting--
+	p.launch = nil
+	switch {
+	case err != nil:
+		pm.forgetIfEmpty(p)
+	case pm.pools[pluginKey] != p:
+		pm.discard(instance)
+		err = fmt.Errorf("plugin %s was stopped while starting", name)
+	default:
+		pm.register(p, instance)
+		pm.fillPool(p)
+	}
 
-	if _, exists := pm.plugins[pluginKey]; exists {
-		return nil // Plugin already running
+	launch.err = err
+	close(launch.done)
+	return err
+}
+
+// prepare locates a plugin binary and vets it before it is executed
+func (pm *PluginManager) prepare(category, name string) (string, searchpath.Dir, *manifest.Manifest, error) {
+	execPath, dir, err := pm.Locate(category, name)
+	if err != nil {
+		return "", searchpath.Dir{}, nil, err
 	}
 
-	execPath := filepath.Join(pm.pluginsDir, category, name)
+	// Never execute a plugin its manifest rejects
+	pluginManifest, err := pm.vetPlugin(category, name)
+	if err != nil {
+		return "", searchpath.Dir{}, nil, err
+	}
+	return execPath, dir, pluginManifest, nil
+}
 
-	pm.logger.Infof("Starting plugin: %s (%s)", name, execPath)
+// spawn starts a plugin process under limiter and waits for it to be
+// serving. The instance is not part of a pool yet, see register.
+func (pm *PluginManager) spawn(category, name, execPath string, dir searchpath.Dir, pluginManifest *manifest.Manifest, pluginConfig PluginConfig, limiter *limits.Process) (*PluginInstance, error) {
+	pm.logger.Infof("Starting plugin: %s (%s, from %s)", name, execPath, dir.Source)
+	pluginLogger := pm.logger.WithField("plugin", name)
+
+	startTimeout, waitTimeout := handshakeTimeout, readyTimeout
+	if pluginConfig.StartTimeout > 0 {
+		startTimeout, waitTimeout = pluginConfig.StartTimeout, pluginConfig.StartTimeout
+	}
+
+	// A verified binary is executed from the file opened, whatever happens
+	// to its path meanwhile
+	binary, err := pm.openVerified(name, execPath, pluginManifest)
+	if err != nil {
+		return nil, err
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	cmd := exec.CommandContext(ctx, execPath)
+	if binary != nil {
+		defer binary.Close()
+		cmd.Path = execFile(binary)
+	}
+	cmd.Env = os.Environ()
+	for key, value := range pluginConfig.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	cmd.Env = append(cmd.Env, handshake.EnvProtocolVersions+"="+handshake.FormatVersions(SupportedProtocolVersions))
+
+	if pluginConfig.Sandbox.Enabled {
+		report, err := sandbox.Prepare(cmd, name, pluginConfig.Sandbox, binary)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		for _, missing := range report.Missing {
+			pluginLogger.Warnf("Sandboxing without %s", missing)
+		}
+		pluginLogger.Infof("Sandboxing plugin: %s", report)
+		// The sandbox executes the binary
+		binary = nil
+	}
 
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		cancel()
-		return fmt.Errorf("failed to create stdin pipe: %w", err)
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
 	}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		cancel()
-		return fmt.Errorf("failed to create stdout pipe: %w", err)
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
 
-	// Capture stderr for logging
-	stderr, err := cmd.StderrPipe()
+	// Capture stderr for logging. The pipe is not closed by cmd.Wait, so
+	// that the last lines of a crashing plugin are logged too.
+	stderr, stderrWriter, err := os.Pipe()
 	if err != nil {
 		cancel()
-		return fmt.Errorf("failed to create stderr pipe: %w", err)
+		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
 	}
+	cmd.Stderr = stderrWriter
 
-	if err := cmd.Start(); err != nil {
+	if err := limiter.Prepare(cmd, binary); err != nil {
 		cancel()
-		return fmt.Errorf("failed to start plugin: %w", err)
+		stderr.Close()
+		stderrWriter.Close()
+		return nil, fmt.Errorf("failed to limit the resources of plugin %s: %w", name, err)
 	}
 
-	pluginLogger := pm.logger.WithField("plugin", name)
+	err = cmd.Start()
+	stderrWriter.Close()
+	if err != nil {
+		cancel()
+		stderr.Close()
+		limiter.Release()
+		return nil, fmt.Errorf("failed to start plugin: %w", err)
+	}
 
 	// Log plugin stderr
+	logged := make(chan struct{})
 	go func() {
+		defer close(logged)
+		defer stderr.Close()
 		scanner := bufio.NewScanner(stderr)
 		for scanner.Scan() {
+			limiter.Observe(scanner.Text())
 			pluginLogger.Info(scanner.Text())
 		}
 	}()
 
-	// Create gRPC client
-	client, err := NewGRPCClient(stdin, stdout, pluginLogger)
-	if err != nil {
+	// abort stops the plugin when it fails to start, reporting the limit it
+	// exceeded if it died of one rather than err
+	abort := func(err error) error {
 		cancel()
 		cmd.Process.Kill()
-		return fmt.Errorf("failed to create gRPC client: %w", err)
+		cmd.Wait()
+		awaitLogged(logged)
+		defer limiter.Release()
+		return limiter.Check(name, cmd.ProcessState, err)
+	}
+
+	limiter.Started()
+
+	// Negotiate the protocol version before the gRPC service is used
+	reader := bufio.NewReader(stdout)
+	version, err := readHandshake(reader, cmd, startTimeout)
+	if err != nil {
+		return nil, abort(&handshake.IncompatibleError{Plugin: name, HostVersions: SupportedProtocolVersions, Reason: err.Error()})
+	}
+	if _, ok := handshake.Negotiate(SupportedProtocolVersions, []int{version}); !ok {
+		return nil, abort(&handshake.IncompatibleError{
+			Plugin:       name,
+			HostVersions: SupportedProtocolVersions,
+			Reason:       fmt.Sprintf("plugin selected unsupported protocol version %d", version),
+		})
+	}
+	pluginLogger.Debugf("Negotiated protocol version %d", version)
+	if pluginManifest != nil && pluginManifest.ProtocolVersion != 0 && pluginManifest.ProtocolVersion != version {
+		pluginLogger.Warnf("Plugin negotiated protocol version %d but its manifest declares %d", version, pluginManifest.ProtocolVersion)
+	}
+
+	// Create gRPC client, the handshake reader keeps any bytes buffered past the handshake line
+	client, err := NewGRPCClient(stdin, readCloser{Reader: reader, Closer: stdout}, pluginLogger)
+	if err != nil {
+		return nil, abort(fmt.Errorf("failed to create gRPC client: %w", err))
+	}
+
+	// Wait for the plugin to report SERVING before marking it ready
+	if err := waitForServing(ctx, client, waitTimeout); err != nil {
+		client.Close()
+		return nil, abort(fmt.Errorf("plugin %s did not become ready: %w", name, err))
 	}
+	pluginLogger.Debug("Plugin is serving")
 
 	instance := &PluginInstance{
+		Category:   category,
 		Name:       name,
+		Path:       execPath,
+		Dir:        dir,
 		Command:    cmd,
 		Stdin:      stdin,
 		Stdout:     stdout,
@@ -147,40 +605,344 @@ func (pm *PluginManager) StartPlugin(category, name string) error {
 		Logger:     pluginLogger,
 		ctx:        ctx,
 		cancelFunc: cancel,
+
+		ProtocolVersion: version,
+		exited:          make(chan struct{}),
+		limits:          limiter,
+		logged:          logged,
+		health:          healthpb.HealthCheckResponse_SERVING,
 	}
+	return instance, nil
+}
+
+// awaitLogged waits briefly for the stderr output of an exited plugin to be logged
+func awaitLogged(logged chan struct{}) {
+	select {
+	case <-logged:
+	case <-time.After(exitGracePeriod):
+	}
+}
 
-	pm.plugins[pluginKey] = instance
+// register adds a started instance to the pool of its plugin and watches
+// it until it exits. The caller holds mutex.
+func (pm *PluginManager) register(p *pool, instance *PluginInstance) {
+	instance.pool = p
+	instance.lastUsed.Store(time.Now().UnixNano())
+	p.instances = append(p.instances, instance)
 
-	// Handle process exit
+	go pm.probeHealth(instance, pm.healthCheckInterval)
+	go pm.reapWhenIdle(instance)
+
+	// Handle process exit, the supervisor takes care of crashed plugins
 	go func() {
-		err := cmd.Wait()
+		err := instance.Command.Wait()
+		awaitLogged(instance.logged)
+		err = instance.limits.Check(instance.Name, instance.Command.ProcessState, err)
+		if releaseErr := instance.limits.Release(); releaseErr != nil {
+			instance.Logger.Warnf("Failed to release resource limits: %v", releaseErr)
+		}
+
+		if instance.ctx.Err() == nil {
+			pm.handleCrash(instance, err)
+			return
+		}
+
 		pm.mutex.Lock()
-		delete(pm.plugins, pluginKey)
+		pm.removeInstance(instance)
 		pm.mutex.Unlock()
+		close(instance.exited)
+		instance.Logger.Info("Plugin exited")
+	}()
+}
 
-		if err != nil && ctx.Err() == nil { // Don't log if we cancelled the context
-			pluginLogger.Errorf("Plugin exited with error: %v", err)
-		} else {
-			pluginLogger.Info("Plugin exited")
+// vetPlugin checks a plugin binary against its signature and its manifest,
+// if it has one, before the plugin is executed. Warnings are logged; plugins
+// that must be refused, skipped or rejected are logged and reported as an
+// error. Results are cached until the binary changes; the signature in
+// enforce mode, and the checksum of the manifest if any, are checked again
+// on every start, see openVerified.
+func (pm *PluginManager) vetPlugin(category, name string) (*manifest.Manifest, error) {
+	execPath, _, err := pm.Locate(category, name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(execPath)
+	if err != nil {
+		return nil, err
+	}
+
+	pm.vetMutex.Lock()
+	defer pm.vetMutex.Unlock()
+
+	if cached, ok := pm.vetted[execPath]; ok && cached.modTime.Equal(fi.ModTime()) && cached.size == fi.Size() {
+		return cached.manifest, cached.err
+	}
+
+	// Nothing shipped with an untrusted binary is worth reading
+	var m *manifest.Manifest
+	if err = pm.verifySignature(name, execPath); err == nil {
+		m, err = pm.checkManifest(name, execPath)
+	}
+
+	switch err.(type) {
+	case nil:
+	case *manifest.IncompatibleError:
+		pm.logger.Warnf("%v", err)
+	default:
+		pm.logger.Errorf("%v", err)
+	}
+
+	pm.vetted[execPath] = vetResult{modTime: fi.ModTime(), size: fi.Size(), manifest: m, err: err}
+	return m, err
+}
+
+// openVerified opens a plugin binary and verifies again its signature in
+// enforce mode and its checksum when its manifest declares one, returning
+// nil when there is nothing to verify: the vetting of the binary is cached,
+// and its path may point to another file by now
+func (pm *PluginManager) openVerified(name, execPath string, pluginManifest *manifest.Manifest) (*os.File, error) {
+	pm.vetMutex.Lock()
+	mode, keyring := pm.signatureMode, pm.keyring
+	pm.vetMutex.Unlock()
+
+	checksum := pluginManifest != nil && pluginManifest.Checksum != ""
+	if mode != signing.ModeEnforce && !checksum {
+		return nil, nil
+	}
+
+	binary, err := os.Open(execPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plugin: %w", err)
+	}
+	if mode == signing.ModeEnforce {
+		_, err = keyring.VerifyFile(name, execPath, binary)
+	}
+	if err == nil && checksum {
+		err = pluginManifest.VerifyFile(name, binary)
+	}
+	if err != nil {
+		binary.Close()
+		pm.logger.Errorf("%v", err)
+		return nil, err
+	}
+	return binary, nil
+}
+
+// execFile returns the path executing an open file: its descriptor on
+// Linux, which the child process inherits until it executes it, and its
+// name elsewhere
+func execFile(f *os.File) string {
+	if runtime.GOOS == "linux" {
+		return fmt.Sprintf("/proc/self/fd/%d", f.Fd())
+	}
+	return f.Name()
+}
+
+// checkManifest loads the manifest of a plugin binary, if it has one, and checks the binary against it
+func (pm *PluginManager) checkManifest(name, execPath string) (*manifest.Manifest, error) {
+	m, err := manifest.Load(execPath)
+	if err != nil {
+		return nil, &manifest.InvalidError{Plugin: name, Path: manifest.Path(execPath), Err: err}
+	}
+	if m == nil {
+		pm.logger.Debugf("Plugin %s has no manifest", name)
+		return nil, nil
+	}
+
+	host := manifest.Host{Version: version.Version, ProtocolVersions: SupportedProtocolVersions}
+	warnings, err := m.Check(name, execPath, host)
+	for _, warning := range warnings {
+		pm.logger.Warnf("Plugin %s: %s", name, warning)
+	}
+	return m, err
+}
+
+// verifySignature checks the signature of a plugin binary according to the
+// verification mode. Failures are only logged in warn mode. The caller holds vetMutex.
+func (pm *PluginManager) verifySignature(name, execPath string) error {
+	if pm.signatureMode == signing.ModeOff {
+		return nil
+	}
+
+	key, err := pm.keyring.Verify(name, execPath)
+	if err != nil {
+		if pm.signatureMode == signing.ModeWarn {
+			pm.logger.Warnf("Running unverified plugin: %v", err)
+			return nil
 		}
-	}()
+		return err
+	}
 
+	pm.logger.Debugf("Plugin %s is signed by trusted key %s (%s)", name, key.ID, key.Fingerprint())
+	return nil
+}
+
+// checkKind rejects greeting kinds a plugin's manifest does not declare,
+// without starting the plugin
+func (pm *PluginManager) checkKind(category, name, kind string) error {
+	m, err := pm.vetPlugin(category, name)
+	if err != nil {
+		return err
+	}
+	if m != nil && !m.Supports(kind) {
+		return &greetings.UnsupportedKindError{Kind: kind, Language: name, Supported: m.Greetings}
+	}
 	return nil
 }
 
-// StopPlugin terminates a plugin process
+// readHandshake reads the protocol version announced by a starting plugin,
+// killing the plugin if it does not answer within timeout
+func readHandshake(reader *bufio.Reader, cmd *exec.Cmd, timeout time.Duration) (int, error) {
+	type result struct {
+		version int
+		err     error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		version, err := handshake.ReadVersion(reader)
+		done <- result{version, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.version, res.err
+	case <-time.After(timeout):
+		cmd.Process.Kill()
+		return 0, fmt.Errorf("no handshake received within %s", timeout)
+	}
+}
+
+// waitForServing polls the health service of a starting plugin until it reports SERVING
+func waitForServing(ctx context.Context, client *GRPCClient, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		status, err := client.CheckHealth(ctx)
+		if err == nil && status == healthpb.HealthCheckResponse_SERVING {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return fmt.Errorf("no healthy answer within %s: %w", timeout, err)
+			}
+			return fmt.Errorf("health status still %s after %s", status, timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeHealth periodically checks a running plugin until it is stopped,
+// logging every change of its serving status
+func (pm *PluginManager) probeHealth(instance *PluginInstance, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-instance.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		status, err := instance.Client.CheckHealth(instance.ctx)
+		if err != nil {
+			if instance.ctx.Err() != nil {
+				return
+			}
+			instance.Logger.Warnf("Health check failed: %v", err)
+		}
+
+		if previous := instance.setHealth(status); previous != status {
+			if status == healthpb.HealthCheckResponse_SERVING {
+				instance.Logger.Infof("Plugin health changed from %s to %s", previous, status)
+			} else {
+				instance.Logger.Warnf("Plugin health changed from %s to %s", previous, status)
+			}
+		}
+	}
+}
+
+// Health returns the serving status of a running plugin, UNKNOWN if it is
+// not running. A plugin with several instances is serving if one of them is.
+func (pm *PluginManager) Health(category, name string) healthpb.HealthCheckResponse_ServingStatus {
+	pm.mutex.RLock()
+	p, exists := pm.pools[category+"-"+name]
+	var instances []*PluginInstance
+	if exists {
+		instances = slices.Clone(p.instances)
+	}
+	pm.mutex.RUnlock()
+
+	remote, err := pm.remoteFor(category, name)
+	if err != nil {
+		// A refused plugin is never running
+		return healthpb.HealthCheckResponse_UNKNOWN
+	}
+	if remote != nil {
+		health, err := remote.Health(context.Background(), category, name)
+		if err != nil {
+			pm.logger.Warnf("Failed to query the health of plugin %s: %v", name, err)
+		}
+		return health
+	}
+
+	health := healthpb.HealthCheckResponse_UNKNOWN
+	for i, instance := range instances {
+		if status := instance.Health(); i == 0 || status == healthpb.HealthCheckResponse_SERVING {
+			health = status
+		}
+	}
+	return health
+}
+
+// readCloser reads from a buffered reader while closing the underlying pipe
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// StopPlugin terminates the processes of a plugin
 func (pm *PluginManager) StopPlugin(category, name string) error {
+	if remote := pm.Remote(); remote != nil {
+		return remote.StopPlugin(context.Background(), category, name)
+	}
+
 	pm.mutex.Lock()
 	defer pm.mutex.Unlock()
 
 	pluginKey := category + "-" + name
-	instance, exists := pm.plugins[pluginKey]
+	if pending, ok := pm.restarts[pluginKey]; ok {
+		pending.cancel()
+	}
+	p, exists := pm.pools[pluginKey]
 	if !exists {
 		return nil // Plugin not running
 	}
 
-	pm.logger.Infof("Stopping plugin: %s", name)
+	pm.stopPool(pluginKey, p)
+	return nil
+}
+
+// stopPool terminates the instances of a pool and forgets it. The caller holds mutex.
+func (pm *PluginManager) stopPool(pluginKey string, p *pool) {
+	pm.logger.Infof("Stopping plugin: %s", p.name)
+	for _, instance := range p.instances {
+		pm.terminate(instance)
+	}
+
+	// Instances still starting are stopped once they are ready, see addInstance
+	delete(pm.pools, pluginKey)
+}
 
+// terminate stops the process of a plugin instance
+func (pm *PluginManager) terminate(instance *PluginInstance) {
 	// Close gRPC client
 	if instance.Client != nil {
 		//first close pipes
@@ -197,82 +959,196 @@ func (pm *PluginManager) StopPlugin(category, name string) error {
 
 	}
 
-	pm.logger.Info("canceling plugin context")
+	pm.logger.Debug("canceling plugin context")
 
 	// Cancel the context to stop the command
 	instance.cancelFunc()
 
 	// Force kill if necessary
-	pm.logger.Info("waiting for plugin to exit")
+	pm.logger.Debug("waiting for plugin to exit")
 	if instance.Command.ProcessState == nil || !instance.Command.ProcessState.Exited() {
 		if err := instance.Command.Process.Kill(); err != nil {
 			pm.logger.Warnf("Failed to kill plugin process: %v", err)
 		}
 	}
-
-	delete(pm.plugins, pluginKey)
-
-	return nil
 }
 
-// GetGreeting sends a command to a plugin and returns the response
-func (pm *PluginManager) GetGreeting(ctx context.Context, category, name, command string) (string, error) {
-	pm.mutex.RLock()
+// getInstance returns the least busy instance of a plugin, waiting for the
+// plugin if it is restarting after a crash and starting it if needed. The
+// pool of the plugin grows in the background when every instance is busy.
+func (pm *PluginManager) getInstance(ctx context.Context, category, name string) (*PluginInstance, error) {
 	pluginKey := category + "-" + name
-	instance, exists := pm.plugins[pluginKey]
-	pm.mutex.RUnlock()
 
-	if !exists {
+	instance := pm.pick(pluginKey)
+	if instance == nil {
+		if err := pm.awaitRestart(ctx, category, name); err != nil {
+			return nil, err
+		}
+		instance = pm.pick(pluginKey)
+	}
+
+	if instance == nil {
 		// Try to start the plugin if it's not running
 		if err := pm.StartPlugin(category, name); err != nil {
-			return "", fmt.Errorf("plugin %s is not running and could not be started: %w", pluginKey, err)
+			return nil, fmt.Errorf("plugin %s is not running and could not be started: %w", pluginKey, err)
 		}
 
-		pm.mutex.RLock()
-		instance = pm.plugins[pluginKey]
-		pm.mutex.RUnlock()
+		if instance = pm.pick(pluginKey); instance == nil {
+			return nil, fmt.Errorf("plugin %s exited right after starting", pluginKey)
+		}
 	}
 
-	pm.logger.Debugf("Executing command '%s' on plugin %s", command, name)
+	return instance, nil
+}
+
+// GetGreeting sends a greeting request to a plugin and returns the response
+func (pm *PluginManager) GetGreeting(ctx context.Context, category, name string, req greetings.Request) (greetings.Greeting, error) {
+	if err := pm.checkKind(category, name, req.Kind); err != nil {
+		return greetings.Greeting{}, err
+	}
 
-	// Use the gRPC client to get the greeting
-	return instance.Client.GetGreeting(ctx, command)
+	if remote := pm.Remote(); remote != nil {
+		pm.logger.Debugf("Requesting greeting '%s' from remote plugin %s", req.Kind, name)
+		return remote.GetGreeting(ctx, category, name, req)
+	}
+
+	pm.logger.Debugf("Requesting greeting '%s' from plugin %s", req.Kind, name)
+
+	var greeting greetings.Greeting
+	err := pm.callPlugin(ctx, category, name, func(instance *PluginInstance) error {
+		ctx, cancel := pm.callContext(ctx, name)
+		defer cancel()
+
+		// Use the gRPC client to get the greeting
+		var err error
+		greeting, err = instance.Client.GetGreeting(ctx, req)
+		if status.Code(err) == codes.Unimplemented {
+			// The plugin does not provide this kind, report what it does provide
+			supported, _ := instance.Client.ListKinds(ctx)
+			return &greetings.UnsupportedKindError{Kind: req.Kind, Language: name, Supported: supported}
+		}
+		return err
+	})
+	if err != nil {
+		return greetings.Greeting{}, err
+	}
+
+	return greeting, nil
 }
 
-// CleanupPlugins stops all running plugins
-func (pm *PluginManager) CleanupPlugins() {
-	pm.mutex.Lock()
-	defer pm.mutex.Unlock()
+// GetVariants requests the variants of a greeting from a plugin. Plugins
+// that predate variants are answered with their single greeting.
+func (pm *PluginManager) GetVariants(ctx context.Context, category, name string, req greetings.Request) ([]greetings.Variant, error) {
+	if err := pm.checkKind(category, name, req.Kind); err != nil {
+		return nil, err
+	}
 
-	for key, instance := range pm.plugins {
-		pm.logger.Infof("Stopping plugin: %s", instance.Name)
+	if remote := pm.Remote(); remote != nil {
+		pm.logger.Debugf("Requesting variants of '%s' from remote plugin %s", req.Kind, name)
+		return remote.GetVariants(ctx, category, name, req)
+	}
 
-		// Close gRPC client
-		if instance.Client != nil {
-			//first close pipes
-			if err := instance.Stdin.Close(); err != nil {
-				pm.logger.Warnf("Failed to close stdin pipe: %v", err)
-			}
-			if err := instance.Stdout.Close(); err != nil {
-				pm.logger.Warnf("Failed to close stdout pipe: %v", err)
-			}
-			//then close client
-			if err := instance.Client.Close(); err != nil {
-				pm.logger.Warnf("Failed to close gRPC client: %v", err)
-			}
+	pm.logger.Debugf("Requesting variants of '%s' from plugin %s", req.Kind, name)
+
+	var variants []greetings.Variant
+	err := pm.callPlugin(ctx, category, name, func(instance *PluginInstance) error {
+		callCtx, cancel := pm.callContext(ctx, name)
+		defer cancel()
 
+		var err error
+		variants, err = instance.Client.GetVariants(callCtx, req)
+		return err
+	})
+	if status.Code(err) == codes.Unimplemented || (err == nil && len(variants) == 0) {
+		// Either an older plugin or an unsupported kind, Greet tells which
+		greeting, err := pm.GetGreeting(ctx, category, name, req)
+		if err != nil {
+			return nil, err
 		}
+		return []greetings.Variant{{Greeting: greeting, Weight: 1}}, nil
+	}
 
-		// Cancel the context to stop the command
-		instance.cancelFunc()
+	return variants, err
+}
 
-		// Force kill if necessary
-		if instance.Command.ProcessState == nil || !instance.Command.ProcessState.Exited() {
-			if err := instance.Command.Process.Kill(); err != nil {
-				pm.logger.Warnf("Failed to kill plugin process: %v", err)
-			}
+// ListKinds returns the greeting kinds supported by a plugin
+func (pm *PluginManager) ListKinds(ctx context.Context, category, name string) ([]string, error) {
+	remote, err := pm.remoteFor(category, name)
+	if err != nil {
+		return nil, err
+	}
+	if remote != nil {
+		return remote.ListKinds(ctx, category, name)
+	}
+
+	var kinds []string
+	err = pm.callPlugin(ctx, category, name, func(instance *PluginInstance) error {
+		ctx, cancel := pm.callContext(ctx, name)
+		defer cancel()
+
+		var err error
+		kinds, err = instance.Client.ListKinds(ctx)
+		return err
+	})
+	return kinds, err
+}
+
+// PluginInfo returns the metadata reported by a plugin
+func (pm *PluginManager) PluginInfo(ctx context.Context, category, name string) (*greetings.Info, error) {
+	remote, err := pm.remoteFor(category, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var info *greetings.Info
+	if remote != nil {
+		if info, err = remote.PluginInfo(ctx, category, name); err != nil {
+			return nil, err
 		}
+	} else {
+		err := pm.callPlugin(ctx, category, name, func(instance *PluginInstance) error {
+			ctx, cancel := pm.callContext(ctx, name)
+			defer cancel()
+
+			var err error
+			info, err = instance.Client.GetInfo(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
 
-		delete(pm.plugins, key)
+	if info.Name != name {
+		pm.logger.Debugf("Plugin %s reports name %q", name, info.Name)
+	}
+	info.Name = name
+
+	return info, nil
+}
+
+// CleanupPlugins stops all running plugins. Plugins served by a remote keep
+// running, only the connection to the remote is closed.
+func (pm *PluginManager) CleanupPlugins() {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if pm.remote != nil {
+		if err := pm.remote.Close(); err != nil {
+			pm.logger.Warnf("Failed to close remote connection: %v", err)
+		}
+		pm.remote = nil
+	}
+
+	for _, pending := range pm.restarts {
+		pending.cancel()
+	}
+
+	for key, p := range pm.pools {
+		for _, instance := range p.instances {
+			pm.logger.Infof("Stopping plugin: %s", instance.Name)
+			pm.terminate(instance)
+		}
+		delete(pm.pools, key)
 	}
 }
@@ -0,0 +1,345 @@
+package plugin
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin/handshake"
+	"github.com/unsuman/greeter/pkg/plugin/manifest"
+	"github.com/unsuman/greeter/pkg/plugin/searchpath"
+	"github.com/unsuman/greeter/pkg/plugin/signing"
+	"github.com/unsuman/greeter/pkg/version"
+)
+
+func TestManifestVetting(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, err := manifest.Checksum(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		manifest manifest.Manifest
+		// listed tells whether DiscoverPlugins lists the plugin, started
+		// whether it can be started
+		listed  bool
+		started bool
+	}{
+		{"valid", manifest.Manifest{Name: "fake", Checksum: sum, ProtocolVersion: handshake.ProtocolVersion, MinHostVersion: version.Version}, true, true},
+		{"no checksum", manifest.Manifest{Name: "fake"}, true, true},
+		{"checksum mismatch", manifest.Manifest{Name: "fake", Checksum: "sha256:9f86d081884c7d65"}, true, false},
+		{"other plugin", manifest.Manifest{Name: "hindi", Checksum: sum}, true, false},
+		{"newer host required", manifest.Manifest{Name: "fake", Checksum: sum, MinHostVersion: "999.0"}, false, false},
+		{"other protocol", manifest.Manifest{Name: "fake", Checksum: sum, ProtocolVersion: 99}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pm, _, _ := newTestManager(t, PluginConfig{})
+			execPath, _, err := pm.Locate("lang", "fake")
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := json.Marshal(tt.manifest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(manifest.Path(execPath), data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			names, err := pm.DiscoverPlugins("lang")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if listed := len(names) == 1; listed != tt.listed {
+				t.Errorf("listed = %v (%v), want %v", listed, names, tt.listed)
+			}
+
+			err = pm.StartPlugin("lang", "fake")
+			if started := err == nil; started != tt.started {
+				t.Errorf("started = %v (%v), want %v", started, err, tt.started)
+			}
+			if !tt.started && len(instances(pm)) > 0 {
+				t.Error("a rejected plugin was executed")
+			}
+		})
+	}
+}
+
+func TestManifestGreetings(t *testing.T) {
+	pm, _, _ := newTestManager(t, PluginConfig{})
+	execPath, _, err := pm.Locate("lang", "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(`{"name": "fake", "greetings": ["hello"]}`)
+	if err := os.WriteFile(manifest.Path(execPath), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var unsupported *greetings.UnsupportedKindError
+	if _, err := pm.GetGreeting(context.Background(), "lang", "fake", greetings.Request{Kind: "slow"}); !errors.As(err, &unsupported) {
+		t.Errorf("got %v, want an *UnsupportedKindError", err)
+	}
+	if len(instances(pm)) > 0 {
+		t.Error("the plugin was started for a kind its manifest does not declare")
+	}
+	if _, err := pm.GetGreeting(context.Background(), "lang", "fake", greetings.Request{Kind: "hello"}); err != nil {
+		t.Errorf("GetGreeting: %v", err)
+	}
+}
+
+func TestSignatureVerification(t *testing.T) {
+	public, release, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, other, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring := signing.Keyring{{ID: "release.pub", PublicKey: public}}
+
+	tests := []struct {
+		name    string
+		mode    signing.Mode
+		key     ed25519.PrivateKey
+		started bool
+	}{
+		{"signed", signing.ModeEnforce, release, true},
+		{"unsigned", signing.ModeEnforce, nil, false},
+		{"untrusted", signing.ModeEnforce, other, false},
+		{"untrusted in warn mode", signing.ModeWarn, other, true},
+		{"unsigned in warn mode", signing.ModeWarn, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pm, _, _ := newTestManager(t, PluginConfig{})
+			pm.SetSignatureVerification(tt.mode, keyring)
+			if tt.key != nil {
+				execPath, _, err := pm.Locate("lang", "fake")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := signing.Sign(tt.key, execPath); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := pm.StartPlugin("lang", "fake")
+			if started := err == nil; started != tt.started {
+				t.Errorf("started = %v (%v), want %v", started, err, tt.started)
+			}
+			var verr *signing.VerificationError
+			if !tt.started && (!errors.As(err, &verr) || len(instances(pm)) > 0) {
+				t.Errorf("got %v, want the plugin refused before it is executed", err)
+			}
+		})
+	}
+}
+
+func TestSignatureVerifiedOnEveryStart(t *testing.T) {
+	public, release, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, other, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pm, _, _ := newTestManager(t, PluginConfig{})
+	pm.SetSignatureVerification(signing.ModeEnforce, signing.Keyring{{ID: "release.pub", PublicKey: public}})
+	execPath, _, err := pm.Locate("lang", "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signing.Sign(release, execPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := pm.StartPlugin("lang", "fake"); err != nil {
+		t.Fatal(err)
+	}
+	if err := pm.StopPlugin("lang", "fake"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The binary is unchanged, so its vetting stays cached
+	if err := signing.Sign(other, execPath); err != nil {
+		t.Fatal(err)
+	}
+	var verr *signing.VerificationError
+	if err := pm.StartPlugin("lang", "fake"); !errors.As(err, &verr) {
+		t.Errorf("got %v, want the signature checked again", err)
+	}
+}
+
+func TestChecksumVerifiedOnEveryStart(t *testing.T) {
+	pm, _, _ := newTestManager(t, PluginConfig{})
+	execPath, _, err := pm.Locate("lang", "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, err := manifest.Checksum(execPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(manifest.Manifest{Name: "fake", Checksum: sum})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(manifest.Path(execPath), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := pm.StartPlugin("lang", "fake"); err != nil {
+		t.Fatal(err)
+	}
+	if err := pm.StopPlugin("lang", "fake"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Swap in another binary of the same size and modification time, so
+	// that the vetting stays cached
+	fi, err := os.Stat(execPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary, err := os.ReadFile(execPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary[len(binary)-1] ^= 0xff
+	swapped := filepath.Join(t.TempDir(), "fake")
+	if err := os.WriteFile(swapped, binary, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(swapped, fi.ModTime(), fi.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(execPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(swapped, execPath); err != nil {
+		t.Fatal(err)
+	}
+
+	var invalid *manifest.InvalidError
+	if err := pm.StartPlugin("lang", "fake"); !errors.As(err, &invalid) || len(instances(pm)) > 0 {
+		t.Errorf("got %v, want the swapped binary refused before it is executed", err)
+	}
+}
+
+func TestLocatePrecedence(t *testing.T) {
+	high, low := t.TempDir(), t.TempDir()
+	for path, mode := range map[string]os.FileMode{
+		filepath.Join(high, "lang", "hindi"):               0o755,
+		filepath.Join(low, "lang", "hindi"):                0o755,
+		filepath.Join(low, "lang", "japanese"):             0o755,
+		filepath.Join(high, "lang", "french"):              0o644,
+		filepath.Join(low, "lang", "french"):               0o755,
+		filepath.Join(low, "lang", "hindi.sig"):            0o755,
+		filepath.Join(low, "lang", "japanese.plugin.json"): 0o755,
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logger, _ := logtest.NewNullLogger()
+	pm := NewPluginManager(logger, searchpath.Path{{Path: high, Source: "high"}, {Path: low, Source: "low"}})
+
+	for name, want := range map[string]string{"hindi": high, "japanese": low, "french": low} {
+		execPath, dir, err := pm.Locate("lang", name)
+		if err != nil || dir.Path != want || execPath != filepath.Join(want, "lang", name) {
+			t.Errorf("Locate(%s) = %s, %v, %v, want it in %s", name, execPath, dir, err, want)
+		}
+	}
+	var notFound *NotFoundError
+	if _, _, err := pm.Locate("lang", "hindi.sig"); !errors.As(err, &notFound) {
+		t.Errorf("got %v, want a signature not taken for a plugin", err)
+	}
+
+	names, err := pm.DiscoverPlugins("lang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+	if want := []string{"french", "hindi", "japanese"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("DiscoverPlugins = %v, want %v", names, want)
+	}
+}
+
+func TestProtocolNegotiation(t *testing.T) {
+	tests := []struct {
+		name string
+		host []int
+		// versions are the versions the plugin serves, handshake the line
+		// it writes instead of negotiating, if set
+		versions  string
+		handshake string
+		want      int
+		// err is part of the expected incompatibility, empty for none
+		err string
+	}{
+		{"current version", []int{handshake.ProtocolVersion}, "", "", handshake.ProtocolVersion, ""},
+		{"highest common version", []int{1, 2}, "1,2", "", 2, ""},
+		{"older plugin", []int{1, 2}, "1", "", 1, ""},
+		{"newer plugin", []int{1}, "1,2", "", 1, ""},
+		{"no common version", []int{1}, "2,3", "", 0, "plugin refused the connection: plugin serves protocol versions 2,3, host supports 1"},
+		{"version not offered", []int{1}, "", "GREETER_PLUGIN|2|grpc\n", 0, "plugin selected unsupported protocol version 2"},
+		{"no handshake", []int{1}, "", "Hello, world!\n", 0, "built for an older greeter"},
+		{"malformed handshake", []int{1}, "", "GREETER_PLUGIN|v1|grpc\n", 0, "malformed handshake"},
+		{"other transport", []int{1}, "", "GREETER_PLUGIN|1|netrpc\n", 0, `unsupported transport "netrpc"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(versions []int) { SupportedProtocolVersions = versions }(SupportedProtocolVersions)
+			SupportedProtocolVersions = tt.host
+
+			pm, _, dir := newTestManager(t, PluginConfig{})
+			for name, content := range map[string]string{"versions": tt.versions, "handshake": tt.handshake} {
+				if content == "" {
+					continue
+				}
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := pm.StartPlugin("lang", "fake")
+			if tt.err != "" {
+				var incompatible *handshake.IncompatibleError
+				if !errors.As(err, &incompatible) || !strings.Contains(incompatible.Reason, tt.err) {
+					t.Errorf("got %v, want an incompatibility containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := pm.PluginInfo(context.Background(), "lang", "fake")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.ProtocolVersion != tt.want {
+				t.Errorf("negotiated protocol version %d, want %d", info.ProtocolVersion, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+// Package manifest reads the optional manifest files describing external
+// plugins, so the host can vet a plugin before ever executing it.
+//
+// The manifest of a plugin binary sits next to it with the ".plugin.json"
+// suffix, e.g. lang/hindi.plugin.json:
+//
+//	{
+//	  "name": "hindi",
+//	  "version": "1.0.0",
+//	  "protocol_version": 1,
+//	  "checksum": "sha256:9f86d081884c7d65...",
+//	  "description": "Hindi greetings in Devanagari with romanization",
+//	  "greetings": ["hello", "goodmorning"],
+//	  "min_host_version": "1.0.0"
+//	}
+//
+// Only name is required. Plugin binaries print their manifest when run with
+// the --manifest flag, see external.Serve.
+package manifest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/unsuman/greeter/pkg/version"
+)
+
+// Suffix is appended to the path of a plugin binary to locate its manifest
+const Suffix = ".plugin.json"
+
+// checksumAlgorithm prefixes the hex digest in the checksum field
+const checksumAlgorithm = "sha256:"
+
+// Manifest describes an external plugin
+type Manifest struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	// ProtocolVersion is the plugin protocol version the plugin speaks, 0 if not declared
+	ProtocolVersion int `json:"protocol_version,omitempty"`
+	// Checksum is the digest of the plugin binary, "sha256:<hex>"
+	Checksum    string `json:"checksum,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Greetings lists the greeting kinds supported by the plugin
+	Greetings []string `json:"greetings,omitempty"`
+	// MinHostVersion is the oldest greeter release the plugin works with
+	MinHostVersion string `json:"min_host_version,omitempty"`
+
+	// Path is the file the manifest was read from
+	Path string `json:"-"`
+}
+
+// Host describes the greeter a plugin is checked against
+type Host struct {
+	Version          string
+	ProtocolVersions []int
+}
+
+// InvalidError reports a manifest that is malformed or does not match its
+// binary. The plugin must not be executed.
+type InvalidError struct {
+	Plugin string
+	Path   string
+	Err    error
+}
+
+func (e *InvalidError) Error() string {
+	return fmt.Sprintf("plugin %s rejected: %s: %v", e.Plugin, e.Path, e.Err)
+}
+
+func (e *InvalidError) Unwrap() error {
+	return e.Err
+}
+
+// IncompatibleError reports a plugin built for another greeter release or
+// protocol version. The plugin is skipped.
+type IncompatibleError struct {
+	Plugin string
+	Reason string
+}
+
+func (e *IncompatibleError) Error() string {
+	return fmt.Sprintf("plugin %s skipped: %s", e.Plugin, e.Reason)
+}
+
+// Path returns the manifest path of a plugin binary
+func Path(binary string) string {
+	return binary + Suffix
+}
+
+// IsManifest reports whether a file name is the one of a manifest
+func IsManifest(name string) bool {
+	return strings.HasSuffix(name, Suffix)
+}
+
+// Load reads the manifest of a plugin binary. It returns nil without error
+// when the plugin has no manifest.
+func Load(binary string) (*Manifest, error) {
+	path := Path(binary)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	m := &Manifest{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(m); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	m.Path = path
+
+	if m.Name == "" {
+		return nil, fmt.Errorf("%s: name is required", path)
+	}
+	if m.Checksum != "" && !strings.HasPrefix(m.Checksum, checksumAlgorithm) {
+		return nil, fmt.Errorf("%s: checksum must be %s<hex digest>", path, checksumAlgorithm)
+	}
+	if m.MinHostVersion != "" {
+		if err := version.Validate(m.MinHostVersion); err != nil {
+			return nil, fmt.Errorf("%s: min_host_version: %w", path, err)
+		}
+	}
+
+	return m, nil
+}
+
+// Checksum returns the checksum of a file in the manifest format
+func Checksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return checksum(f)
+}
+
+// checksum returns the checksum of the content of r in the manifest format
+func checksum(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return checksumAlgorithm + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyFile checks the plugin binary opened as f against the checksum of
+// the manifest, if it has one, so that the caller can execute the very file
+// it checked. The plugin named name is rejected with an *InvalidError.
+func (m *Manifest) VerifyFile(name string, f *os.File) error {
+	if m.Checksum == "" {
+		return nil
+	}
+	sum, err := checksum(io.NewSectionReader(f, 0, math.MaxInt64))
+	if err != nil {
+		return &InvalidError{Plugin: name, Path: m.Path, Err: err}
+	}
+	if sum != m.Checksum {
+		return &InvalidError{Plugin: name, Path: m.Path, Err: fmt.Errorf("checksum mismatch: binary is %s", sum)}
+	}
+	return nil
+}
+
+// Check vets a plugin binary against its manifest and the host. The plugin
+// named name is rejected with an *InvalidError when the manifest does not
+// match the binary, and skipped with an *IncompatibleError when it requires
+// another host. Problems that do not prevent using the plugin are returned
+// as warnings.
+func (m *Manifest) Check(name, binary string, host Host) (warnings []string, err error) {
+	if m.Name != name {
+		return nil, &InvalidError{Plugin: name, Path: m.Path, Err: fmt.Errorf("manifest is for plugin %q", m.Name)}
+	}
+
+	if m.Checksum == "" {
+		warnings = append(warnings, "manifest has no checksum, the binary cannot be verified")
+	} else {
+		f, err := os.Open(binary)
+		if err != nil {
+			return nil, &InvalidError{Plugin: name, Path: m.Path, Err: err}
+		}
+		err = m.VerifyFile(name, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if m.MinHostVersion != "" {
+		cmp, err := version.Compare(host.Version, m.MinHostVersion)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("cannot compare host version: %v", err))
+		} else if cmp < 0 {
+			return nil, &IncompatibleError{Plugin: name, Reason: fmt.Sprintf("requires greeter %s or later, this is %s", m.MinHostVersion, host.Version)}
+		}
+	}
+
+	if m.ProtocolVersion != 0 {
+		supported := false
+		for _, v := range host.ProtocolVersions {
+			if v == m.ProtocolVersion {
+				supported = true
+			}
+		}
+		if !supported {
+			return nil, &IncompatibleError{Plugin: name, Reason: fmt.Sprintf("speaks protocol version %d, this greeter supports %v", m.ProtocolVersion, host.ProtocolVersions)}
+		}
+	}
+
+	return warnings, nil
+}
+
+// Supports reports whether the manifest declares a greeting kind. Manifests
+// that do not list their greetings support every kind.
+func (m *Manifest) Supports(kind string) bool {
+	if len(m.Greetings) == 0 {
+		return true
+	}
+	for _, k := range m.Greetings {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePlugin writes a plugin binary and its manifest, if not empty, and
+// returns the path of the binary
+func writePlugin(t *testing.T, manifest string) string {
+	t.Helper()
+	binary := filepath.Join(t.TempDir(), "hindi")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\necho namaste\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if manifest != "" {
+		if err := os.WriteFile(Path(binary), []byte(manifest), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return binary
+}
+
+func TestLoad(t *testing.T) {
+	m, err := Load(writePlugin(t, ""))
+	if m != nil || err != nil {
+		t.Errorf("without manifest: got %v, %v, want nil, nil", m, err)
+	}
+
+	binary := writePlugin(t, `{"name": "hindi", "version": "1.0.0", "protocol_version": 1, "greetings": ["hello"]}`)
+	m, err = Load(binary)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.Name != "hindi" || m.ProtocolVersion != 1 || m.Path != Path(binary) {
+		t.Errorf("got %+v", m)
+	}
+	if !m.Supports("hello") || m.Supports("goodnight") {
+		t.Errorf("Supports does not follow greetings %v", m.Greetings)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     string
+	}{
+		{"not JSON", `{"name": "hindi"`, "unexpected EOF"},
+		{"unknown field", `{"name": "hindi", "protocol": 1}`, `unknown field "protocol"`},
+		{"no name", `{"version": "1.0.0"}`, "name is required"},
+		{"checksum algorithm", `{"name": "hindi", "checksum": "md5:d41d8cd98f00b204"}`, "checksum must be sha256:"},
+		{"min_host_version", `{"name": "hindi", "min_host_version": "one"}`, "min_host_version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			binary := writePlugin(t, tt.manifest)
+			_, err := Load(binary)
+			if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), Path(binary)) {
+				t.Errorf("got %v, want an error about %s containing %q", err, Path(binary), tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	host := Host{Version: "1.2.0", ProtocolVersions: []int{1}}
+	binary := writePlugin(t, "")
+	sum, err := Checksum(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		manifest Manifest
+		host     Host
+		// warnings is the number of warnings expected
+		warnings int
+		// invalid and incompatible are part of the *InvalidError or
+		// *IncompatibleError expected
+		invalid      string
+		incompatible string
+	}{
+		{name: "valid", manifest: Manifest{Name: "hindi", Checksum: sum, ProtocolVersion: 1, MinHostVersion: "1.2"}},
+		{name: "no checksum", manifest: Manifest{Name: "hindi"}, warnings: 1},
+		{name: "other plugin", manifest: Manifest{Name: "japanese", Checksum: sum}, invalid: `manifest is for plugin "japanese"`},
+		{name: "checksum mismatch", manifest: Manifest{Name: "hindi", Checksum: "sha256:9f86d081884c7d65"}, invalid: "checksum mismatch: binary is " + sum},
+		{name: "newer host required", manifest: Manifest{Name: "hindi", Checksum: sum, MinHostVersion: "1.10.0"}, incompatible: "requires greeter 1.10.0 or later, this is 1.2.0"},
+		{name: "older host required", manifest: Manifest{Name: "hindi", Checksum: sum, MinHostVersion: "v1.1.9"}},
+		{name: "development host", manifest: Manifest{Name: "hindi", Checksum: sum, MinHostVersion: "1.0"}, host: Host{Version: "dev", ProtocolVersions: []int{1}}, warnings: 1},
+		{name: "other protocol", manifest: Manifest{Name: "hindi", Checksum: sum, ProtocolVersion: 2}, incompatible: "speaks protocol version 2, this greeter supports [1]"},
+		{name: "newer protocol supported", manifest: Manifest{Name: "hindi", Checksum: sum, ProtocolVersion: 2}, host: Host{Version: "2.0.0", ProtocolVersions: []int{1, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.host.Version == "" {
+				tt.host = host
+			}
+			tt.manifest.Path = Path(binary)
+			warnings, err := tt.manifest.Check("hindi", binary, tt.host)
+
+			var invalid *InvalidError
+			var incompatible *IncompatibleError
+			switch {
+			case tt.invalid != "":
+				if !errors.As(err, &invalid) || !strings.Contains(err.Error(), tt.invalid) {
+					t.Errorf("got %v, want an *InvalidError containing %q", err, tt.invalid)
+				}
+			case tt.incompatible != "":
+				if !errors.As(err, &incompatible) || !strings.Contains(err.Error(), tt.incompatible) {
+					t.Errorf("got %v, want an *IncompatibleError containing %q", err, tt.incompatible)
+				}
+			case err != nil:
+				t.Errorf("Check: %v", err)
+			case len(warnings) != tt.warnings:
+				t.Errorf("warnings = %q, want %d", warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestCheckTamperedBinary(t *testing.T) {
+	binary := writePlugin(t, "")
+	sum, err := Checksum(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Manifest{Name: "hindi", Checksum: sum, Path: Path(binary)}
+
+	f, err := os.OpenFile(binary, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("echo tampered\n")
+	f.Close()
+
+	var invalid *InvalidError
+	if _, err := m.Check("hindi", binary, Host{Version: "1.0.0"}); !errors.As(err, &invalid) {
+		t.Errorf("got %v, want the tampered binary rejected", err)
+	}
+}
+
+func TestVerifyFile(t *testing.T) {
+	binary := writePlugin(t, "")
+	sum, err := Checksum(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// The open file is checked, whatever its path points to by now
+	swapped := binary + ".new"
+	if err := os.WriteFile(swapped, []byte("#!/bin/sh\necho swapped\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(swapped, binary); err != nil {
+		t.Fatal(err)
+	}
+	var invalid *InvalidError
+	if _, err := (&Manifest{Name: "hindi", Checksum: sum}).Check("hindi", binary, Host{Version: "1.0.0"}); !errors.As(err, &invalid) {
+		t.Errorf("Check: got %v, want the swapped binary rejected", err)
+	}
+	for i := 0; i < 2; i++ {
+		// Reading the file does not move on its offset
+		if err := (&Manifest{Name: "hindi", Checksum: sum}).VerifyFile("hindi", f); err != nil {
+			t.Errorf("VerifyFile #%d: %v", i+1, err)
+		}
+	}
+	if err := (&Manifest{Name: "hindi", Checksum: "sha256:00"}).VerifyFile("hindi", f); !errors.As(err, &invalid) {
+		t.Errorf("got %v, want a checksum mismatch", err)
+	}
+	if err := (&Manifest{Name: "hindi"}).VerifyFile("hindi", f); err != nil {
+		t.Errorf("without checksum: %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package plugin
+
+import (
+	"slices"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// defaultScaleDownAfter is how long an instance beyond the minimum size of
+// its pool may stay unused, unless the plugin has its own setting
+const defaultScaleDownAfter = 30 * time.Second
+
+// pool holds the running instances of a plugin. Its fields are guarded by
+// the mutex of the manager, except calls.
+type pool struct {
+	category string
+	name     string
+
+	instances []*PluginInstance
+	// starting is the number of instances being added, see addInstance,
+	// launch the start of the first instance, see StartPlugin
+	starting int
+	launch   *launch
+
+	calls      atomic.Uint64
+	queued     uint64
+	scaleUps   uint64
+	scaleDowns uint64
+}
+
+// launch is the start of the first instance of a pool
+type launch struct {
+	// done is closed once the instance started, or failed to with err
+	done chan struct{}
+	err  error
+}
+
+// PoolStats describes the instances of a running plugin
+type PoolStats struct {
+	Category string
+	Name     string
+	// Instances are the running instances, Starting the ones being added
+	Instances int
+	Starting  int
+	// Busy is the number of instances serving calls, InFlight the number of calls in progress
+	Busy     int
+	InFlight int
+	// Min and Max bound the number of instances
+	Min int
+	Max int
+	// Calls is the number of calls served, Queued the number of calls that found every instance busy
+	Calls  uint64
+	Queued uint64
+	// ScaleUps and ScaleDowns count the instances added under load and stopped for being unused
+	ScaleUps   uint64
+	ScaleDowns uint64
+}
+
+// poolLimits returns the minimum and maximum number of instances of a plugin
+func (pm *PluginManager) poolLimits(name string) (int, int) {
+	cfg := pm.configs[name]
+	minSize := max(cfg.MinInstances, 1)
+	return minSize, max(cfg.MaxInstances, minSize)
+}
+
+// pick returns the instance of a plugin with the fewest calls in progress,
+// nil if the plugin is not running. When every instance is busy, one more
+// is added unless the pool reached its maximum size.
+func (pm *PluginManager) pick(pluginKey string) *PluginInstance {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	p, exists := pm.pools[pluginKey]
+	if !exists || len(p.instances) == 0 {
+		return nil
+	}
+
+	best := p.instances[0]
+	for _, instance := range p.instances[1:] {
+		if instance.inFlight.Load() < best.inFlight.Load() {
+			best = instance
+		}
+	}
+
+	// The call about to be made counts as a use, so that the idle reaper
+	// does not stop the instance before it begins
+	best.lastUsed.Store(time.Now().UnixNano())
+
+	if best.Busy() {
+		// The call queues behind another one
+		p.queued++
+		if _, maxSize := pm.poolLimits(p.name); len(p.instances)+p.starting < maxSize {
+			pm.startInstance(p, "every instance is busy")
+			p.scaleUps++
+		}
+	}
+	return best
+}
+
+// fillPool adds instances to a pool until it reaches its minimum size. The caller holds mutex.
+func (pm *PluginManager) fillPool(p *pool) {
+	minSize, _ := pm.poolLimits(p.name)
+	for i := len(p.instances) + p.starting; i < minSize; i++ {
+		pm.startInstance(p, "below the minimum pool size")
+	}
+}
+
+// startInstance adds an instance to a pool in the background. The caller holds mutex.
+func (pm *PluginManager) startInstance(p *pool, reason string) {
+	p.starting++
+	go pm.addInstance(p, reason)
+}
+
+// addInstance starts an instance of a plugin and adds it to its pool,
+// unless the plugin was stopped meanwhile
+func (pm *PluginManager) addInstance(p *pool, reason string) {
+	pluginLogger := pm.logger.WithField("plugin", p.name)
+	pluginLogger.Debugf("Adding an instance: %s", reason)
+
+	pm.mutex.RLock()
+	pluginConfig := pm.configs[p.name]
+	limiter := pm.newLimits(p.category, p.name)
+	pm.mutex.RUnlock()
+
+	var instance *PluginInstance
+	execPath, dir, pluginManifest, err := pm.prepare(p.category, p.name)
+	if err == nil {
+		err = pm.checkQuarantine(p.category, p.name, pluginConfig.Restart.withDefaults())
+	}
+	if err == nil {
+		instance, err = pm.spawn(p.category, p.name, execPath, dir, pluginManifest, pluginConfig, limiter)
+	}
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	p.starting--
+	if err != nil {
+		pluginLogger.Warnf("Failed to add an instance: %v", err)
+		pm.forgetIfEmpty(p)
+		return
+	}
+	if pm.pools[p.category+"-"+p.name] != p {
+		// The plugin was stopped while the instance started
+		pm.discard(instance)
+		return
+	}
+
+	pm.register(p, instance)
+	_, maxSize := pm.poolLimits(p.name)
+	instance.Logger.Infof("Added instance %d of at most %d: %s", len(p.instances), maxSize, reason)
+}
+
+// removeInstance removes an exited instance from its pool, forgetting the
+// pool once it is empty. The caller holds mutex.
+func (pm *PluginManager) removeInstance(instance *PluginInstance) {
+	p := instance.pool
+	p.instances = slices.DeleteFunc(p.instances, func(i *PluginInstance) bool { return i == instance })
+	pm.forgetIfEmpty(p)
+}
+
+// forgetIfEmpty forgets a pool left without instances, running or starting. The caller holds mutex.
+func (pm *PluginManager) forgetIfEmpty(p *pool) {
+	pluginKey := p.category + "-" + p.name
+	if len(p.instances) == 0 && p.starting == 0 && pm.pools[pluginKey] == p {
+		delete(pm.pools, pluginKey)
+	}
+}
+
+// discard stops an instance started for a plugin that was stopped meanwhile. The caller holds mutex.
+func (pm *PluginManager) discard(instance *PluginInstance) {
+	pm.terminate(instance)
+	go func() {
+		instance.Command.Wait()
+		instance.limits.Release()
+	}()
+}
+
+// scaleDownAfter returns how long an instance may stay unused before it is
+// stopped to shrink its pool, 0 if the pool is not above its minimum size
+func (pm *PluginManager) scaleDownAfter(instance *PluginInstance) time.Duration {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	if minSize, _ := pm.poolLimits(instance.Name); len(instance.pool.instances) <= minSize {
+		return 0
+	}
+	if after := pm.configs[instance.Name].ScaleDownAfter; after > 0 {
+		return after
+	}
+	return defaultScaleDownAfter
+}
+
+// shrinkPool stops an instance if its pool is above its minimum size,
+// reporting whether it did
+func (pm *PluginManager) shrinkPool(instance *PluginInstance) bool {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	p := instance.pool
+	if minSize, _ := pm.poolLimits(instance.Name); len(p.instances) <= minSize || !slices.Contains(p.instances, instance) {
+		return false
+	}
+
+	pm.removeInstance(instance)
+	p.scaleDowns++
+	pm.terminate(instance)
+	return true
+}
+
+// PoolStats returns the statistics of the pools of the running plugins, sorted by plugin
+func (pm *PluginManager) PoolStats() []PoolStats {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	stats := make([]PoolStats, 0, len(pm.pools))
+	for _, p := range pm.pools {
+		minSize, maxSize := pm.poolLimits(p.name)
+		s := PoolStats{
+			Category:   p.category,
+			Name:       p.name,
+			Instances:  len(p.instances),
+			Starting:   p.starting,
+			Min:        minSize,
+			Max:        maxSize,
+			Calls:      p.calls.Load(),
+			Queued:     p.queued,
+			ScaleUps:   p.scaleUps,
+			ScaleDowns: p.scaleDowns,
+		}
+		for _, instance := range p.instances {
+			if n := int(instance.inFlight.Load()); n > 0 {
+				s.Busy++
+				s.InFlight += n
+			}
+		}
+		stats = append(stats, s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Category+"/"+stats[i].Name < stats[j].Category+"/"+stats[j].Name
+	})
+	return stats
+}
@@ -0,0 +1,213 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin/searchpath"
+)
+
+func TestPoolLimits(t *testing.T) {
+	tests := []struct {
+		cfg      PluginConfig
+		min, max int
+	}{
+		{PluginConfig{}, 1, 1},
+		{PluginConfig{MaxInstances: 4}, 1, 4},
+		{PluginConfig{MinInstances: 2}, 2, 2},
+		{PluginConfig{MinInstances: 2, MaxInstances: 3}, 2, 3},
+		{PluginConfig{MinInstances: 3, MaxInstances: 2}, 3, 3},
+	}
+	for _, tt := range tests {
+		logger, _ := logtest.NewNullLogger()
+		pm := NewPluginManager(logger, searchpath.Path{})
+		pm.SetPluginConfig("fake", tt.cfg)
+		if minSize, maxSize := pm.poolLimits("fake"); minSize != tt.min || maxSize != tt.max {
+			t.Errorf("%+v: got %d, %d, want %d, %d", tt.cfg, minSize, maxSize, tt.min, tt.max)
+		}
+	}
+}
+
+// poolStats returns the statistics of the pool of the "fake" plugin
+func poolStats(t *testing.T, pm *PluginManager) PoolStats {
+	t.Helper()
+	for _, s := range pm.PoolStats() {
+		if s.Name == "fake" {
+			return s
+		}
+	}
+	t.Fatal("the plugin is not running")
+	return PoolStats{}
+}
+
+func TestPoolPicksLeastBusy(t *testing.T) {
+	pm, _, _ := newTestManager(t, PluginConfig{MinInstances: 2})
+	if err := pm.StartPlugin("lang", "fake"); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the pool to fill", func() bool { return len(instances(pm)) == 2 })
+	running := instances(pm)
+
+	running[0].begin()
+	running[0].begin()
+	running[1].begin()
+	if got := pm.pick("lang-fake"); got != running[1] {
+		t.Errorf("picked the instance with %d calls in progress, want the one with 1", got.inFlight.Load())
+	}
+
+	// At its maximum size, the pool queues calls rather than growing
+	s := poolStats(t, pm)
+	if s.Queued != 1 || s.ScaleUps != 0 || s.Busy != 2 || s.InFlight != 3 {
+		t.Errorf("got %+v, want 1 call queued on 2 busy instances", s)
+	}
+	running[1].end()
+	if got := pm.pick("lang-fake"); got != running[1] {
+		t.Error("did not pick the idle instance")
+	}
+	if s := poolStats(t, pm); s.Queued != 1 {
+		t.Errorf("queued = %d, want 1", s.Queued)
+	}
+	running[0].end()
+	running[0].end()
+}
+
+func TestPoolScalesUp(t *testing.T) {
+	pm, _, _ := newTestManager(t, PluginConfig{MaxInstances: 3})
+	if err := pm.StartPlugin("lang", "fake"); err != nil {
+		t.Fatal(err)
+	}
+	first := instances(pm)[0]
+	first.begin()
+	defer first.end()
+
+	// Instances being started count towards the maximum size
+	for i := 0; i < 5; i++ {
+		if got := pm.pick("lang-fake"); got != first {
+			t.Fatal("picked an instance still starting")
+		}
+	}
+	eventually(t, "the pool to grow", func() bool { return len(instances(pm)) == 3 })
+
+	s := poolStats(t, pm)
+	if s.Instances != 3 || s.Starting != 0 || s.ScaleUps != 2 || s.Queued != 5 {
+		t.Errorf("got %+v, want 2 instances added for 5 calls queued", s)
+	}
+	for _, instance := range instances(pm)[1:] {
+		if got := pm.pick("lang-fake"); got == first {
+			t.Error("picked the busy instance")
+		}
+		instance.begin()
+		defer instance.end()
+	}
+}
+
+func TestPoolServesConcurrentCalls(t *testing.T) {
+	pm, _, _ := newTestManager(t, PluginConfig{MinInstances: 3})
+	if err := pm.StartPlugin("lang", "fake"); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the pool to fill", func() bool { return len(instances(pm)) == 3 })
+
+	type result struct {
+		text string
+		err  error
+	}
+	results := make(chan result)
+	for i := 0; i < 3; i++ {
+		go func() {
+			g, err := pm.GetGreeting(context.Background(), "lang", "fake", greetings.Request{Kind: "slow"})
+			results <- result{g.Text, err}
+		}()
+		// Every call begins before the next one picks an instance
+		eventually(t, "the call to begin", func() bool { return poolStats(t, pm).InFlight == i+1 })
+	}
+
+	served := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		r := <-results
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+		served[r.text] = true
+	}
+	if len(served) != 3 {
+		t.Errorf("calls served by %d instances, want 3", len(served))
+	}
+	if s := poolStats(t, pm); s.Calls != 3 || s.Queued != 0 {
+		t.Errorf("got %+v, want 3 calls and none queued", s)
+	}
+}
+
+func TestPoolShrinks(t *testing.T) {
+	pm, _, _ := newTestManager(t, PluginConfig{MinInstances: 3, ScaleDownAfter: time.Hour})
+	if err := pm.StartPlugin("lang", "fake"); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the pool to fill", func() bool { return len(instances(pm)) == 3 })
+	running := instances(pm)
+
+	// The pool is at its minimum size
+	if after := pm.scaleDownAfter(running[0]); after != 0 {
+		t.Errorf("scale down after %s, want never", after)
+	}
+	if pm.shrinkPool(running[0]) {
+		t.Error("shrank the pool below its minimum size")
+	}
+
+	pm.SetPluginConfig("fake", PluginConfig{MinInstances: 1, ScaleDownAfter: time.Hour})
+	if after := pm.scaleDownAfter(running[0]); after != time.Hour {
+		t.Errorf("scale down after %s, want 1h", after)
+	}
+	for _, instance := range running[:2] {
+		if !pm.shrinkPool(instance) {
+			t.Error("did not shrink the pool")
+		}
+	}
+	if pm.shrinkPool(running[0]) || pm.shrinkPool(running[2]) {
+		t.Error("shrank the pool twice for an instance, or below its minimum size")
+	}
+	eventually(t, "the instances to stop", func() bool { return len(instances(pm)) == 1 })
+	if s := poolStats(t, pm); s.ScaleDowns != 2 {
+		t.Errorf("scale downs = %d, want 2", s.ScaleDowns)
+	}
+}
+
+func TestPoolScaleDownDefault(t *testing.T) {
+	pm, _, _ := newTestManager(t, PluginConfig{MinInstances: 2})
+	if err := pm.StartPlugin("lang", "fake"); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the pool to fill", func() bool { return len(instances(pm)) == 2 })
+
+	pm.SetPluginConfig("fake", PluginConfig{})
+	if after := pm.scaleDownAfter(instances(pm)[0]); after != defaultScaleDownAfter {
+		t.Errorf("scale down after %s, want %s", after, defaultScaleDownAfter)
+	}
+}
+
+func TestReaperScalesDown(t *testing.T) {
+	pm, _, _ := newTestManager(t, PluginConfig{MaxInstances: 2, ScaleDownAfter: 100 * time.Millisecond})
+	if err := pm.StartPlugin("lang", "fake"); err != nil {
+		t.Fatal(err)
+	}
+	first := instances(pm)[0]
+	first.begin()
+	pm.pick("lang-fake")
+	eventually(t, "the pool to grow", func() bool { return len(instances(pm)) == 2 })
+
+	// The instance added goes once unused, the busy one stays
+	eventually(t, "the pool to shrink", func() bool { return len(instances(pm)) == 1 })
+	if instances(pm)[0] != first {
+		t.Error("stopped the busy instance")
+	}
+	first.end()
+
+	// The pool does not shrink below its minimum size, and no idle timeout stops it
+	time.Sleep(minIdleCheck + 500*time.Millisecond)
+	if len(instances(pm)) != 1 {
+		t.Error("the last instance was stopped")
+	}
+}
@@ -0,0 +1,122 @@
+package proto
+
+import "github.com/unsuman/greeter/pkg/greetings"
+
+// Conversions between the messages and their greetings counterparts, shared
+// by the plugin adapter, the plugin client and greeterd.
+
+// FromRequest converts a greeting request into its message
+func FromRequest(req greetings.Request) *GreetingRequest {
+	return &GreetingRequest{
+		Kind:      req.Kind,
+		Recipient: req.Recipient,
+		Formality: Formality(req.Formality),
+	}
+}
+
+// ToRequest converts a greeting request message into its greetings counterpart
+func ToRequest(req *GreetingRequest) greetings.Request {
+	return greetings.Request{
+		Kind:      req.GetKind(),
+		Recipient: req.GetRecipient(),
+		Formality: greetings.Formality(req.GetFormality()),
+	}
+}
+
+// FromGreeting converts a greeting into its message
+func FromGreeting(greeting greetings.Greeting) *GreetingResponse {
+	return &GreetingResponse{
+		Message:       greeting.String(),
+		Text:          greeting.Text,
+		Script:        greeting.Script,
+		Romanization:  greeting.Romanization,
+		Pronunciation: greeting.Pronunciation,
+		Meaning:       greeting.Meaning,
+	}
+}
+
+// ToGreeting converts a greeting message into its greetings counterpart
+func ToGreeting(response *GreetingResponse) greetings.Greeting {
+	greeting := greetings.Greeting{
+		Text:          response.GetText(),
+		Script:        response.GetScript(),
+		Romanization:  response.GetRomanization(),
+		Pronunciation: response.GetPronunciation(),
+		Meaning:       response.GetMeaning(),
+	}
+	if greeting.Text == "" {
+		// Plugins that only fill the formatted message
+		greeting.Text = response.GetMessage()
+	}
+	return greeting
+}
+
+// FromVariants converts greeting variants into their message
+func FromVariants(variants []greetings.Variant) *VariantList {
+	list := &VariantList{}
+	for _, v := range variants {
+		list.Variants = append(list.Variants, &Variant{Greeting: FromGreeting(v.Greeting), Weight: int32(v.Weight)})
+	}
+	return list
+}
+
+// ToVariants converts a variant list message into greeting variants
+func ToVariants(list *VariantList) []greetings.Variant {
+	var variants []greetings.Variant
+	for _, v := range list.GetVariants() {
+		variants = append(variants, greetings.Variant{Greeting: ToGreeting(v.Greeting), Weight: int(v.Weight)})
+	}
+	return variants
+}
+
+// FromInfo converts plugin metadata into its message
+func FromInfo(info greetings.Info) *PluginInfo {
+	dayParts := make([]*DayPart, len(info.DayParts))
+	for i, part := range info.DayParts {
+		dayParts[i] = &DayPart{Start: int32(part.Start), Kind: part.Kind}
+	}
+
+	occasions := make([]*Occasion, len(info.Occasions))
+	for i, o := range info.Occasions {
+		occasions[i] = &Occasion{Kind: o.Kind, Name: o.Name, Rule: o.Rule, Days: int32(o.Days)}
+	}
+
+	return &PluginInfo{
+		Name:            info.Name,
+		DisplayName:     info.DisplayName,
+		Version:         info.Version,
+		Author:          info.Author,
+		Description:     info.Description,
+		Locale:          info.Locale,
+		Kinds:           info.Kinds,
+		ProtocolVersion: int32(info.ProtocolVersion),
+		DayParts:        dayParts,
+		Occasions:       occasions,
+	}
+}
+
+// ToInfo converts a plugin metadata message into its greetings counterpart
+func ToInfo(response *PluginInfo) *greetings.Info {
+	dayParts := make([]greetings.DayPart, len(response.DayParts))
+	for i, part := range response.DayParts {
+		dayParts[i] = greetings.DayPart{Start: int(part.Start), Kind: part.Kind}
+	}
+
+	occasions := make([]greetings.Occasion, len(response.Occasions))
+	for i, o := range response.Occasions {
+		occasions[i] = greetings.Occasion{Kind: o.Kind, Name: o.Name, Rule: o.Rule, Days: int(o.Days)}
+	}
+
+	return &greetings.Info{
+		Name:            response.Name,
+		DisplayName:     response.DisplayName,
+		Version:         response.Version,
+		Author:          response.Author,
+		Description:     response.Description,
+		Locale:          response.Locale,
+		Kinds:           response.Kinds,
+		ProtocolVersion: int(response.ProtocolVersion),
+		DayParts:        dayParts,
+		Occasions:       occasions,
+	}
+}
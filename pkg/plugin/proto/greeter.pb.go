@@ -21,6 +21,60 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Formality is the politeness register of a greeting. Plugins serve the
+// closest available register when the requested one is missing.
+type Formality int32
+
+const (
+	Formality_FORMALITY_NEUTRAL   Formality = 0
+	Formality_FORMALITY_CASUAL    Formality = 1
+	Formality_FORMALITY_FORMAL    Formality = 2
+	Formality_FORMALITY_HONORIFIC Formality = 3
+)
+
+// Enum value maps for Formality.
+var (
+	Formality_name = map[int32]string{
+		0: "FORMALITY_NEUTRAL",
+		1: "FORMALITY_CASUAL",
+		2: "FORMALITY_FORMAL",
+		3: "FORMALITY_HONORIFIC",
+	}
+	Formality_value = map[string]int32{
+		"FORMALITY_NEUTRAL":   0,
+		"FORMALITY_CASUAL":    1,
+		"FORMALITY_FORMAL":    2,
+		"FORMALITY_HONORIFIC": 3,
+	}
+)
+
+func (x Formality) Enum() *Formality {
+	p := new(Formality)
+	*p = x
+	return p
+}
+
+func (x Formality) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Formality) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_plugin_proto_greeter_proto_enumTypes[0].Descriptor()
+}
+
+func (Formality) Type() protoreflect.EnumType {
+	return &file_pkg_plugin_proto_greeter_proto_enumTypes[0]
+}
+
+func (x Formality) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Formality.Descriptor instead.
+func (Formality) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_plugin_proto_greeter_proto_rawDescGZIP(), []int{0}
+}
+
 // Empty request message
 type Empty struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -58,17 +112,92 @@ func (*Empty) Descriptor() ([]byte, []int) {
 	return file_pkg_plugin_proto_greeter_proto_rawDescGZIP(), []int{0}
 }
 
+// GreetingRequest carries the parameters of a single greeting
+type GreetingRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name of the person being greeted, empty for a generic greeting
+	Recipient string `protobuf:"bytes,1,opt,name=recipient,proto3" json:"recipient,omitempty"`
+	// Greeting kind, e.g. "hello" or "goodmorning"
+	Kind string `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	// Requested politeness register
+	Formality     Formality `protobuf:"varint,3,opt,name=formality,proto3,enum=greeter.Formality" json:"formality,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GreetingRequest) Reset() {
+	*x = GreetingRequest{}
+	mi := &file_pkg_plugin_proto_greeter_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GreetingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GreetingRequest) ProtoMessage() {}
+
+func (x *GreetingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_plugin_proto_greeter_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GreetingRequest.ProtoReflect.Descriptor instead.
+func (*GreetingRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_plugin_proto_greeter_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GreetingRequest) GetRecipient() string {
+	if x != nil {
+		return x.Recipient
+	}
+	return ""
+}
+
+func (x *GreetingRequest) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *GreetingRequest) GetFormality() Formality {
+	if x != nil {
+		return x.Formality
+	}
+	return Formality_FORMALITY_NEUTRAL
+}
+
 // GreetingResponse contains the greeting message
 type GreetingResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Greeting formatted for display: native text followed by its romanization
+	Message string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// Greeting in the language's native script
+	Text string `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	// ISO 15924 code of the script of text, e.g. "Deva" or "Jpan"
+	Script string `protobuf:"bytes,3,opt,name=script,proto3" json:"script,omitempty"`
+	// Text transcribed into the Latin alphabet, empty for Latin scripts
+	Romanization string `protobuf:"bytes,4,opt,name=romanization,proto3" json:"romanization,omitempty"`
+	// IPA or respelling hint for saying the greeting
+	Pronunciation string `protobuf:"bytes,5,opt,name=pronunciation,proto3" json:"pronunciation,omitempty"`
+	// Literal English meaning of the greeting
+	Meaning       string `protobuf:"bytes,6,opt,name=meaning,proto3" json:"meaning,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GreetingResponse) Reset() {
 	*x = GreetingResponse{}
-	mi := &file_pkg_plugin_proto_greeter_proto_msgTypes[1]
+	mi := &file_pkg_plugin_proto_greeter_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -80,7 +209,7 @@ func (x *GreetingResponse) String() string {
 func (*GreetingResponse) ProtoMessage() {}
 
 func (x *GreetingResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_plugin_proto_greeter_proto_msgTypes[1]
+	mi := &file_pkg_plugin_proto_greeter_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -93,7 +222,7 @@ func (x *GreetingResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GreetingResponse.ProtoReflect.Descriptor instead.
 func (*GreetingResponse) Descriptor() ([]byte, []int) {
-	return file_pkg_plugin_proto_greeter_proto_rawDescGZIP(), []int{1}
+	return file_pkg_plugin_proto_greeter_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *GreetingResponse) GetMessage() string {
@@ -103,20 +232,489 @@ func (x *GreetingResponse) GetMessage() string {
 	return ""
 }
 
+func (x *GreetingResponse) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *GreetingResponse) GetScript() string {
+	if x != nil {
+		return x.Script
+	}
+	return ""
+}
+
+func (x *GreetingResponse) GetRomanization() string {
+	if x != nil {
+		return x.Romanization
+	}
+	return ""
+}
+
+func (x *GreetingResponse) GetPronunciation() string {
+	if x != nil {
+		return x.Pronunciation
+	}
+	return ""
+}
+
+func (x *GreetingResponse) GetMeaning() string {
+	if x != nil {
+		return x.Meaning
+	}
+	return ""
+}
+
+// Variant is one of several ways of saying a greeting
+type Variant struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Greeting *GreetingResponse      `protobuf:"bytes,1,opt,name=greeting,proto3" json:"greeting,omitempty"`
+	// Relative frequency of the variant, values below 1 count as 1
+	Weight        int32 `protobuf:"varint,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Variant) Reset() {
+	*x = Variant{}
+	mi := &file_pkg_plugin_proto_greeter_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Variant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Variant) ProtoMessage() {}
+
+func (x *Variant) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_plugin_proto_greeter_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Variant.ProtoReflect.Descriptor instead.
+func (*Variant) Descriptor() ([]byte, []int) {
+	return file_pkg_plugin_proto_greeter_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Variant) GetGreeting() *GreetingResponse {
+	if x != nil {
+		return x.Greeting
+	}
+	return nil
+}
+
+func (x *Variant) GetWeight() int32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+// VariantList contains the variants of a greeting
+type VariantList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Variants      []*Variant             `protobuf:"bytes,1,rep,name=variants,proto3" json:"variants,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VariantList) Reset() {
+	*x = VariantList{}
+	mi := &file_pkg_plugin_proto_greeter_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VariantList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VariantList) ProtoMessage() {}
+
+func (x *VariantList) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_plugin_proto_greeter_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VariantList.ProtoReflect.Descriptor instead.
+func (*VariantList) Descriptor() ([]byte, []int) {
+	return file_pkg_plugin_proto_greeter_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *VariantList) GetVariants() []*Variant {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+// KindList contains the greeting kinds supported by a plugin
+type KindList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kinds         []string               `protobuf:"bytes,1,rep,name=kinds,proto3" json:"kinds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KindList) Reset() {
+	*x = KindList{}
+	mi := &file_pkg_plugin_proto_greeter_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KindList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KindList) ProtoMessage() {}
+
+func (x *KindList) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_plugin_proto_greeter_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KindList.ProtoReflect.Descriptor instead.
+func (*KindList) Descriptor() ([]byte, []int) {
+	return file_pkg_plugin_proto_greeter_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *KindList) GetKinds() []string {
+	if x != nil {
+		return x.Kinds
+	}
+	return nil
+}
+
+// PluginInfo describes a language plugin
+type PluginInfo struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Name        string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	DisplayName string                 `protobuf:"bytes,2,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Version     string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	Author      string                 `protobuf:"bytes,4,opt,name=author,proto3" json:"author,omitempty"`
+	Description string                 `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	// Greeting kinds supported by the plugin
+	Kinds []string `protobuf:"bytes,6,rep,name=kinds,proto3" json:"kinds,omitempty"`
+	// Plugin protocol version spoken by the plugin
+	ProtocolVersion int32 `protobuf:"varint,7,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	// Greeting kinds used at the different times of day
+	DayParts []*DayPart `protobuf:"bytes,8,rep,name=day_parts,json=dayParts,proto3" json:"day_parts,omitempty"`
+	// BCP 47 tag of the language, e.g. "hi" or "pt-BR"
+	Locale string `protobuf:"bytes,9,opt,name=locale,proto3" json:"locale,omitempty"`
+	// Festivals and holidays the plugin has greetings for
+	Occasions     []*Occasion `protobuf:"bytes,10,rep,name=occasions,proto3" json:"occasions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PluginInfo) Reset() {
+	*x = PluginInfo{}
+	mi := &file_pkg_plugin_proto_greeter_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PluginInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PluginInfo) ProtoMessage() {}
+
+func (x *PluginInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_plugin_proto_greeter_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PluginInfo.ProtoReflect.Descriptor instead.
+func (*PluginInfo) Descriptor() ([]byte, []int) {
+	return file_pkg_plugin_proto_greeter_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PluginInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PluginInfo) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *PluginInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *PluginInfo) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *PluginInfo) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *PluginInfo) GetKinds() []string {
+	if x != nil {
+		return x.Kinds
+	}
+	return nil
+}
+
+func (x *PluginInfo) GetProtocolVersion() int32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *PluginInfo) GetDayParts() []*DayPart {
+	if x != nil {
+		return x.DayParts
+	}
+	return nil
+}
+
+func (x *PluginInfo) GetLocale() string {
+	if x != nil {
+		return x.Locale
+	}
+	return ""
+}
+
+func (x *PluginInfo) GetOccasions() []*Occasion {
+	if x != nil {
+		return x.Occasions
+	}
+	return nil
+}
+
+// Occasion is a festival or holiday with a greeting of its own
+type Occasion struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Greeting kind used on the occasion, e.g. "diwali"
+	Kind string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	// Display name, e.g. "Diwali"
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Date rule, e.g. "12-25" or "new moon before 11-16, shifted -19h, in Asia/Kolkata"
+	Rule string `protobuf:"bytes,3,opt,name=rule,proto3" json:"rule,omitempty"`
+	// Number of days the occasion lasts, 1 when 0
+	Days          int32 `protobuf:"varint,4,opt,name=days,proto3" json:"days,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Occasion) Reset() {
+	*x = Occasion{}
+	mi := &file_pkg_plugin_proto_greeter_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Occasion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Occasion) ProtoMessage() {}
+
+func (x *Occasion) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_plugin_proto_greeter_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Occasion.ProtoReflect.Descriptor instead.
+func (*Occasion) Descriptor() ([]byte, []int) {
+	return file_pkg_plugin_proto_greeter_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Occasion) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Occasion) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Occasion) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *Occasion) GetDays() int32 {
+	if x != nil {
+		return x.Days
+	}
+	return 0
+}
+
+// DayPart starts a window of the day during which a greeting kind is used
+type DayPart struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Start of the window in minutes after midnight
+	Start         int32  `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	Kind          string `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DayPart) Reset() {
+	*x = DayPart{}
+	mi := &file_pkg_plugin_proto_greeter_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DayPart) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DayPart) ProtoMessage() {}
+
+func (x *DayPart) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_plugin_proto_greeter_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DayPart.ProtoReflect.Descriptor instead.
+func (*DayPart) Descriptor() ([]byte, []int) {
+	return file_pkg_plugin_proto_greeter_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DayPart) GetStart() int32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *DayPart) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
 var File_pkg_plugin_proto_greeter_proto protoreflect.FileDescriptor
 
 const file_pkg_plugin_proto_greeter_proto_rawDesc = "" +
 	"\n" +
 	"\x1epkg/plugin/proto/greeter.proto\x12\agreeter\"\a\n" +
-	"\x05Empty\",\n" +
+	"\x05Empty\"u\n" +
+	"\x0fGreetingRequest\x12\x1c\n" +
+	"\trecipient\x18\x01 \x01(\tR\trecipient\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x120\n" +
+	"\tformality\x18\x03 \x01(\x0e2\x12.greeter.FormalityR\tformality\"\xbc\x01\n" +
 	"\x10GreetingResponse\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage2\xa8\x02\n" +
-	"\x0eGreeterService\x122\n" +
-	"\x05Hello\x12\x0e.greeter.Empty\x1a\x19.greeter.GreetingResponse\x128\n" +
-	"\vGoodMorning\x12\x0e.greeter.Empty\x1a\x19.greeter.GreetingResponse\x12:\n" +
-	"\rGoodAfternoon\x12\x0e.greeter.Empty\x1a\x19.greeter.GreetingResponse\x126\n" +
-	"\tGoodNight\x12\x0e.greeter.Empty\x1a\x19.greeter.GreetingResponse\x124\n" +
-	"\aGoodBye\x12\x0e.greeter.Empty\x1a\x19.greeter.GreetingResponseB-Z+github.com/unsuman/greeter/pkg/plugin/protob\x06proto3"
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12\x16\n" +
+	"\x06script\x18\x03 \x01(\tR\x06script\x12\"\n" +
+	"\fromanization\x18\x04 \x01(\tR\fromanization\x12$\n" +
+	"\rpronunciation\x18\x05 \x01(\tR\rpronunciation\x12\x18\n" +
+	"\ameaning\x18\x06 \x01(\tR\ameaning\"X\n" +
+	"\aVariant\x125\n" +
+	"\bgreeting\x18\x01 \x01(\v2\x19.greeter.GreetingResponseR\bgreeting\x12\x16\n" +
+	"\x06weight\x18\x02 \x01(\x05R\x06weight\";\n" +
+	"\vVariantList\x12,\n" +
+	"\bvariants\x18\x01 \x03(\v2\x10.greeter.VariantR\bvariants\" \n" +
+	"\bKindList\x12\x14\n" +
+	"\x05kinds\x18\x01 \x03(\tR\x05kinds\"\xd0\x02\n" +
+	"\n" +
+	"PluginInfo\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
+	"\fdisplay_name\x18\x02 \x01(\tR\vdisplayName\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\x12\x16\n" +
+	"\x06author\x18\x04 \x01(\tR\x06author\x12 \n" +
+	"\vdescription\x18\x05 \x01(\tR\vdescription\x12\x14\n" +
+	"\x05kinds\x18\x06 \x03(\tR\x05kinds\x12)\n" +
+	"\x10protocol_version\x18\a \x01(\x05R\x0fprotocolVersion\x12-\n" +
+	"\tday_parts\x18\b \x03(\v2\x10.greeter.DayPartR\bdayParts\x12\x16\n" +
+	"\x06locale\x18\t \x01(\tR\x06locale\x12/\n" +
+	"\toccasions\x18\n" +
+	" \x03(\v2\x11.greeter.OccasionR\toccasions\"Z\n" +
+	"\bOccasion\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04rule\x18\x03 \x01(\tR\x04rule\x12\x12\n" +
+	"\x04days\x18\x04 \x01(\x05R\x04days\"3\n" +
+	"\aDayPart\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x05R\x05start\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind*g\n" +
+	"\tFormality\x12\x15\n" +
+	"\x11FORMALITY_NEUTRAL\x10\x00\x12\x14\n" +
+	"\x10FORMALITY_CASUAL\x10\x01\x12\x14\n" +
+	"\x10FORMALITY_FORMAL\x10\x02\x12\x17\n" +
+	"\x13FORMALITY_HONORIFIC\x10\x032\xea\x01\n" +
+	"\x0eGreeterService\x12<\n" +
+	"\x05Greet\x12\x18.greeter.GreetingRequest\x1a\x19.greeter.GreetingResponse\x12:\n" +
+	"\bVariants\x12\x18.greeter.GreetingRequest\x1a\x14.greeter.VariantList\x12.\n" +
+	"\tListKinds\x12\x0e.greeter.Empty\x1a\x11.greeter.KindList\x12.\n" +
+	"\aGetInfo\x12\x0e.greeter.Empty\x1a\x13.greeter.PluginInfoB-Z+github.com/unsuman/greeter/pkg/plugin/protob\x06proto3"
 
 var (
 	file_pkg_plugin_proto_greeter_proto_rawDescOnce sync.Once
@@ -130,27 +728,39 @@ func file_pkg_plugin_proto_greeter_proto_rawDescGZIP() []byte {
 	return file_pkg_plugin_proto_greeter_proto_rawDescData
 }
 
-var file_pkg_plugin_proto_greeter_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_pkg_plugin_proto_greeter_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_pkg_plugin_proto_greeter_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_pkg_plugin_proto_greeter_proto_goTypes = []any{
-	(*Empty)(nil),            // 0: greeter.Empty
-	(*GreetingResponse)(nil), // 1: greeter.GreetingResponse
+	(Formality)(0),           // 0: greeter.Formality
+	(*Empty)(nil),            // 1: greeter.Empty
+	(*GreetingRequest)(nil),  // 2: greeter.GreetingRequest
+	(*GreetingResponse)(nil), // 3: greeter.GreetingResponse
+	(*Variant)(nil),          // 4: greeter.Variant
+	(*VariantList)(nil),      // 5: greeter.VariantList
+	(*KindList)(nil),         // 6: greeter.KindList
+	(*PluginInfo)(nil),       // 7: greeter.PluginInfo
+	(*Occasion)(nil),         // 8: greeter.Occasion
+	(*DayPart)(nil),          // 9: greeter.DayPart
 }
 var file_pkg_plugin_proto_greeter_proto_depIdxs = []int32{
-	0, // 0: greeter.GreeterService.Hello:input_type -> greeter.Empty
-	0, // 1: greeter.GreeterService.GoodMorning:input_type -> greeter.Empty
-	0, // 2: greeter.GreeterService.GoodAfternoon:input_type -> greeter.Empty
-	0, // 3: greeter.GreeterService.GoodNight:input_type -> greeter.Empty
-	0, // 4: greeter.GreeterService.GoodBye:input_type -> greeter.Empty
-	1, // 5: greeter.GreeterService.Hello:output_type -> greeter.GreetingResponse
-	1, // 6: greeter.GreeterService.GoodMorning:output_type -> greeter.GreetingResponse
-	1, // 7: greeter.GreeterService.GoodAfternoon:output_type -> greeter.GreetingResponse
-	1, // 8: greeter.GreeterService.GoodNight:output_type -> greeter.GreetingResponse
-	1, // 9: greeter.GreeterService.GoodBye:output_type -> greeter.GreetingResponse
-	5, // [5:10] is the sub-list for method output_type
-	0, // [0:5] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0, // 0: greeter.GreetingRequest.formality:type_name -> greeter.Formality
+	3, // 1: greeter.Variant.greeting:type_name -> greeter.GreetingResponse
+	4, // 2: greeter.VariantList.variants:type_name -> greeter.Variant
+	9, // 3: greeter.PluginInfo.day_parts:type_name -> greeter.DayPart
+	8, // 4: greeter.PluginInfo.occasions:type_name -> greeter.Occasion
+	2, // 5: greeter.GreeterService.Greet:input_type -> greeter.GreetingRequest
+	2, // 6: greeter.GreeterService.Variants:input_type -> greeter.GreetingRequest
+	1, // 7: greeter.GreeterService.ListKinds:input_type -> greeter.Empty
+	1, // 8: greeter.GreeterService.GetInfo:input_type -> greeter.Empty
+	3, // 9: greeter.GreeterService.Greet:output_type -> greeter.GreetingResponse
+	5, // 10: greeter.GreeterService.Variants:output_type -> greeter.VariantList
+	6, // 11: greeter.GreeterService.ListKinds:output_type -> greeter.KindList
+	7, // 12: greeter.GreeterService.GetInfo:output_type -> greeter.PluginInfo
+	9, // [9:13] is the sub-list for method output_type
+	5, // [5:9] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_pkg_plugin_proto_greeter_proto_init() }
@@ -163,13 +773,14 @@ func file_pkg_plugin_proto_greeter_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_plugin_proto_greeter_proto_rawDesc), len(file_pkg_plugin_proto_greeter_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   2,
+			NumEnums:      1,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_pkg_plugin_proto_greeter_proto_goTypes,
 		DependencyIndexes: file_pkg_plugin_proto_greeter_proto_depIdxs,
+		EnumInfos:         file_pkg_plugin_proto_greeter_proto_enumTypes,
 		MessageInfos:      file_pkg_plugin_proto_greeter_proto_msgTypes,
 	}.Build()
 	File_pkg_plugin_proto_greeter_proto = out.File
@@ -19,11 +19,10 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	GreeterService_Hello_FullMethodName         = "/greeter.GreeterService/Hello"
-	GreeterService_GoodMorning_FullMethodName   = "/greeter.GreeterService/GoodMorning"
-	GreeterService_GoodAfternoon_FullMethodName = "/greeter.GreeterService/GoodAfternoon"
-	GreeterService_GoodNight_FullMethodName     = "/greeter.GreeterService/GoodNight"
-	GreeterService_GoodBye_FullMethodName       = "/greeter.GreeterService/GoodBye"
+	GreeterService_Greet_FullMethodName     = "/greeter.GreeterService/Greet"
+	GreeterService_Variants_FullMethodName  = "/greeter.GreeterService/Variants"
+	GreeterService_ListKinds_FullMethodName = "/greeter.GreeterService/ListKinds"
+	GreeterService_GetInfo_FullMethodName   = "/greeter.GreeterService/GetInfo"
 )
 
 // GreeterServiceClient is the client API for GreeterService service.
@@ -32,11 +31,17 @@ const (
 //
 // GreeterService defines the interface for language-specific greeters
 type GreeterServiceClient interface {
-	Hello(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*GreetingResponse, error)
-	GoodMorning(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*GreetingResponse, error)
-	GoodAfternoon(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*GreetingResponse, error)
-	GoodNight(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*GreetingResponse, error)
-	GoodBye(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*GreetingResponse, error)
+	// Greet returns the greeting of the requested kind. Kinds the plugin does
+	// not provide are answered with codes.Unimplemented.
+	Greet(ctx context.Context, in *GreetingRequest, opts ...grpc.CallOption) (*GreetingResponse, error)
+	// Variants returns the ways of saying the requested greeting, starting
+	// with the one Greet returns. Hosts fall back to Greet for plugins that
+	// answer with codes.Unimplemented.
+	Variants(ctx context.Context, in *GreetingRequest, opts ...grpc.CallOption) (*VariantList, error)
+	// ListKinds returns the greeting kinds the plugin supports
+	ListKinds(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*KindList, error)
+	// GetInfo returns the plugin metadata
+	GetInfo(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PluginInfo, error)
 }
 
 type greeterServiceClient struct {
@@ -47,50 +52,40 @@ func NewGreeterServiceClient(cc grpc.ClientConnInterface) GreeterServiceClient {
 	return &greeterServiceClient{cc}
 }
 
-func (c *greeterServiceClient) Hello(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*GreetingResponse, error) {
+func (c *greeterServiceClient) Greet(ctx context.Context, in *GreetingRequest, opts ...grpc.CallOption) (*GreetingResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GreetingResponse)
-	err := c.cc.Invoke(ctx, GreeterService_Hello_FullMethodName, in, out, cOpts...)
+	err := c.cc.Invoke(ctx, GreeterService_Greet_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *greeterServiceClient) GoodMorning(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*GreetingResponse, error) {
+func (c *greeterServiceClient) Variants(ctx context.Context, in *GreetingRequest, opts ...grpc.CallOption) (*VariantList, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(GreetingResponse)
-	err := c.cc.Invoke(ctx, GreeterService_GoodMorning_FullMethodName, in, out, cOpts...)
+	out := new(VariantList)
+	err := c.cc.Invoke(ctx, GreeterService_Variants_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *greeterServiceClient) GoodAfternoon(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*GreetingResponse, error) {
+func (c *greeterServiceClient) ListKinds(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*KindList, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(GreetingResponse)
-	err := c.cc.Invoke(ctx, GreeterService_GoodAfternoon_FullMethodName, in, out, cOpts...)
+	out := new(KindList)
+	err := c.cc.Invoke(ctx, GreeterService_ListKinds_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *greeterServiceClient) GoodNight(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*GreetingResponse, error) {
+func (c *greeterServiceClient) GetInfo(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PluginInfo, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(GreetingResponse)
-	err := c.cc.Invoke(ctx, GreeterService_GoodNight_FullMethodName, in, out, cOpts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
-}
-
-func (c *greeterServiceClient) GoodBye(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*GreetingResponse, error) {
-	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(GreetingResponse)
-	err := c.cc.Invoke(ctx, GreeterService_GoodBye_FullMethodName, in, out, cOpts...)
+	out := new(PluginInfo)
+	err := c.cc.Invoke(ctx, GreeterService_GetInfo_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -103,11 +98,17 @@ func (c *greeterServiceClient) GoodBye(ctx context.Context, in *Empty, opts ...g
 //
 // GreeterService defines the interface for language-specific greeters
 type GreeterServiceServer interface {
-	Hello(context.Context, *Empty) (*GreetingResponse, error)
-	GoodMorning(context.Context, *Empty) (*GreetingResponse, error)
-	GoodAfternoon(context.Context, *Empty) (*GreetingResponse, error)
-	GoodNight(context.Context, *Empty) (*GreetingResponse, error)
-	GoodBye(context.Context, *Empty) (*GreetingResponse, error)
+	// Greet returns the greeting of the requested kind. Kinds the plugin does
+	// not provide are answered with codes.Unimplemented.
+	Greet(context.Context, *GreetingRequest) (*GreetingResponse, error)
+	// Variants returns the ways of saying the requested greeting, starting
+	// with the one Greet returns. Hosts fall back to Greet for plugins that
+	// answer with codes.Unimplemented.
+	Variants(context.Context, *GreetingRequest) (*VariantList, error)
+	// ListKinds returns the greeting kinds the plugin supports
+	ListKinds(context.Context, *Empty) (*KindList, error)
+	// GetInfo returns the plugin metadata
+	GetInfo(context.Context, *Empty) (*PluginInfo, error)
 	mustEmbedUnimplementedGreeterServiceServer()
 }
 
@@ -118,20 +119,17 @@ type GreeterServiceServer interface {
 // pointer dereference when methods are called.
 type UnimplementedGreeterServiceServer struct{}
 
-func (UnimplementedGreeterServiceServer) Hello(context.Context, *Empty) (*GreetingResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Hello not implemented")
-}
-func (UnimplementedGreeterServiceServer) GoodMorning(context.Context, *Empty) (*GreetingResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GoodMorning not implemented")
+func (UnimplementedGreeterServiceServer) Greet(context.Context, *GreetingRequest) (*GreetingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Greet not implemented")
 }
-func (UnimplementedGreeterServiceServer) GoodAfternoon(context.Context, *Empty) (*GreetingResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GoodAfternoon not implemented")
+func (UnimplementedGreeterServiceServer) Variants(context.Context, *GreetingRequest) (*VariantList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Variants not implemented")
 }
-func (UnimplementedGreeterServiceServer) GoodNight(context.Context, *Empty) (*GreetingResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GoodNight not implemented")
+func (UnimplementedGreeterServiceServer) ListKinds(context.Context, *Empty) (*KindList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListKinds not implemented")
 }
-func (UnimplementedGreeterServiceServer) GoodBye(context.Context, *Empty) (*GreetingResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GoodBye not implemented")
+func (UnimplementedGreeterServiceServer) GetInfo(context.Context, *Empty) (*PluginInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInfo not implemented")
 }
 func (UnimplementedGreeterServiceServer) mustEmbedUnimplementedGreeterServiceServer() {}
 func (UnimplementedGreeterServiceServer) testEmbeddedByValue()                        {}
@@ -154,92 +152,74 @@ func RegisterGreeterServiceServer(s grpc.ServiceRegistrar, srv GreeterServiceSer
 	s.RegisterService(&GreeterService_ServiceDesc, srv)
 }
 
-func _GreeterService_Hello_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Empty)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(GreeterServiceServer).Hello(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: GreeterService_Hello_FullMethodName,
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GreeterServiceServer).Hello(ctx, req.(*Empty))
-	}
-	return interceptor(ctx, in, info, handler)
-}
-
-func _GreeterService_GoodMorning_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Empty)
+func _GreeterService_Greet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GreetingRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GreeterServiceServer).GoodMorning(ctx, in)
+		return srv.(GreeterServiceServer).Greet(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: GreeterService_GoodMorning_FullMethodName,
+		FullMethod: GreeterService_Greet_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GreeterServiceServer).GoodMorning(ctx, req.(*Empty))
+		return srv.(GreeterServiceServer).Greet(ctx, req.(*GreetingRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _GreeterService_GoodAfternoon_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Empty)
+func _GreeterService_Variants_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GreetingRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GreeterServiceServer).GoodAfternoon(ctx, in)
+		return srv.(GreeterServiceServer).Variants(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: GreeterService_GoodAfternoon_FullMethodName,
+		FullMethod: GreeterService_Variants_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GreeterServiceServer).GoodAfternoon(ctx, req.(*Empty))
+		return srv.(GreeterServiceServer).Variants(ctx, req.(*GreetingRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _GreeterService_GoodNight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+func _GreeterService_ListKinds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GreeterServiceServer).GoodNight(ctx, in)
+		return srv.(GreeterServiceServer).ListKinds(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: GreeterService_GoodNight_FullMethodName,
+		FullMethod: GreeterService_ListKinds_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GreeterServiceServer).GoodNight(ctx, req.(*Empty))
+		return srv.(GreeterServiceServer).ListKinds(ctx, req.(*Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _GreeterService_GoodBye_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+func _GreeterService_GetInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GreeterServiceServer).GoodBye(ctx, in)
+		return srv.(GreeterServiceServer).GetInfo(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: GreeterService_GoodBye_FullMethodName,
+		FullMethod: GreeterService_GetInfo_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GreeterServiceServer).GoodBye(ctx, req.(*Empty))
+		return srv.(GreeterServiceServer).GetInfo(ctx, req.(*Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -252,24 +232,20 @@ var GreeterService_ServiceDesc = grpc.ServiceDesc{
 	HandlerType: (*GreeterServiceServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "Hello",
-			Handler:    _GreeterService_Hello_Handler,
-		},
-		{
-			MethodName: "GoodMorning",
-			Handler:    _GreeterService_GoodMorning_Handler,
+			MethodName: "Greet",
+			Handler:    _GreeterService_Greet_Handler,
 		},
 		{
-			MethodName: "GoodAfternoon",
-			Handler:    _GreeterService_GoodAfternoon_Handler,
+			MethodName: "Variants",
+			Handler:    _GreeterService_Variants_Handler,
 		},
 		{
-			MethodName: "GoodNight",
-			Handler:    _GreeterService_GoodNight_Handler,
+			MethodName: "ListKinds",
+			Handler:    _GreeterService_ListKinds_Handler,
 		},
 		{
-			MethodName: "GoodBye",
-			Handler:    _GreeterService_GoodBye_Handler,
+			MethodName: "GetInfo",
+			Handler:    _GreeterService_GetInfo_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/sirupsen/logrus"
@@ -39,7 +40,14 @@ func (r *Registry) Register(plugin greetings.Plugin) {
 	}
 
 	r.plugins[name] = plugin
-	r.logger.Infof("Registered plugin: %s", name)
+	r.logger.Debugf("Registered plugin: %s", name)
+}
+
+// SetLogger replaces the logger of the registry, e.g. once logging is configured
+func (r *Registry) SetLogger(logger *logrus.Logger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logger = logger
 }
 
 // Get retrieves a plugin by name
@@ -50,7 +58,7 @@ func (r *Registry) Get(name string) (greetings.Plugin, bool) {
 	return plugin, exists
 }
 
-// List returns all registered plugin names
+// List returns all registered plugin names, sorted
 func (r *Registry) List() []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -59,6 +67,7 @@ func (r *Registry) List() []string {
 	for name := range r.plugins {
 		names = append(names, name)
 	}
+	sort.Strings(names)
 	return names
 }
 
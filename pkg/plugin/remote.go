@@ -0,0 +1,130 @@
+package plugin
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin/limits"
+	"github.com/unsuman/greeter/pkg/plugin/sandbox"
+	"github.com/unsuman/greeter/pkg/plugin/signing"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Remote serves plugins run by another process, such as greeterd. Errors
+// are reported like the ones of the PluginManager methods of the same name.
+type Remote interface {
+	GetGreeting(ctx context.Context, category, name string, req greetings.Request) (greetings.Greeting, error)
+	GetVariants(ctx context.Context, category, name string, req greetings.Request) ([]greetings.Variant, error)
+	ListKinds(ctx context.Context, category, name string) ([]string, error)
+	PluginInfo(ctx context.Context, category, name string) (*greetings.Info, error)
+	Health(ctx context.Context, category, name string) (healthpb.HealthCheckResponse_ServingStatus, error)
+	StopPlugin(ctx context.Context, category, name string) error
+	Close() error
+}
+
+// SetRemote makes the manager forward plugin calls to remote instead of
+// starting plugins itself. Plugins are still located and vetted locally, so
+// a plugin this manager would refuse is not used through remote either; the
+// remote should enforce the same Policy on the plugins it starts.
+func (pm *PluginManager) SetRemote(remote Remote) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.remote = remote
+}
+
+// Remote returns the remote plugins are served by, nil if they are started by the manager
+func (pm *PluginManager) Remote() Remote {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+	return pm.remote
+}
+
+// RunningPlugins returns the plugins started by the manager as "category/name"
+func (pm *PluginManager) RunningPlugins() []string {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	running := make([]string, 0, len(pm.pools))
+	for _, p := range pm.pools {
+		if len(p.instances) > 0 {
+			running = append(running, p.category+"/"+p.name)
+		}
+	}
+	return running
+}
+
+// remoteFor returns the remote serving a plugin, nil if the manager starts
+// plugins itself. The plugin is vetted first, so that a plugin the manager
+// would refuse is not forwarded to the remote either.
+func (pm *PluginManager) remoteFor(category, name string) (Remote, error) {
+	remote := pm.Remote()
+	if remote == nil {
+		return nil, nil
+	}
+	if _, err := pm.vetPlugin(category, name); err != nil {
+		return nil, err
+	}
+	return remote, nil
+}
+
+// Policy is what the manager enforces on the plugins it starts, so that a
+// remote can be checked to enforce the same
+type Policy struct {
+	SignatureMode signing.Mode
+	// TrustedKeys are the fingerprints of the trusted keys, sorted
+	TrustedKeys []string
+	// CgroupRoot is the cgroup delegated to the manager, see SetCgroupRoot
+	CgroupRoot string
+	// Plugins holds the limits and sandbox of the plugins configured with some
+	Plugins map[string]PluginPolicy
+}
+
+// PluginPolicy is what the manager enforces on the processes of a plugin
+type PluginPolicy struct {
+	Limits  limits.Limits
+	Sandbox sandbox.Config
+}
+
+// Equal reports whether two policies enforce the same
+func (p Policy) Equal(other Policy) bool {
+	return p.SignatureMode == other.SignatureMode &&
+		slices.Equal(p.TrustedKeys, other.TrustedKeys) &&
+		p.CgroupRoot == other.CgroupRoot &&
+		maps.EqualFunc(p.Plugins, other.Plugins, func(a, b PluginPolicy) bool {
+			return a.Limits == b.Limits &&
+				a.Sandbox.Enabled == b.Sandbox.Enabled &&
+				a.Sandbox.Required == b.Sandbox.Required &&
+				slices.Equal(a.Sandbox.ReadPaths, b.Sandbox.ReadPaths)
+		})
+}
+
+// Policy returns what the manager enforces on the plugins it starts
+func (pm *PluginManager) Policy() Policy {
+	var policy Policy
+	pm.vetMutex.Lock()
+	policy.SignatureMode = pm.signatureMode
+	if policy.SignatureMode != signing.ModeOff {
+		for _, key := range pm.keyring {
+			policy.TrustedKeys = append(policy.TrustedKeys, key.Fingerprint())
+		}
+		sort.Strings(policy.TrustedKeys)
+	}
+	pm.vetMutex.Unlock()
+
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+	policy.CgroupRoot = pm.cgroupRoot
+	for name, cfg := range pm.configs {
+		if cfg.Limits.IsZero() && !cfg.Sandbox.Enabled {
+			continue
+		}
+		if policy.Plugins == nil {
+			policy.Plugins = make(map[string]PluginPolicy)
+		}
+		policy.Plugins[name] = PluginPolicy{Limits: cfg.Limits, Sandbox: cfg.Sandbox}
+	}
+	return policy
+}
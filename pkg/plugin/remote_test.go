@@ -0,0 +1,145 @@
+package plugin
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin/limits"
+	"github.com/unsuman/greeter/pkg/plugin/sandbox"
+	"github.com/unsuman/greeter/pkg/plugin/signing"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// fakeRemote serves every plugin, recording the calls forwarded to it
+type fakeRemote struct {
+	calls []string
+}
+
+func (r *fakeRemote) GetGreeting(ctx context.Context, category, name string, req greetings.Request) (greetings.Greeting, error) {
+	r.calls = append(r.calls, "GetGreeting")
+	return greetings.Greeting{Text: "Hello from remote"}, nil
+}
+
+func (r *fakeRemote) GetVariants(ctx context.Context, category, name string, req greetings.Request) ([]greetings.Variant, error) {
+	r.calls = append(r.calls, "GetVariants")
+	return []greetings.Variant{{Greeting: greetings.Greeting{Text: "Hello from remote"}, Weight: 1}}, nil
+}
+
+func (r *fakeRemote) ListKinds(ctx context.Context, category, name string) ([]string, error) {
+	r.calls = append(r.calls, "ListKinds")
+	return []string{"hello"}, nil
+}
+
+func (r *fakeRemote) PluginInfo(ctx context.Context, category, name string) (*greetings.Info, error) {
+	r.calls = append(r.calls, "PluginInfo")
+	return &greetings.Info{Name: name}, nil
+}
+
+func (r *fakeRemote) Health(ctx context.Context, category, name string) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	r.calls = append(r.calls, "Health")
+	return healthpb.HealthCheckResponse_SERVING, nil
+}
+
+func (r *fakeRemote) StopPlugin(ctx context.Context, category, name string) error {
+	r.calls = append(r.calls, "StopPlugin")
+	return nil
+}
+
+func (r *fakeRemote) Close() error { return nil }
+
+func TestRemoteRefusedPlugin(t *testing.T) {
+	public, release, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pm, _, _ := newTestManager(t, PluginConfig{})
+	pm.SetSignatureVerification(signing.ModeEnforce, signing.Keyring{{ID: "release.pub", PublicKey: public}})
+	remote := &fakeRemote{}
+	pm.SetRemote(remote)
+
+	// The plugin is unsigned, so it is refused before reaching the remote
+	ctx := context.Background()
+	req := greetings.Request{Kind: "hello"}
+	var verr *signing.VerificationError
+	if _, err := pm.GetGreeting(ctx, "lang", "fake", req); !errors.As(err, &verr) {
+		t.Errorf("GetGreeting: got %v, want the plugin refused", err)
+	}
+	if _, err := pm.GetVariants(ctx, "lang", "fake", req); !errors.As(err, &verr) {
+		t.Errorf("GetVariants: got %v, want the plugin refused", err)
+	}
+	if _, err := pm.ListKinds(ctx, "lang", "fake"); !errors.As(err, &verr) {
+		t.Errorf("ListKinds: got %v, want the plugin refused", err)
+	}
+	if _, err := pm.PluginInfo(ctx, "lang", "fake"); !errors.As(err, &verr) {
+		t.Errorf("PluginInfo: got %v, want the plugin refused", err)
+	}
+	if health := pm.Health("lang", "fake"); health != healthpb.HealthCheckResponse_UNKNOWN {
+		t.Errorf("Health = %s, want UNKNOWN", health)
+	}
+	if len(remote.calls) > 0 {
+		t.Errorf("forwarded %q for a refused plugin", remote.calls)
+	}
+
+	execPath, _, err := pm.Locate("lang", "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signing.Sign(release, execPath); err != nil {
+		t.Fatal(err)
+	}
+	pm.SetSignatureVerification(signing.ModeEnforce, signing.Keyring{{ID: "release.pub", PublicKey: public}})
+	if _, err := pm.PluginInfo(ctx, "lang", "fake"); err != nil {
+		t.Errorf("PluginInfo: %v", err)
+	}
+	if health := pm.Health("lang", "fake"); health != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Health = %s, want SERVING", health)
+	}
+	if len(remote.calls) != 2 || len(instances(pm)) > 0 {
+		t.Errorf("forwarded %q, want the signed plugin served by the remote", remote.calls)
+	}
+}
+
+func TestPolicyEqual(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newManager := func(mode signing.Mode, cfg PluginConfig) *PluginManager {
+		pm, _, _ := newTestManager(t, cfg)
+		pm.SetSignatureVerification(mode, signing.Keyring{{ID: "release.pub", PublicKey: public}})
+		return pm
+	}
+	sandboxed := PluginConfig{Sandbox: sandbox.Config{Enabled: true, ReadPaths: []string{}}}
+	policy := newManager(signing.ModeEnforce, sandboxed).Policy()
+	if want := []string{signing.Key{PublicKey: public}.Fingerprint()}; !slices.Equal(policy.TrustedKeys, want) {
+		t.Errorf("trusted keys = %q, want %q", policy.TrustedKeys, want)
+	}
+
+	tests := []struct {
+		name  string
+		pm    *PluginManager
+		equal bool
+	}{
+		{"same", newManager(signing.ModeEnforce, PluginConfig{Sandbox: sandbox.Config{Enabled: true}}), true},
+		{"signing off", newManager(signing.ModeOff, sandboxed), false},
+		{"no sandbox", newManager(signing.ModeEnforce, PluginConfig{}), false},
+		{"limits", newManager(signing.ModeEnforce, PluginConfig{Sandbox: sandboxed.Sandbox, Limits: limits.Limits{Memory: 64 << 20}}), false},
+		{"read paths", newManager(signing.ModeEnforce, PluginConfig{Sandbox: sandbox.Config{Enabled: true, ReadPaths: []string{"/usr/share"}}}), false},
+	}
+	for _, tt := range tests {
+		if equal := tt.pm.Policy().Equal(policy); equal != tt.equal {
+			t.Errorf("%s: Equal = %v, want %v", tt.name, equal, tt.equal)
+		}
+	}
+
+	// Without signature verification, the keys do not matter
+	other := newManager(signing.ModeOff, PluginConfig{})
+	other.SetSignatureVerification(signing.ModeOff, nil)
+	if !other.Policy().Equal(newManager(signing.ModeOff, PluginConfig{}).Policy()) {
+		t.Error("policies differ by the keys of a manager not verifying signatures")
+	}
+}
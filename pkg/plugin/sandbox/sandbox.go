@@ -0,0 +1,124 @@
+// Package sandbox confines plugin processes on Linux: fresh user, network
+// and mount namespaces, a Landlock ruleset only letting the plugin read its
+// own binary and the paths it is given, and a seccomp allowlist of the
+// system calls a gRPC-over-stdio Go program makes. Features the kernel
+// lacks are skipped, unless the sandbox is required.
+//
+// Landlock and seccomp apply to the process restricting itself, so the
+// plugin is started through the executable of the host: Prepare makes cmd
+// run /proc/self/exe, where RunTrampoline applies the sandbox and executes
+// the plugin in place. Programs starting sandboxed plugins call it first
+// thing in main, see plugin.RunTrampolines.
+package sandbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envSpec is the environment variable passing the spec of the sandbox to
+// the host executable started in place of the plugin
+const envSpec = "GREETER_SANDBOX"
+
+// Config is the sandbox of a plugin
+type Config struct {
+	Enabled bool
+	// ReadPaths are files and directories the plugin may read, besides its binary
+	ReadPaths []string
+	// Required refuses to start the plugin when the kernel lacks a feature
+	// of the sandbox, rather than running it less confined
+	Required bool
+}
+
+// Report describes the sandbox a plugin is started in
+type Report struct {
+	// Namespaces are the namespaces created for the plugin, e.g. "user"
+	Namespaces []string
+	// Landlock is the Landlock ABI version restricting the plugin, 0 for none
+	Landlock int
+	Seccomp  bool
+	// Missing are the features of the sandbox the kernel lacks, with the reason
+	Missing []string
+}
+
+func (r Report) String() string {
+	var features []string
+	if len(r.Namespaces) > 0 {
+		features = append(features, strings.Join(r.Namespaces, ", ")+" namespaces")
+	}
+	if r.Landlock > 0 {
+		features = append(features, fmt.Sprintf("Landlock ABI %d", r.Landlock))
+	}
+	if r.Seccomp {
+		features = append(features, "seccomp allowlist")
+	}
+	if len(features) == 0 {
+		return "no confinement"
+	}
+	return strings.Join(features, ", ")
+}
+
+// UnsupportedError reports a required sandbox the kernel cannot provide
+type UnsupportedError struct {
+	Plugin  string
+	Missing []string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("plugin %s requires a sandbox the kernel cannot provide: %s", e.Plugin, strings.Join(e.Missing, "; "))
+}
+
+// spec is what the host executable applies before executing the plugin
+type spec struct {
+	// Probe exits right away, to check that namespaces can be created
+	Probe bool `json:"probe,omitempty"`
+
+	Plugin string   `json:"plugin,omitempty"`
+	Args   []string `json:"args,omitempty"`
+	// PluginFD is the descriptor Plugin refers to, 0 for none
+	PluginFD int `json:"plugin_fd,omitempty"`
+	// Landlock is the ABI version to restrict the plugin with, 0 for none,
+	// and Rules the paths it may access
+	Landlock int    `json:"landlock,omitempty"`
+	Rules    []rule `json:"rules,omitempty"`
+	Seccomp  bool   `json:"seccomp,omitempty"`
+}
+
+// rule grants access to the files beneath a path
+type rule struct {
+	Path    string `json:"path"`
+	Execute bool   `json:"execute,omitempty"`
+}
+
+// RunTrampoline applies the sandbox and executes the plugin when the host
+// executable was started in place of the plugin by Prepare, and returns
+// right away otherwise
+func RunTrampoline() {
+	encoded, ok := os.LookupEnv(envSpec)
+	if !ok {
+		return
+	}
+
+	var s spec
+	if err := json.Unmarshal([]byte(encoded), &s); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: invalid spec: %v\n", err)
+		os.Exit(126)
+	}
+	if s.Probe {
+		os.Exit(0)
+	}
+
+	env := make([]string, 0, len(os.Environ()))
+	for _, variable := range os.Environ() {
+		if !strings.HasPrefix(variable, envSpec+"=") {
+			env = append(env, variable)
+		}
+	}
+
+	// Only returns on failure
+	err := enter(s, env)
+	fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+	os.Exit(126)
+}
@@ -0,0 +1,303 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"debug/elf"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// selfExe is the executable of the host, started in place of the plugin
+const selfExe = "/proc/self/exe"
+
+// libraryPaths are the paths a dynamically linked plugin loads its libraries from
+var libraryPaths = []string{"/lib", "/lib64", "/usr/lib", "/usr/lib64", "/etc/ld.so.cache"}
+
+// support is what the kernel provides, probed once
+var support struct {
+	once sync.Once
+
+	// namespaces are the namespaces attrs creates, namespacesErr why none can be
+	namespaces    []string
+	attrs         syscall.SysProcAttr
+	namespacesErr error
+
+	landlock    int
+	landlockErr error
+
+	seccompErr error
+}
+
+// probe checks which features of the sandbox the kernel provides
+func probe() {
+	support.once.Do(func() {
+		support.namespaces, support.attrs, support.namespacesErr = probeNamespaces()
+		support.landlock, support.landlockErr = probeLandlock()
+		support.seccompErr = probeSeccomp()
+	})
+}
+
+// namespaceAttrs returns the attributes starting a process in new network
+// and mount namespaces, owned by a new user namespace if userNS is set
+func namespaceAttrs(userNS bool) syscall.SysProcAttr {
+	attrs := syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWNET,
+		// Unsharing the mount namespace rather than cloning it makes the
+		// mounts private, so that none propagate out of the sandbox
+		Unshareflags: syscall.CLONE_NEWNS,
+	}
+	if userNS {
+		attrs.Cloneflags |= syscall.CLONE_NEWUSER
+		attrs.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+		attrs.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+		attrs.GidMappingsEnableSetgroups = false
+	}
+	return attrs
+}
+
+// probeNamespaces starts the host executable in new namespaces. Without
+// user namespaces, root can still create the others.
+func probeNamespaces() ([]string, syscall.SysProcAttr, error) {
+	var err error
+	for _, userNS := range []bool{true, false} {
+		if !userNS && os.Geteuid() != 0 {
+			break
+		}
+
+		attrs := namespaceAttrs(userNS)
+		cmd := exec.Command(selfExe)
+		cmd.Env = []string{envSpec + `={"probe":true}`}
+		cmd.SysProcAttr = &attrs
+		if err = cmd.Run(); err != nil {
+			continue
+		}
+
+		namespaces := []string{"network", "mount"}
+		if userNS {
+			namespaces = append([]string{"user"}, namespaces...)
+		}
+		return namespaces, attrs, nil
+	}
+	return nil, syscall.SysProcAttr{}, fmt.Errorf("namespaces: %w", err)
+}
+
+// probeLandlock returns the Landlock ABI version of the kernel
+func probeLandlock() (int, error) {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	switch errno {
+	case 0:
+		return int(abi), nil
+	case unix.ENOSYS:
+		return 0, errors.New("Landlock: not supported by the kernel")
+	case unix.EOPNOTSUPP:
+		return 0, errors.New("Landlock: disabled in the kernel")
+	default:
+		return 0, fmt.Errorf("Landlock: %w", errno)
+	}
+}
+
+// probeSeccomp checks that the kernel filters system calls and that an allowlist exists for the architecture
+func probeSeccomp() error {
+	if auditArch == 0 {
+		return fmt.Errorf("seccomp: no allowlist for %s", runtime.GOARCH)
+	}
+	actions, err := os.ReadFile("/proc/sys/kernel/seccomp/actions_avail")
+	if err != nil || !strings.Contains(string(actions), "errno") {
+		return errors.New("seccomp: filters not supported by the kernel")
+	}
+	return nil
+}
+
+// Prepare makes cmd start its plugin in the sandbox, as far as the kernel
+// supports it. binary, if not nil, is the open plugin binary, passed to
+// the sandbox and executed rather than cmd.Path. Prepare fails if the sandbox is required
+// and the kernel lacks a feature, the report then telling which.
+func Prepare(cmd *exec.Cmd, plugin string, cfg Config, binary *os.File) (Report, error) {
+	probe()
+
+	var report Report
+	s := spec{Plugin: cmd.Path, Args: cmd.Args}
+	path := cmd.Path
+	if binary != nil {
+		s.PluginFD = 3 + len(cmd.ExtraFiles)
+		s.Plugin = fmt.Sprintf("/proc/self/fd/%d", s.PluginFD)
+		path = binary.Name()
+	}
+
+	if support.namespacesErr != nil {
+		report.Missing = append(report.Missing, support.namespacesErr.Error())
+	} else {
+		report.Namespaces = support.namespaces
+	}
+
+	if support.landlockErr != nil {
+		report.Missing = append(report.Missing, support.landlockErr.Error())
+	} else {
+		report.Landlock = support.landlock
+		s.Landlock = support.landlock
+		s.Rules = rules(path, cfg.ReadPaths)
+	}
+
+	if support.seccompErr != nil {
+		report.Missing = append(report.Missing, support.seccompErr.Error())
+	} else {
+		report.Seccomp = true
+		s.Seccomp = true
+	}
+
+	if cfg.Required && len(report.Missing) > 0 {
+		return report, &UnsupportedError{Plugin: plugin, Missing: report.Missing}
+	}
+
+	encoded, err := json.Marshal(s)
+	if err != nil {
+		return report, err
+	}
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, envSpec+"="+string(encoded))
+	cmd.Path = selfExe
+	if binary != nil {
+		cmd.ExtraFiles = append(cmd.ExtraFiles, binary)
+	}
+
+	if report.Namespaces != nil {
+		if cmd.SysProcAttr == nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		cmd.SysProcAttr.Cloneflags |= support.attrs.Cloneflags
+		cmd.SysProcAttr.Unshareflags |= support.attrs.Unshareflags
+		cmd.SysProcAttr.UidMappings = support.attrs.UidMappings
+		cmd.SysProcAttr.GidMappings = support.attrs.GidMappings
+		cmd.SysProcAttr.GidMappingsEnableSetgroups = support.attrs.GidMappingsEnableSetgroups
+	}
+	return report, nil
+}
+
+// rules returns the paths the plugin may access: its binary, the libraries
+// if it is dynamically linked, and readPaths
+func rules(plugin string, readPaths []string) []rule {
+	rules := []rule{{Path: plugin, Execute: true}}
+
+	if binary, err := elf.Open(plugin); err == nil {
+		for _, prog := range binary.Progs {
+			if prog.Type != elf.PT_INTERP {
+				continue
+			}
+			interpreter := make([]byte, prog.Filesz)
+			if _, err := prog.ReadAt(interpreter, 0); err == nil {
+				rules = append(rules, rule{Path: strings.TrimRight(string(interpreter), "\x00"), Execute: true})
+				for _, path := range libraryPaths {
+					rules = append(rules, rule{Path: path})
+				}
+			}
+		}
+		binary.Close()
+	}
+
+	for _, path := range readPaths {
+		rules = append(rules, rule{Path: path})
+	}
+	return rules
+}
+
+// enter applies the sandbox to the current process and executes the plugin
+func enter(s spec, env []string) error {
+	// Landlock and seccomp restrict the calling thread, the one executing the plugin
+	runtime.LockOSThread()
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to set no_new_privs: %w", err)
+	}
+	if s.Landlock > 0 {
+		if err := restrictPaths(s.Landlock, s.Rules); err != nil {
+			return fmt.Errorf("Landlock: %w", err)
+		}
+	}
+	if s.Seccomp {
+		if err := filterSyscalls(); err != nil {
+			return fmt.Errorf("seccomp: %w", err)
+		}
+	}
+
+	// The plugin does not keep the descriptor of its binary
+	if s.PluginFD > 0 {
+		unix.CloseOnExec(s.PluginFD)
+	}
+	return unix.Exec(s.Plugin, s.Args, env)
+}
+
+// restrictPaths denies access to the filesystem but for the paths of
+// rules, and, when the ABI supports it, TCP and signals to other processes
+func restrictPaths(abi int, rules []rule) error {
+	attr := unix.LandlockRulesetAttr{Access_fs: 1<<13 - 1}
+	if abi >= 2 {
+		attr.Access_fs |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		attr.Access_fs |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	if abi >= 4 {
+		attr.Access_net = unix.LANDLOCK_ACCESS_NET_BIND_TCP | unix.LANDLOCK_ACCESS_NET_CONNECT_TCP
+	}
+	if abi >= 5 {
+		attr.Access_fs |= unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+	}
+	if abi >= 6 {
+		attr.Scoped = unix.LANDLOCK_SCOPE_ABSTRACT_UNIX_SOCKET | unix.LANDLOCK_SCOPE_SIGNAL
+	}
+
+	ruleset, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to create ruleset: %w", errno)
+	}
+	defer unix.Close(int(ruleset))
+
+	for _, r := range rules {
+		fd, err := unix.Open(r.Path, unix.O_PATH|unix.O_CLOEXEC, 0)
+		if errors.Is(err, unix.ENOENT) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", r.Path, err)
+		}
+
+		var stat unix.Stat_t
+		err = unix.Fstat(fd, &stat)
+		access := uint64(unix.LANDLOCK_ACCESS_FS_READ_FILE)
+		if stat.Mode&unix.S_IFMT == unix.S_IFDIR {
+			access |= unix.LANDLOCK_ACCESS_FS_READ_DIR
+		}
+		if r.Execute {
+			access |= unix.LANDLOCK_ACCESS_FS_EXECUTE
+		}
+		if err == nil {
+			beneath := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)}
+			_, _, errno = unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, ruleset, unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&beneath)), 0, 0, 0)
+			if errno != 0 {
+				err = errno
+			}
+		}
+		unix.Close(fd)
+		if err != nil {
+			return fmt.Errorf("failed to allow %s: %w", r.Path, err)
+		}
+	}
+
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, ruleset, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to restrict: %w", errno)
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package sandbox
+
+import (
+	"debug/elf"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestMain(m *testing.M) {
+	// Prepared commands start the test binary in place of the plugin
+	RunTrampoline()
+	os.Exit(m.Run())
+}
+
+// runFilter evaluates a seccomp filter for a system call with its first
+// argument, supporting the instructions seccompFilter uses
+func runFilter(t *testing.T, filter []unix.SockFilter, arch, nr, arg0 uint32) uint32 {
+	t.Helper()
+	var acc uint32
+	for pc := 0; pc < len(filter); pc++ {
+		ins := filter[pc]
+		switch ins.Code {
+		case unix.BPF_LD | unix.BPF_W | unix.BPF_ABS:
+			switch ins.K {
+			case offsetArch:
+				acc = arch
+			case offsetNr:
+				acc = nr
+			case offsetArg0:
+				acc = arg0
+			default:
+				t.Fatalf("load of seccomp_data offset %d", ins.K)
+			}
+		case unix.BPF_ALU | unix.BPF_AND | unix.BPF_K:
+			acc &= ins.K
+		case unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K:
+			if acc == ins.K {
+				pc += int(ins.Jt)
+			} else {
+				pc += int(ins.Jf)
+			}
+		case unix.BPF_RET | unix.BPF_K:
+			return ins.K
+		default:
+			t.Fatalf("unexpected instruction %#x", ins.Code)
+		}
+	}
+	t.Fatal("the filter does not return")
+	return 0
+}
+
+func TestSeccompFilter(t *testing.T) {
+	if auditArch == 0 {
+		t.Skip("no allowlist for the architecture")
+	}
+	filter, err := seccompFilter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deny := unix.SECCOMP_RET_ERRNO | uint32(unix.EPERM)
+	// The flags the Go runtime starts threads with, and fork's
+	thread := uint32(unix.CLONE_VM | unix.CLONE_FS | unix.CLONE_FILES | unix.CLONE_SIGHAND | unix.CLONE_SYSVSEM | unix.CLONE_THREAD)
+	fork := uint32(unix.CLONE_CHILD_SETTID | unix.CLONE_CHILD_CLEARTID | unix.SIGCHLD)
+	tests := []struct {
+		name string
+		arch uint32
+		nr   uintptr
+		arg0 uint32
+		want uint32
+	}{
+		{"read", auditArch, unix.SYS_READ, 0, unix.SECCOMP_RET_ALLOW},
+		{"futex", auditArch, unix.SYS_FUTEX, 0, unix.SECCOMP_RET_ALLOW},
+		{"execve", auditArch, unix.SYS_EXECVE, 0, unix.SECCOMP_RET_ALLOW},
+		{"socket", auditArch, unix.SYS_SOCKET, 0, deny},
+		{"connect", auditArch, unix.SYS_CONNECT, 0, deny},
+		{"ptrace", auditArch, unix.SYS_PTRACE, 0, deny},
+		{"mount", auditArch, unix.SYS_MOUNT, 0, deny},
+		{"unshare", auditArch, unix.SYS_UNSHARE, unix.CLONE_NEWUSER, deny},
+		{"clone thread", auditArch, unix.SYS_CLONE, thread, unix.SECCOMP_RET_ALLOW},
+		{"clone process", auditArch, unix.SYS_CLONE, fork, deny},
+		{"clone thread in user namespace", auditArch, unix.SYS_CLONE, thread | unix.CLONE_NEWUSER, deny},
+		{"clone process in network namespace", auditArch, unix.SYS_CLONE, fork | unix.CLONE_NEWNET, deny},
+		{"clone3", auditArch, unix.SYS_CLONE3, 0, unix.SECCOMP_RET_ERRNO | uint32(unix.ENOSYS)},
+		{"other architecture", auditArch + 1, unix.SYS_READ, 0, unix.SECCOMP_RET_KILL_PROCESS},
+	}
+	for _, tt := range tests {
+		if got := runFilter(t, filter, tt.arch, uint32(tt.nr), tt.arg0); got != tt.want {
+			t.Errorf("%s: got %#x, want %#x", tt.name, got, tt.want)
+		}
+	}
+
+	// Every system call of the allowlist is allowed, the last one included
+	for _, nr := range append(append([]uintptr(nil), syscalls...), archSyscalls...) {
+		if got := runFilter(t, filter, auditArch, uint32(nr), 0); got != unix.SECCOMP_RET_ALLOW {
+			t.Errorf("system call %d: got %#x, want it allowed", nr, got)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "hindi")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho namaste\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	want := []rule{{Path: script, Execute: true}, {Path: "/usr/share/greeter/hindi"}}
+	if got := rules(script, []string{"/usr/share/greeter/hindi"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// A dynamically linked plugin loads its libraries
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell")
+	}
+	interpreter := elfInterpreter(sh)
+	if interpreter == "" {
+		t.Skip("the shell is not dynamically linked")
+	}
+	got := rules(sh, []string{dir})
+	want = []rule{{Path: sh, Execute: true}, {Path: interpreter, Execute: true}}
+	for _, path := range libraryPaths {
+		want = append(want, rule{Path: path})
+	}
+	want = append(want, rule{Path: dir})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+// elfInterpreter returns the dynamic linker of an executable, "" if it has none
+func elfInterpreter(path string) string {
+	f, err := elf.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	for _, prog := range f.Progs {
+		if prog.Type == elf.PT_INTERP {
+			data := make([]byte, prog.Filesz)
+			prog.ReadAt(data, 0)
+			return strings.TrimRight(string(data), "\x00")
+		}
+	}
+	return ""
+}
+
+// sandboxSpec returns the spec Prepare passed to cmd
+func sandboxSpec(t *testing.T, cmd *exec.Cmd) spec {
+	t.Helper()
+	var s spec
+	for _, variable := range cmd.Env {
+		if encoded, ok := strings.CutPrefix(variable, envSpec+"="); ok {
+			if err := json.Unmarshal([]byte(encoded), &s); err != nil {
+				t.Fatal(err)
+			}
+			return s
+		}
+	}
+	t.Fatal("no sandbox spec")
+	return s
+}
+
+func TestPrepare(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell")
+	}
+	binary, err := os.Open(sh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer binary.Close()
+
+	cmd := exec.Command(sh, "-c", "true")
+	report, err := Prepare(cmd, "hindi", Config{Enabled: true, ReadPaths: []string{"/usr/share/greeter"}}, binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := sandboxSpec(t, cmd)
+	if cmd.Path != selfExe || s.Plugin != "/proc/self/fd/3" || s.PluginFD != 3 || len(cmd.ExtraFiles) != 1 {
+		t.Errorf("got %s executing %+v, want the host executing the open binary", cmd.Path, s)
+	}
+	if !reflect.DeepEqual(s.Args, []string{sh, "-c", "true"}) {
+		t.Errorf("args = %q", s.Args)
+	}
+
+	// The spec and the report follow what the kernel supports
+	if s.Landlock != report.Landlock || s.Seccomp != report.Seccomp {
+		t.Errorf("spec %+v does not match report %+v", s, report)
+	}
+	if report.Landlock > 0 && (len(s.Rules) == 0 || s.Rules[0] != rule{Path: sh, Execute: true} || s.Rules[len(s.Rules)-1].Path != "/usr/share/greeter") {
+		t.Errorf("rules = %+v, want the binary and the read paths", s.Rules)
+	}
+	if len(report.Namespaces) > 0 && (cmd.SysProcAttr == nil || cmd.SysProcAttr.Cloneflags&unix.CLONE_NEWNET == 0) {
+		t.Errorf("the process is not started in new namespaces: %+v", cmd.SysProcAttr)
+	}
+	if len(report.Missing) == 0 && len(report.Namespaces) == 0 {
+		t.Errorf("report = %+v, want the namespaces or why they are missing", report)
+	}
+}
+
+func TestPrepareRequired(t *testing.T) {
+	cmd := exec.Command("/bin/true")
+	report, err := Prepare(cmd, "hindi", Config{Enabled: true, Required: true}, nil)
+	if len(report.Missing) == 0 {
+		if err != nil {
+			t.Errorf("Prepare: %v", err)
+		}
+		t.Skip("the kernel supports the whole sandbox")
+	}
+
+	var unsupported *UnsupportedError
+	if !errors.As(err, &unsupported) || !reflect.DeepEqual(unsupported.Missing, report.Missing) {
+		t.Errorf("got %v, want the missing features %q reported", err, report.Missing)
+	}
+	if cmd.Path != "/bin/true" || cmd.Env != nil {
+		t.Error("the command was changed")
+	}
+}
+
+func TestSandboxedProcess(t *testing.T) {
+	probe()
+	if support.landlockErr != nil || support.seccompErr != nil {
+		t.Skipf("the kernel lacks a feature: %v", errors.Join(support.landlockErr, support.seccompErr))
+	}
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell")
+	}
+	cat, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skip("no cat")
+	}
+
+	allowed := t.TempDir()
+	if err := os.WriteFile(filepath.Join(allowed, "greeting"), []byte("namaste\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	denied := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(denied, []byte("secret\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// fails is set for scripts the shell gives up on
+	tests := []struct {
+		name   string
+		script string
+		want   string
+		fails  bool
+	}{
+		{"read path", "read line < " + filepath.Join(allowed, "greeting") + " && echo $line", "namaste\n", false},
+		{"other file", "read line < " + denied + " && echo $line || echo denied", "denied\n", false},
+		{"other program", cat + " " + filepath.Join(allowed, "greeting") + " 2>/dev/null || echo denied", "denied\n", false},
+		{"other program in place", "exec " + cat + " " + filepath.Join(allowed, "greeting") + " 2>/dev/null", "", true},
+		{"new process", "echo started; (echo forked) 2>/dev/null", "started\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command(sh, "-c", tt.script)
+			if _, err := Prepare(cmd, "hindi", Config{Enabled: true, ReadPaths: []string{allowed}}, nil); err != nil {
+				t.Fatal(err)
+			}
+			out, err := cmd.Output()
+			var exitErr *exec.ExitError
+			if tt.fails && !errors.As(err, &exitErr) {
+				t.Errorf("got %v, want the shell to fail", err)
+			} else if !tt.fails && err != nil {
+				t.Fatalf("the sandboxed process failed: %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("got %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
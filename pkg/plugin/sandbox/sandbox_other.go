@@ -0,0 +1,24 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// Prepare leaves cmd as it is, plugins are only sandboxed on Linux. It
+// fails if the sandbox is required.
+func Prepare(cmd *exec.Cmd, plugin string, cfg Config, binary *os.File) (Report, error) {
+	report := Report{Missing: []string{"sandboxing is only supported on Linux"}}
+	if cfg.Required {
+		return report, &UnsupportedError{Plugin: plugin, Missing: report.Missing}
+	}
+	return report, nil
+}
+
+// enter fails, plugins are only sandboxed on Linux
+func enter(s spec, env []string) error {
+	return errors.New("sandboxing is only supported on Linux")
+}
@@ -0,0 +1,29 @@
+package sandbox
+
+import "testing"
+
+func TestReportString(t *testing.T) {
+	tests := []struct {
+		report Report
+		want   string
+	}{
+		{Report{}, "no confinement"},
+		{Report{Missing: []string{"Landlock: not supported by the kernel"}}, "no confinement"},
+		{Report{Namespaces: []string{"user", "network", "mount"}}, "user, network, mount namespaces"},
+		{Report{Namespaces: []string{"network", "mount"}, Landlock: 3, Seccomp: true}, "network, mount namespaces, Landlock ABI 3, seccomp allowlist"},
+		{Report{Seccomp: true}, "seccomp allowlist"},
+	}
+	for _, tt := range tests {
+		if got := tt.report.String(); got != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.report, got, tt.want)
+		}
+	}
+}
+
+func TestUnsupportedError(t *testing.T) {
+	err := &UnsupportedError{Plugin: "hindi", Missing: []string{"namespaces: operation not permitted", "Landlock: disabled in the kernel"}}
+	want := "plugin hindi requires a sandbox the kernel cannot provide: namespaces: operation not permitted; Landlock: disabled in the kernel"
+	if err.Error() != want {
+		t.Errorf("got %q, want %q", err.Error(), want)
+	}
+}
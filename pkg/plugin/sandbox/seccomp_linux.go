@@ -0,0 +1,115 @@
+package sandbox
+
+import (
+	"errors"
+	"slices"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// syscalls are the system calls a Go plugin serving gRPC over its standard
+// input and output makes on every architecture, besides archSyscalls.
+// Files are opened subject to Landlock; sockets cannot be created; clone
+// only starts threads, see seccompFilter.
+var syscalls = []uintptr{
+	// I/O on the pipes and the files Landlock lets through
+	unix.SYS_READ, unix.SYS_WRITE, unix.SYS_READV, unix.SYS_WRITEV,
+	unix.SYS_PREAD64, unix.SYS_PWRITE64, unix.SYS_CLOSE, unix.SYS_LSEEK,
+	unix.SYS_OPENAT, unix.SYS_FSTAT, unix.SYS_STATX, unix.SYS_READLINKAT,
+	unix.SYS_FACCESSAT, unix.SYS_FACCESSAT2, unix.SYS_GETDENTS64, unix.SYS_GETCWD,
+	unix.SYS_FCNTL, unix.SYS_IOCTL, unix.SYS_DUP, unix.SYS_DUP3, unix.SYS_PIPE2,
+
+	// Network poller
+	unix.SYS_EPOLL_CREATE1, unix.SYS_EPOLL_CTL, unix.SYS_EPOLL_PWAIT,
+	unix.SYS_EVENTFD2, unix.SYS_PPOLL, unix.SYS_PSELECT6,
+
+	// Memory
+	unix.SYS_MMAP, unix.SYS_MUNMAP, unix.SYS_MPROTECT, unix.SYS_MADVISE,
+	unix.SYS_MREMAP, unix.SYS_BRK, unix.SYS_MINCORE, unix.SYS_MEMBARRIER,
+
+	// Threads, signals and scheduling
+	unix.SYS_EXIT, unix.SYS_EXIT_GROUP,
+	unix.SYS_FUTEX, unix.SYS_SCHED_YIELD, unix.SYS_SCHED_GETAFFINITY,
+	unix.SYS_SET_ROBUST_LIST, unix.SYS_SET_TID_ADDRESS, unix.SYS_RSEQ,
+	unix.SYS_RT_SIGACTION, unix.SYS_RT_SIGPROCMASK, unix.SYS_RT_SIGRETURN,
+	unix.SYS_SIGALTSTACK, unix.SYS_TGKILL, unix.SYS_TKILL, unix.SYS_RESTART_SYSCALL,
+
+	// Time
+	unix.SYS_CLOCK_GETTIME, unix.SYS_CLOCK_GETRES, unix.SYS_CLOCK_NANOSLEEP,
+	unix.SYS_GETTIMEOFDAY, unix.SYS_NANOSLEEP, unix.SYS_SETITIMER, unix.SYS_GETITIMER,
+	unix.SYS_TIMER_CREATE, unix.SYS_TIMER_SETTIME, unix.SYS_TIMER_DELETE,
+
+	// Process information
+	unix.SYS_GETPID, unix.SYS_GETPPID, unix.SYS_GETTID, unix.SYS_GETUID,
+	unix.SYS_GETEUID, unix.SYS_GETGID, unix.SYS_GETEGID, unix.SYS_UNAME,
+	unix.SYS_SYSINFO, unix.SYS_GETRLIMIT, unix.SYS_PRLIMIT64, unix.SYS_GETRANDOM,
+
+	// Executing the plugin once the filter is installed. The filter outlives
+	// the execution, so it cannot tell it from the plugin executing a
+	// program later: Landlock only lets the plugin execute its binary and
+	// its dynamic linker, and as clone only starts threads, whatever it
+	// executes replaces it rather than running in another process.
+	unix.SYS_EXECVE,
+}
+
+// seccomp_data offsets of the system call number, the architecture and
+// the low 32 bits of the first argument, on little-endian architectures
+const (
+	offsetNr   = 0
+	offsetArch = 4
+	offsetArg0 = 16
+)
+
+// cloneFlags are the flags of clone checked by the filter: a thread shares
+// the process of the plugin, any other clone would start a new process or
+// enter new namespaces, which the plugin may not do
+const cloneFlags = unix.CLONE_THREAD | unix.CLONE_NEWNS | unix.CLONE_NEWCGROUP |
+	unix.CLONE_NEWUTS | unix.CLONE_NEWIPC | unix.CLONE_NEWUSER | unix.CLONE_NEWPID | unix.CLONE_NEWNET
+
+// filterSyscalls makes the system calls missing from the allowlist fail
+// with EPERM, and the ones of other architectures kill the process
+func filterSyscalls() error {
+	filter, err := seccompFilter()
+	if err != nil {
+		return err
+	}
+	program := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	return unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&program)), 0, 0)
+}
+
+// seccompFilter returns the BPF program of the allowlist, see
+// filterSyscalls. clone is only allowed to start threads. The arguments of
+// clone3 are out of reach of the filter, so it fails with ENOSYS, making
+// the C library fall back to clone.
+func seccompFilter() ([]unix.SockFilter, error) {
+	allowed := slices.Concat(syscalls, archSyscalls)
+	if len(allowed) > 254 {
+		return nil, errors.New("allowlist too long for a single jump")
+	}
+
+	n := len(allowed)
+	filter := []unix.SockFilter{
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: offsetArch},
+		{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jt: 1, K: auditArch},
+		{Code: unix.BPF_RET | unix.BPF_K, K: unix.SECCOMP_RET_KILL_PROCESS},
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: offsetNr},
+
+		{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jf: 1, K: unix.SYS_CLONE3},
+		{Code: unix.BPF_RET | unix.BPF_K, K: unix.SECCOMP_RET_ERRNO | uint32(unix.ENOSYS)},
+
+		// Past the allowlist to the denial or the allowance for clone
+		{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jf: 3, K: unix.SYS_CLONE},
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: offsetArg0},
+		{Code: unix.BPF_ALU | unix.BPF_AND | unix.BPF_K, K: cloneFlags},
+		{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jt: uint8(n + 1), Jf: uint8(n), K: unix.CLONE_THREAD},
+	}
+	for i, nr := range allowed {
+		// Jump over the remaining checks and the denial to the allowance
+		filter = append(filter, unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jt: uint8(n - i), K: uint32(nr)})
+	}
+	return append(filter,
+		unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: unix.SECCOMP_RET_ERRNO | uint32(unix.EPERM)},
+		unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: unix.SECCOMP_RET_ALLOW},
+	), nil
+}
@@ -0,0 +1,14 @@
+package sandbox
+
+import "golang.org/x/sys/unix"
+
+// auditArch is the architecture seccomp filters check system calls against
+const auditArch = unix.AUDIT_ARCH_X86_64
+
+// archSyscalls are the system calls of the allowlist only amd64 has
+var archSyscalls = []uintptr{
+	unix.SYS_ARCH_PRCTL, unix.SYS_OPEN, unix.SYS_STAT, unix.SYS_LSTAT,
+	unix.SYS_NEWFSTATAT, unix.SYS_ACCESS, unix.SYS_READLINK, unix.SYS_GETDENTS,
+	unix.SYS_EPOLL_CREATE, unix.SYS_EPOLL_WAIT, unix.SYS_POLL, unix.SYS_SELECT,
+	unix.SYS_PIPE, unix.SYS_DUP2, unix.SYS_TIME,
+}
@@ -0,0 +1,11 @@
+package sandbox
+
+import "golang.org/x/sys/unix"
+
+// auditArch is the architecture seccomp filters check system calls against
+const auditArch = unix.AUDIT_ARCH_AARCH64
+
+// archSyscalls are the system calls of the allowlist only arm64 has
+var archSyscalls = []uintptr{
+	unix.SYS_FSTATAT,
+}
@@ -0,0 +1,9 @@
+//go:build linux && !amd64 && !arm64
+
+package sandbox
+
+// auditArch is 0, there is no allowlist for the architecture
+const auditArch = 0
+
+// archSyscalls is empty, there is no allowlist for the architecture
+var archSyscalls []uintptr
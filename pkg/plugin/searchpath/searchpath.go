@@ -0,0 +1,135 @@
+// Package searchpath builds the list of directories plugins are looked up in.
+//
+// Every directory of the search path is laid out like the build output:
+// external plugins in lang/, language packs in packs/ and gettext catalogs
+// in locale/. Directories are searched in order and the first one holding a
+// plugin wins, from the highest precedence to the lowest:
+//
+//  1. --plugin-dir flags, in the order given
+//  2. GREETER_PLUGIN_PATH, a list separated like PATH
+//  3. plugin_dirs in the configuration file
+//  4. the directory of the greeter executable
+//  5. $XDG_DATA_HOME/greeter, ~/.local/share/greeter by default
+//  6. greeter/ in each of $XDG_DATA_DIRS, /usr/local/share and /usr/share by default
+package searchpath
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EnvVar lists plugin directories taking precedence over the configuration file
+const EnvVar = "GREETER_PLUGIN_PATH"
+
+// Sources of the search path directories, reported along with the plugins found there
+const (
+	SourceFlag       = "--plugin-dir"
+	SourceEnv        = EnvVar
+	SourceConfig     = "config file"
+	SourceExecutable = "executable directory"
+	SourceDataHome   = "XDG_DATA_HOME"
+	SourceDataDirs   = "XDG_DATA_DIRS"
+)
+
+// Dir is a directory of the search path along with the setting it comes from
+type Dir struct {
+	Path   string
+	Source string
+}
+
+func (d Dir) String() string {
+	return d.Path + " (" + d.Source + ")"
+}
+
+// Path is the ordered list of directories plugins are looked up in
+type Path []Dir
+
+// Options holds the settings the search path is built from
+type Options struct {
+	// Flags are the --plugin-dir flags
+	Flags []string
+	// Config are the plugin_dirs of the configuration file
+	Config []string
+	// Executable is the path of the running greeter binary, empty to leave its directory out
+	Executable string
+	// Getenv reads GREETER_PLUGIN_PATH and the XDG variables, os.Getenv if nil
+	Getenv func(string) string
+}
+
+// Build returns the search path in precedence order. Directories listed
+// by several sources are only kept where they take the highest precedence.
+func Build(opts Options) Path {
+	getenv := opts.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+
+	var path Path
+	seen := make(map[string]bool)
+	add := func(dir, source string) {
+		if dir == "" {
+			return
+		}
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		if seen[dir] {
+			return
+		}
+		seen[dir] = true
+		path = append(path, Dir{Path: dir, Source: source})
+	}
+
+	for _, dir := range opts.Flags {
+		add(dir, SourceFlag)
+	}
+	for _, dir := range filepath.SplitList(getenv(EnvVar)) {
+		add(dir, SourceEnv)
+	}
+	for _, dir := range opts.Config {
+		add(dir, SourceConfig)
+	}
+	if opts.Executable != "" {
+		add(filepath.Dir(opts.Executable), SourceExecutable)
+	}
+
+	dataHome := getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			dataHome = filepath.Join(home, ".local", "share")
+		}
+	}
+	if dataHome != "" {
+		add(filepath.Join(dataHome, "greeter"), SourceDataHome)
+	}
+
+	dataDirs := getenv("XDG_DATA_DIRS")
+	if dataDirs == "" {
+		dataDirs = "/usr/local/share:/usr/share"
+	}
+	for _, dir := range filepath.SplitList(dataDirs) {
+		if dir != "" {
+			add(filepath.Join(dir, "greeter"), SourceDataDirs)
+		}
+	}
+
+	return path
+}
+
+// Sub returns the subdirectory of a category, e.g. "lang" or "packs", in every directory
+func (p Path) Sub(category string) []string {
+	dirs := make([]string, len(p))
+	for i, dir := range p {
+		dirs[i] = filepath.Join(dir.Path, category)
+	}
+	return dirs
+}
+
+func (p Path) String() string {
+	dirs := make([]string, len(p))
+	for i, dir := range p {
+		dirs[i] = dir.String()
+	}
+	return strings.Join(dirs, ", ")
+}
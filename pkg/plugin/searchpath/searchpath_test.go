@@ -0,0 +1,86 @@
+//go:build unix
+
+package searchpath
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{
+		EnvVar:          "/env/one" + string(filepath.ListSeparator) + "/env/two",
+		"XDG_DATA_HOME": "/data/home",
+		"XDG_DATA_DIRS": "/data/local" + string(filepath.ListSeparator) + "/data/system",
+	}
+	path := Build(Options{
+		Flags:      []string{"/flag/one", "plugins"},
+		Config:     []string{"/config", "/env/two"},
+		Executable: "/opt/greeter/bin/greeter",
+		Getenv:     func(name string) string { return env[name] },
+	})
+
+	want := Path{
+		{"/flag/one", SourceFlag},
+		{filepath.Join(cwd, "plugins"), SourceFlag},
+		{"/env/one", SourceEnv},
+		{"/env/two", SourceEnv},
+		{"/config", SourceConfig},
+		{"/opt/greeter/bin", SourceExecutable},
+		{"/data/home/greeter", SourceDataHome},
+		{"/data/local/greeter", SourceDataDirs},
+		{"/data/system/greeter", SourceDataDirs},
+	}
+	if !reflect.DeepEqual(path, want) {
+		t.Errorf("got %v\nwant %v", path, want)
+	}
+
+	wantLang := []string{"/flag/one/lang", filepath.Join(cwd, "plugins", "lang")}
+	if lang := path.Sub("lang"); !reflect.DeepEqual(lang[:2], wantLang) {
+		t.Errorf("Sub(lang) = %v, want %v first", lang, wantLang)
+	}
+}
+
+func TestBuildDefaults(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	path := Build(Options{Getenv: func(string) string { return "" }})
+
+	want := Path{
+		{"/home/me/.local/share/greeter", SourceDataHome},
+		{"/usr/local/share/greeter", SourceDataDirs},
+		{"/usr/share/greeter", SourceDataDirs},
+	}
+	if !reflect.DeepEqual(path, want) {
+		t.Errorf("got %v\nwant %v", path, want)
+	}
+}
+
+func TestBuildDuplicates(t *testing.T) {
+	path := Build(Options{
+		Flags:  []string{"/usr/share/greeter", "/plugins/", "/plugins"},
+		Config: []string{"/plugins/../plugins"},
+		Getenv: func(name string) string {
+			if name == "XDG_DATA_HOME" {
+				return "/home/me/.local/share"
+			}
+			return ""
+		},
+	})
+
+	// Directories are kept where they take the highest precedence
+	want := Path{
+		{"/usr/share/greeter", SourceFlag},
+		{"/plugins", SourceFlag},
+		{"/home/me/.local/share/greeter", SourceDataHome},
+		{"/usr/local/share/greeter", SourceDataDirs},
+	}
+	if !reflect.DeepEqual(path, want) {
+		t.Errorf("got %v\nwant %v", path, want)
+	}
+}
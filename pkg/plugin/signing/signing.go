@@ -0,0 +1,227 @@
+// Package signing signs plugin binaries with ed25519 and verifies them
+// against a keyring of trusted public keys before they are executed.
+//
+// The signature of a plugin binary sits next to it with the ".sig" suffix,
+// e.g. lang/hindi.sig, and holds the base64 encoded ed25519 signature of
+// the plugin name and the SHA-256 digest of the binary. Binding the name
+// keeps a signed binary from being installed as another plugin.
+//
+// Keys are PEM encoded, PKCS #8 for private keys and PKIX for public keys,
+// as written by:
+//
+//	openssl genpkey -algorithm ed25519 -out release.pem
+//	openssl pkey -in release.pem -pubout -out release.pub
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Suffix is appended to the path of a plugin binary to locate its signature
+const Suffix = ".sig"
+
+// signatureContext separates plugin signatures from anything else signed with the same key
+const signatureContext = "greeter plugin signature v1"
+
+// Mode selects what happens to plugins that fail verification
+type Mode string
+
+const (
+	// ModeEnforce refuses to run plugins without a trusted signature
+	ModeEnforce Mode = "enforce"
+	// ModeWarn logs plugins without a trusted signature and runs them anyway
+	ModeWarn Mode = "warn"
+	// ModeOff does not verify plugins
+	ModeOff Mode = "off"
+)
+
+// ParseMode parses a verification mode, the empty string selects def
+func ParseMode(s string, def Mode) (Mode, error) {
+	switch m := Mode(strings.ToLower(strings.TrimSpace(s))); m {
+	case "":
+		return def, nil
+	case ModeEnforce, ModeWarn, ModeOff:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown signature verification mode %q (expected enforce, warn or off)", s)
+	}
+}
+
+var (
+	// ErrUnsigned is reported for plugin binaries without a signature file
+	ErrUnsigned = errors.New("binary is not signed")
+	// ErrUntrusted is reported for signatures made by none of the trusted keys
+	ErrUntrusted = errors.New("signature does not match any trusted key")
+)
+
+// VerificationError reports a plugin binary that failed signature verification
+type VerificationError struct {
+	Plugin string
+	Path   string
+	Err    error
+}
+
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("plugin %s refused: %s: %v", e.Plugin, e.Path, e.Err)
+}
+
+func (e *VerificationError) Unwrap() error {
+	return e.Err
+}
+
+// Key is a trusted public key
+type Key struct {
+	// ID identifies the key in logs, the file it was read from
+	ID        string
+	PublicKey ed25519.PublicKey
+}
+
+// Fingerprint returns the hex SHA-256 digest of the public key, shortened to 16 digits
+func (k Key) Fingerprint() string {
+	sum := sha256.Sum256(k.PublicKey)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Keyring is the set of public keys plugin binaries may be signed with
+type Keyring []Key
+
+// LoadKeyring reads the PEM public keys of the given files
+func LoadKeyring(paths []string) (Keyring, error) {
+	var keyring Keyring
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read trusted key: %w", err)
+		}
+		key, err := ParsePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("trusted key %s: %w", path, err)
+		}
+		keyring = append(keyring, Key{ID: filepath.Base(path), PublicKey: key})
+	}
+	return keyring, nil
+}
+
+// ParsePublicKey parses a PEM encoded PKIX ed25519 public key
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.New("expected a PEM \"PUBLIC KEY\" block")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("expected an ed25519 key, got %T", key)
+	}
+	return public, nil
+}
+
+// ParsePrivateKey parses a PEM encoded PKCS #8 ed25519 private key
+func ParsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, errors.New("expected a PEM \"PRIVATE KEY\" block")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("expected an ed25519 key, got %T", key)
+	}
+	return private, nil
+}
+
+// Path returns the signature path of a plugin binary
+func Path(binary string) string {
+	return binary + Suffix
+}
+
+// IsSignature reports whether a file name is the one of a signature
+func IsSignature(name string) bool {
+	return strings.HasSuffix(name, Suffix)
+}
+
+// message returns the bytes signed for the plugin binary named name, read from r
+func message(name string, r io.Reader) ([]byte, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return fmt.Appendf(nil, "%s\n%s\n%x", signatureContext, name, h.Sum(nil)), nil
+}
+
+// Sign signs a plugin binary, named after its file, and writes the signature next to it
+func Sign(key ed25519.PrivateKey, binary string) error {
+	f, err := os.Open(binary)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	msg, err := message(filepath.Base(binary), f)
+	if err != nil {
+		return err
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, msg))
+	return os.WriteFile(Path(binary), []byte(sig+"\n"), 0o644)
+}
+
+// Verify checks the signature of the plugin binary named name and returns
+// the trusted key that made it. Failures are reported as a *VerificationError.
+func (k Keyring) Verify(name, binary string) (Key, error) {
+	f, err := os.Open(binary)
+	if err != nil {
+		return Key{}, &VerificationError{Plugin: name, Path: Path(binary), Err: err}
+	}
+	defer f.Close()
+	return k.VerifyFile(name, binary, f)
+}
+
+// VerifyFile is Verify reading the binary from f, opened from the path
+// binary, so that the caller can execute the very file it checked
+func (k Keyring) VerifyFile(name, binary string, f *os.File) (Key, error) {
+	path := Path(binary)
+	fail := func(err error) (Key, error) {
+		return Key{}, &VerificationError{Plugin: name, Path: path, Err: err}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fail(ErrUnsigned)
+		}
+		return fail(err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fail(errors.New("malformed signature"))
+	}
+
+	msg, err := message(name, io.NewSectionReader(f, 0, math.MaxInt64))
+	if err != nil {
+		return fail(err)
+	}
+	for _, key := range k {
+		if ed25519.Verify(key.PublicKey, msg, sig) {
+			return key, nil
+		}
+	}
+	return fail(ErrUntrusted)
+}
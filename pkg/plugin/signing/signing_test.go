@@ -0,0 +1,236 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newKey returns a new private key and the keyring of its public key
+func newKey(t *testing.T, id string) (ed25519.PrivateKey, Keyring) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return private, Keyring{{ID: id, PublicKey: public}}
+}
+
+// writeBinary writes a plugin binary named name and returns its path
+func writeBinary(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestVerify(t *testing.T) {
+	release, keyring := newKey(t, "release.pub")
+	other, _ := newKey(t, "other.pub")
+
+	tests := []struct {
+		name string
+		// prepare signs or alters the plugin binary at path
+		prepare func(t *testing.T, path string)
+		// plugin is the name the binary is verified as, "hindi" if empty
+		plugin string
+		// trusted tells whether the binary is verified, want is the error reported otherwise
+		trusted bool
+		want    error
+	}{
+		{
+			name:    "signed",
+			prepare: func(t *testing.T, path string) { sign(t, release, path) },
+			trusted: true,
+		},
+		{
+			name:    "unsigned",
+			prepare: func(t *testing.T, path string) {},
+			want:    ErrUnsigned,
+		},
+		{
+			name:    "wrong key",
+			prepare: func(t *testing.T, path string) { sign(t, other, path) },
+			want:    ErrUntrusted,
+		},
+		{
+			name:    "wrong plugin name",
+			prepare: func(t *testing.T, path string) { sign(t, release, path) },
+			plugin:  "japanese",
+			want:    ErrUntrusted,
+		},
+		{
+			name: "tampered binary",
+			prepare: func(t *testing.T, path string) {
+				sign(t, release, path)
+				if err := os.WriteFile(path, []byte("#!/bin/sh\necho tampered\n"), 0o755); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: ErrUntrusted,
+		},
+		{
+			name: "malformed signature",
+			prepare: func(t *testing.T, path string) {
+				if err := os.WriteFile(Path(path), []byte("not base64!\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeBinary(t, t.TempDir(), "hindi", "#!/bin/sh\necho namaste\n")
+			tt.prepare(t, path)
+			plugin := tt.plugin
+			if plugin == "" {
+				plugin = "hindi"
+			}
+
+			key, err := keyring.Verify(plugin, path)
+			if tt.trusted {
+				if err != nil || key.ID != "release.pub" {
+					t.Errorf("got %v, %v, want the release key", key, err)
+				}
+				return
+			}
+			var verr *VerificationError
+			if !errors.As(err, &verr) || verr.Plugin != plugin || verr.Path != Path(path) {
+				t.Fatalf("got %v, want a *VerificationError of %s", err, plugin)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRenamedBinary(t *testing.T) {
+	release, keyring := newKey(t, "release.pub")
+	dir := t.TempDir()
+	hindi := writeBinary(t, dir, "hindi", "#!/bin/sh\necho namaste\n")
+	sign(t, release, hindi)
+
+	// A signed binary installed as another plugin is refused
+	japanese := filepath.Join(dir, "japanese")
+	if err := os.Rename(hindi, japanese); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(Path(hindi), Path(japanese)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keyring.Verify("japanese", japanese); !errors.Is(err, ErrUntrusted) {
+		t.Errorf("got %v, want %v", err, ErrUntrusted)
+	}
+}
+
+func TestVerifyFile(t *testing.T) {
+	release, keyring := newKey(t, "release.pub")
+	path := writeBinary(t, t.TempDir(), "hindi", "#!/bin/sh\necho namaste\n")
+	sign(t, release, path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	// The file is read in full wherever its offset is
+	if _, err := io.CopyN(io.Discard, f, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replacing the binary after it was opened does not change the file checked
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	writeBinary(t, filepath.Dir(path), "hindi", "#!/bin/sh\necho tampered\n")
+	if _, err := keyring.VerifyFile("hindi", path, f); err != nil {
+		t.Errorf("VerifyFile: %v", err)
+	}
+	if _, err := keyring.Verify("hindi", path); !errors.Is(err, ErrUntrusted) {
+		t.Errorf("got %v, want the replaced binary refused", err)
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	release, _ := newKey(t, "")
+	dir := t.TempDir()
+
+	der, err := x509.MarshalPKIXPublicKey(release.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	public := filepath.Join(dir, "release.pub")
+	if err := os.WriteFile(public, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	der, err = x509.MarshalPKCS8PrivateKey(release)
+	if err != nil {
+		t.Fatal(err)
+	}
+	private := filepath.Join(dir, "release.pem")
+	if err := os.WriteFile(private, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keyring, err := LoadKeyring([]string{public})
+	if err != nil {
+		t.Fatalf("LoadKeyring: %v", err)
+	}
+	if len(keyring) != 1 || keyring[0].ID != "release.pub" || !keyring[0].PublicKey.Equal(release.Public()) {
+		t.Errorf("got %v", keyring)
+	}
+
+	data, err := os.ReadFile(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := ParsePrivateKey(data); err != nil || !key.Equal(release) {
+		t.Errorf("ParsePrivateKey: %v", err)
+	}
+
+	// A private key is not a trusted key
+	if _, err := LoadKeyring([]string{private}); err == nil || !strings.Contains(err.Error(), "PUBLIC KEY") {
+		t.Errorf("got %v, want the private key refused", err)
+	}
+	if _, err := LoadKeyring([]string{filepath.Join(dir, "missing.pub")}); err == nil {
+		t.Error("a missing key was not reported")
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Mode
+	}{
+		{"", ModeWarn},
+		{"enforce", ModeEnforce},
+		{" Warn ", ModeWarn},
+		{"OFF", ModeOff},
+	}
+	for _, tt := range tests {
+		if got, err := ParseMode(tt.s, ModeWarn); err != nil || got != tt.want {
+			t.Errorf("ParseMode(%q) = %q, %v, want %q", tt.s, got, err, tt.want)
+		}
+	}
+	if _, err := ParseMode("strict", ModeWarn); err == nil {
+		t.Error("ParseMode(strict) succeeded")
+	}
+}
+
+// sign signs the plugin binary at path with key
+func sign(t *testing.T, key ed25519.PrivateKey, path string) {
+	t.Helper()
+	if err := Sign(key, path); err != nil {
+		t.Fatal(err)
+	}
+}
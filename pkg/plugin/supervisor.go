@@ -0,0 +1,478 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/unsuman/greeter/pkg/plugin/limits"
+	"github.com/unsuman/greeter/pkg/statefile"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// exitGracePeriod bounds the wait for the exit of a plugin whose call failed
+// with a broken connection, before the failure is reported as is
+const exitGracePeriod = time.Second
+
+// RestartPolicy is the supervision policy of a plugin: how it is restarted
+// when it crashes, and when it is quarantined for crashing too often
+type RestartPolicy struct {
+	// Never leaves a crashed plugin stopped until it is used again
+	Never bool
+	// Backoff is the delay before restarting a plugin after its first crash
+	// within Window, doubled on every further crash up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// MaxRestarts is the number of crashes tolerated within Window, the next
+	// one quarantines the plugin
+	MaxRestarts int
+	Window      time.Duration
+	// Quarantine is how long a quarantined plugin is refused
+	Quarantine time.Duration
+}
+
+// DefaultRestartPolicy holds the values used for the settings of a RestartPolicy left to zero
+var DefaultRestartPolicy = RestartPolicy{
+	Backoff:     200 * time.Millisecond,
+	MaxBackoff:  30 * time.Second,
+	MaxRestarts: 5,
+	Window:      time.Minute,
+	Quarantine:  10 * time.Minute,
+}
+
+// withDefaults fills the settings left to zero from DefaultRestartPolicy
+func (p RestartPolicy) withDefaults() RestartPolicy {
+	if p.Backoff <= 0 {
+		p.Backoff = DefaultRestartPolicy.Backoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultRestartPolicy.MaxBackoff
+	}
+	if p.MaxRestarts <= 0 {
+		p.MaxRestarts = DefaultRestartPolicy.MaxRestarts
+	}
+	if p.Window <= 0 {
+		p.Window = DefaultRestartPolicy.Window
+	}
+	if p.Quarantine <= 0 {
+		p.Quarantine = DefaultRestartPolicy.Quarantine
+	}
+	return p
+}
+
+// backoff returns the delay before restarting a plugin that crashed crashes times within the window
+func (p RestartPolicy) backoff(crashes int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < crashes && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxBackoff)
+}
+
+// QuarantinedError reports a plugin refused because it kept crashing
+type QuarantinedError struct {
+	Plugin  string
+	Crashes int
+	Window  time.Duration
+	Until   time.Time
+	// LastError is the exit status of the last crash
+	LastError string
+}
+
+func (e *QuarantinedError) Error() string {
+	msg := fmt.Sprintf("plugin %s is quarantined until %s after crashing %d times within %s",
+		e.Plugin, e.Until.Format(time.TimeOnly), e.Crashes, e.Window)
+	if e.LastError != "" {
+		msg += " (last: " + e.LastError + ")"
+	}
+	return msg
+}
+
+// BreakerState is the circuit breaker state of a plugin
+type BreakerState struct {
+	// Crashes are the times of the crashes within the restart window
+	Crashes []time.Time `json:"crashes,omitempty"`
+	// QuarantinedUntil is set while the plugin is quarantined
+	QuarantinedUntil time.Time `json:"quarantined_until,omitempty"`
+	// LastError is the exit status of the last crash
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Quarantined reports whether the plugin is refused at time now
+func (s BreakerState) Quarantined(now time.Time) bool {
+	return now.Before(s.QuarantinedUntil)
+}
+
+// Recent returns the state without the crashes that fell out of the window
+// of policy at time now
+func (s BreakerState) Recent(now time.Time, policy RestartPolicy) BreakerState {
+	window := policy.withDefaults().Window
+	var crashes []time.Time
+	for _, t := range s.Crashes {
+		if now.Sub(t) < window {
+			crashes = append(crashes, t)
+		}
+	}
+	s.Crashes = crashes
+	return s
+}
+
+// BreakerStore keeps the circuit breaker state of plugins by "category/name",
+// either in memory or in a JSON file shared by every greeter process
+type BreakerStore struct {
+	path   string
+	logger *logrus.Logger
+	mutex  sync.Mutex
+	// states holds the states in memory, and for a file store the crashes
+	// recorded while the file could not be used, so that the breaker still trips
+	states map[string]BreakerState
+}
+
+// NewBreakerStore creates a store persisting the states in the file at
+// path, or keeping them in memory if path is empty. Problems with the file
+// are logged to logger.
+func NewBreakerStore(path string, logger *logrus.Logger) *BreakerStore {
+	return &BreakerStore{path: path, logger: logger, states: make(map[string]BreakerState)}
+}
+
+// load reads the states, falling back to the ones kept in memory when the
+// file cannot be read. The caller holds mutex.
+func (s *BreakerStore) load() map[string]BreakerState {
+	if s.path == "" {
+		return s.states
+	}
+	states := make(map[string]BreakerState)
+	if err := statefile.Read(s.path, &states); err != nil {
+		s.logger.Warnf("Using the plugin crashes recorded in memory: %v", err)
+		return s.states
+	}
+	return states
+}
+
+// update changes the states with fn, locking the file against the other
+// processes sharing it. A corrupt file is reset. When the file cannot be
+// written, fn changes the states kept in memory instead and the error is
+// returned. The caller holds mutex.
+func (s *BreakerStore) update(fn func(states map[string]BreakerState)) error {
+	if s.path == "" {
+		fn(s.states)
+		return nil
+	}
+
+	record := func() error {
+		states := make(map[string]BreakerState)
+		return statefile.Update(s.path, &states, func() error {
+			fn(states)
+			return nil
+		})
+	}
+
+	err := record()
+	if errors.Is(err, statefile.ErrInvalid) {
+		// A corrupt file forgets every crash, like a released plugin
+		s.logger.Warnf("Resetting the plugin crashes: %v", err)
+		if err = os.Remove(s.path); err == nil {
+			err = record()
+		}
+	}
+	if err != nil {
+		fn(s.states)
+	}
+	return err
+}
+
+// States returns the state of every plugin that crashed recently or is quarantined
+func (s *BreakerStore) States() map[string]BreakerState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.load()
+}
+
+// Get returns the state of a plugin
+func (s *BreakerStore) Get(key string) BreakerState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.load()[key]
+}
+
+// Release forgets the crashes of a plugin, lifting its quarantine. It
+// reports whether the plugin had a state.
+func (s *BreakerStore) Release(key string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	released := false
+	err := s.update(func(states map[string]BreakerState) {
+		_, released = states[key]
+		delete(states, key)
+	})
+	return released, err
+}
+
+// recordCrash adds a crash to the state of a plugin, forgetting the ones
+// older than the window of policy, and quarantines the plugin once it
+// crashed more than policy.MaxRestarts times within the window
+func (s *BreakerStore) recordCrash(key string, at time.Time, reason string, policy RestartPolicy) (BreakerState, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var state BreakerState
+	err := s.update(func(states map[string]BreakerState) {
+		state = states[key].Recent(at, policy)
+		state.Crashes = append(state.Crashes, at)
+		state.LastError = reason
+		if len(state.Crashes) > policy.MaxRestarts {
+			state.QuarantinedUntil = at.Add(policy.Quarantine)
+		}
+
+		states[key] = state
+	})
+	return state, err
+}
+
+// clock tells the time to the supervisor, so that tests can control it
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the clock of the system
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// restart is the pending restart of a crashed plugin
+type restart struct {
+	// done is closed once the plugin restarted or the supervisor gave up, with err telling why
+	done chan struct{}
+	err  error
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// cancel gives up the restart
+func (r *restart) cancel() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
+
+// SetBreakerStore sets where the crash history and quarantine of plugins
+// are kept. The default store keeps them in memory.
+func (pm *PluginManager) SetBreakerStore(store *BreakerStore) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.breakers = store
+}
+
+// restartPolicy returns the supervision policy of a plugin
+func (pm *PluginManager) restartPolicy(name string) RestartPolicy {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+	return pm.configs[name].Restart.withDefaults()
+}
+
+// checkQuarantine refuses a plugin quarantined for crashing too often
+func (pm *PluginManager) checkQuarantine(category, name string, policy RestartPolicy) error {
+	if state := pm.breakers.Get(category + "/" + name); state.Quarantined(pm.clock.Now()) {
+		return newQuarantinedError(name, state, policy)
+	}
+	return nil
+}
+
+// newQuarantinedError describes the quarantine of a plugin
+func newQuarantinedError(name string, state BreakerState, policy RestartPolicy) *QuarantinedError {
+	return &QuarantinedError{
+		Plugin:    name,
+		Crashes:   len(state.Crashes),
+		Window:    policy.Window,
+		Until:     state.QuarantinedUntil,
+		LastError: state.LastError,
+	}
+}
+
+// handleCrash records the crash of a plugin that exited without being
+// stopped, and restarts it according to its policy unless this quarantines it
+func (pm *PluginManager) handleCrash(instance *PluginInstance, exitErr error) {
+	category, name := instance.Category, instance.Name
+	pluginKey := category + "-" + name
+	policy := pm.restartPolicy(name)
+
+	reason := "exited unexpectedly"
+	if exitErr != nil {
+		reason = exitErr.Error()
+	}
+	instance.Logger.Errorf("Plugin crashed: %s", reason)
+
+	state, err := pm.breakers.recordCrash(category+"/"+name, pm.clock.Now(), reason, policy)
+	if err != nil {
+		instance.Logger.Warnf("Failed to record the crash: %v", err)
+	}
+	quarantined := state.Quarantined(pm.clock.Now())
+
+	var pending *restart
+	if !quarantined && !policy.Never {
+		pending = &restart{done: make(chan struct{}), stop: make(chan struct{})}
+	}
+
+	// Callers looking the plugin up from now on wait for the restart, unless
+	// other instances of the plugin are left to serve them. The crashed
+	// instance is then replaced right away.
+	pm.mutex.Lock()
+	p := instance.pool
+	pm.removeInstance(instance)
+	left := len(p.instances)
+	switch {
+	case left > 0 && pending != nil:
+		pending = nil
+		pm.fillPool(p)
+	case pending != nil:
+		pm.restarts[pluginKey] = pending
+	}
+	pm.mutex.Unlock()
+
+	instance.cancelFunc()
+	instance.Client.Close()
+	instance.exitErr = exitErr
+	close(instance.exited)
+
+	switch {
+	case quarantined:
+		instance.Logger.Errorf("Not restarting plugin: %v", newQuarantinedError(name, state, policy))
+	case policy.Never:
+		instance.Logger.Warn("Not restarting plugin, its restart policy is never")
+	case left > 0:
+		instance.Logger.Infof("Plugin has %d instances left", left)
+	default:
+		go pm.restart(instance, pending, len(state.Crashes), policy)
+	}
+}
+
+// restart starts a crashed plugin again after the backoff of its policy.
+// Failing to start counts as another crash, until the plugin is quarantined.
+func (pm *PluginManager) restart(instance *PluginInstance, pending *restart, crashes int, policy RestartPolicy) {
+	category, name := instance.Category, instance.Name
+	defer func() {
+		pm.mutex.Lock()
+		if pm.restarts[category+"-"+name] == pending {
+			delete(pm.restarts, category+"-"+name)
+		}
+		pm.mutex.Unlock()
+		close(pending.done)
+	}()
+
+	for {
+		delay := policy.backoff(crashes)
+		instance.Logger.Warnf("Restarting plugin in %s (crash %d of %d tolerated within %s)", delay, crashes, policy.MaxRestarts, policy.Window)
+
+		select {
+		case <-pm.clock.After(delay):
+		case <-pending.stop:
+			pending.err = fmt.Errorf("plugin %s was stopped while restarting", name)
+			return
+		}
+
+		// A plugin refused before it runs is not crashing
+		if _, err := pm.vetPlugin(category, name); err != nil {
+			pending.err = err
+			return
+		}
+
+		err := pm.StartPlugin(category, name)
+		if err == nil {
+			instance.Logger.Info("Plugin restarted")
+			return
+		}
+		var quarantined *QuarantinedError
+		if errors.As(err, &quarantined) {
+			pending.err = err
+			return
+		}
+
+		state, recordErr := pm.breakers.recordCrash(category+"/"+name, pm.clock.Now(), err.Error(), policy)
+		if recordErr != nil {
+			instance.Logger.Warnf("Failed to record the crash: %v", recordErr)
+		}
+		if state.Quarantined(pm.clock.Now()) {
+			pending.err = newQuarantinedError(name, state, policy)
+			instance.Logger.Errorf("Not restarting plugin: %v", pending.err)
+			return
+		}
+		crashes = len(state.Crashes)
+	}
+}
+
+// awaitRestart waits for the pending restart of a plugin, if any
+func (pm *PluginManager) awaitRestart(ctx context.Context, category, name string) error {
+	pm.mutex.RLock()
+	pending := pm.restarts[category+"-"+name]
+	pm.mutex.RUnlock()
+
+	if pending == nil {
+		return nil
+	}
+
+	pm.logger.Debugf("Waiting for plugin %s to restart", name)
+	select {
+	case <-pending.done:
+		return pending.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// crashedDuring reports whether a call failed because the plugin process
+// died, waiting briefly for the exit to be handled
+func crashedDuring(instance *PluginInstance, err error) bool {
+	if status.Code(err) != codes.Unavailable {
+		return false
+	}
+
+	select {
+	case <-instance.exited:
+		return true
+	case <-time.After(exitGracePeriod):
+		return false
+	}
+}
+
+// callPlugin runs call against the running instance of a plugin, starting it
+// if needed. A call failing because the plugin crashed is retried once against
+// the instance restarted by the supervisor.
+func (pm *PluginManager) callPlugin(ctx context.Context, category, name string, call func(*PluginInstance) error) error {
+	instance, err := pm.getInstance(ctx, category, name)
+	if err != nil {
+		return err
+	}
+
+	err = track(instance, call)
+	if !crashedDuring(instance, err) {
+		return err
+	}
+
+	// Retrying a call that got the plugin killed for exceeding its limits
+	// would most likely get the restarted plugin killed too
+	var exceeded *limits.ExceededError
+	if errors.As(instance.exitErr, &exceeded) {
+		return exceeded
+	}
+
+	instance.Logger.Warnf("Plugin crashed during the call, retrying: %v", err)
+	if instance, err = pm.getInstance(ctx, category, name); err != nil {
+		return err
+	}
+	return track(instance, call)
+}
+
+// track runs a call to a plugin, recording it as in progress so that the plugin is not reaped meanwhile
+func track(instance *PluginInstance, call func(*PluginInstance) error) error {
+	instance.pool.calls.Add(1)
+	instance.begin()
+	defer instance.end()
+	return call(instance)
+}
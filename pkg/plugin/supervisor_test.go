@@ -0,0 +1,319 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/unsuman/greeter/pkg/greetings"
+)
+
+// testPolicy tolerates two crashes within a minute
+var testPolicy = RestartPolicy{
+	Backoff:     100 * time.Millisecond,
+	MaxBackoff:  time.Second,
+	MaxRestarts: 2,
+	Window:      time.Minute,
+	Quarantine:  10 * time.Minute,
+}
+
+func TestRestartPolicyBackoff(t *testing.T) {
+	tests := []struct {
+		crashes int
+		want    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{50, time.Second},
+	}
+	for _, tt := range tests {
+		if got := testPolicy.backoff(tt.crashes); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.crashes, got, tt.want)
+		}
+	}
+}
+
+func TestRestartPolicyDefaults(t *testing.T) {
+	if got := (RestartPolicy{}).withDefaults(); got != DefaultRestartPolicy {
+		t.Errorf("withDefaults() = %+v, want %+v", got, DefaultRestartPolicy)
+	}
+	if got := testPolicy.withDefaults(); got != testPolicy {
+		t.Errorf("withDefaults() = %+v, want the policy unchanged", got)
+	}
+}
+
+func TestBreakerStoreRecordCrash(t *testing.T) {
+	tests := []struct {
+		name string
+		// crashes are the times of the crashes after the start
+		crashes     []time.Duration
+		want        int
+		quarantined bool
+	}{
+		{"first crash", []time.Duration{0}, 1, false},
+		{"tolerated crashes", []time.Duration{0, 10 * time.Second}, 2, false},
+		{"one crash too many", []time.Duration{0, 10 * time.Second, 20 * time.Second}, 3, true},
+		{"crashes outside the window", []time.Duration{0, 10 * time.Second, 70 * time.Second, 80 * time.Second}, 2, false},
+		{"window boundary", []time.Duration{0, 30 * time.Second, time.Minute}, 2, false},
+		{"spread out crashes", []time.Duration{0, 2 * time.Minute, 4 * time.Minute, 6 * time.Minute}, 1, false},
+	}
+
+	logger, _ := logtest.NewNullLogger()
+	for _, file := range []bool{false, true} {
+		for _, tt := range tests {
+			path := ""
+			if file {
+				path = filepath.Join(t.TempDir(), "plugins.json")
+			}
+			store := NewBreakerStore(path, logger)
+
+			start := time.Now()
+			var state BreakerState
+			for _, crash := range tt.crashes {
+				var err error
+				if state, err = store.recordCrash("lang/hindi", start.Add(crash), "killed", testPolicy); err != nil {
+					t.Fatalf("%s: recordCrash: %v", tt.name, err)
+				}
+			}
+
+			last := start.Add(tt.crashes[len(tt.crashes)-1])
+			stored := store.Get("lang/hindi")
+			if len(stored.Crashes) != tt.want || stored.Quarantined(last) != tt.quarantined {
+				t.Errorf("%s (file %v): %d crashes, quarantined %v, want %d and %v", tt.name, file, len(stored.Crashes), stored.Quarantined(last), tt.want, tt.quarantined)
+			}
+			if len(state.Crashes) != len(stored.Crashes) || stored.LastError != "killed" {
+				t.Errorf("%s (file %v): recordCrash returned %+v, stored %+v", tt.name, file, state, stored)
+			}
+			if tt.quarantined && !stored.QuarantinedUntil.Equal(last.Add(testPolicy.Quarantine)) {
+				t.Errorf("%s: quarantined until %s, want %s", tt.name, stored.QuarantinedUntil, last.Add(testPolicy.Quarantine))
+			}
+		}
+	}
+}
+
+func TestBreakerStateRecent(t *testing.T) {
+	now := time.Now()
+	state := BreakerState{Crashes: []time.Time{now.Add(-2 * time.Minute), now.Add(-time.Minute), now.Add(-time.Second)}}
+
+	recent := state.Recent(now, testPolicy)
+	if len(recent.Crashes) != 1 || !recent.Crashes[0].Equal(now.Add(-time.Second)) {
+		t.Errorf("Recent() = %v, want the last crash only", recent.Crashes)
+	}
+	if len(state.Crashes) != 3 {
+		t.Errorf("Recent() changed the state: %v", state.Crashes)
+	}
+	if recent := (BreakerState{}).Recent(now, RestartPolicy{}); len(recent.Crashes) != 0 {
+		t.Errorf("Recent() of no crash = %v", recent.Crashes)
+	}
+}
+
+func TestBreakerStoreRelease(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	store := NewBreakerStore(filepath.Join(t.TempDir(), "plugins.json"), logger)
+
+	start := time.Now()
+	for i := 0; i <= testPolicy.MaxRestarts; i++ {
+		if _, err := store.recordCrash("lang/hindi", start, "killed", testPolicy); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !store.Get("lang/hindi").Quarantined(start) {
+		t.Fatal("not quarantined")
+	}
+
+	released, err := store.Release("lang/hindi")
+	if err != nil || !released {
+		t.Fatalf("Release() = %v, %v", released, err)
+	}
+	if state := store.Get("lang/hindi"); state.Quarantined(start) || len(state.Crashes) != 0 {
+		t.Errorf("state after Release: %+v", state)
+	}
+	if released, err := store.Release("lang/hindi"); err != nil || released {
+		t.Errorf("second Release() = %v, %v", released, err)
+	}
+}
+
+func TestBreakerStoreCorruptFile(t *testing.T) {
+	logger, hook := logtest.NewNullLogger()
+	path := filepath.Join(t.TempDir(), "plugins.json")
+	if err := os.WriteFile(path, []byte("garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := NewBreakerStore(path, logger)
+
+	start := time.Now()
+	var state BreakerState
+	for i := 0; i <= testPolicy.MaxRestarts; i++ {
+		var err error
+		state, err = store.recordCrash("lang/hindi", start.Add(time.Duration(i)*time.Second), "killed", testPolicy)
+		if err != nil {
+			t.Fatalf("recordCrash: %v", err)
+		}
+	}
+	if !state.Quarantined(start) {
+		t.Errorf("not quarantined after %d crashes: %+v", len(state.Crashes), state)
+	}
+	if !store.Get("lang/hindi").Quarantined(start) {
+		t.Error("the quarantine was not persisted")
+	}
+	if len(hook.AllEntries()) == 0 {
+		t.Error("the reset of the corrupt file was not logged")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	states := make(map[string]BreakerState)
+	if err := json.Unmarshal(data, &states); err != nil {
+		t.Errorf("the file was not reset: %v", err)
+	}
+}
+
+func TestBreakerStoreUnwritableFile(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	dir := t.TempDir()
+	// The file cannot be created below a regular file
+	if err := os.WriteFile(filepath.Join(dir, "state"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := NewBreakerStore(filepath.Join(dir, "state", "plugins.json"), logger)
+
+	start := time.Now()
+	for i := 0; i <= testPolicy.MaxRestarts; i++ {
+		if _, err := store.recordCrash("lang/hindi", start.Add(time.Duration(i)*time.Second), "killed", testPolicy); err == nil {
+			t.Fatal("recordCrash succeeded without a file")
+		}
+	}
+
+	// The crashes are counted in memory, the breaker still trips
+	if state := store.Get("lang/hindi"); !state.Quarantined(start) {
+		t.Errorf("not quarantined after %d crashes: %+v", len(state.Crashes), state)
+	}
+}
+
+func TestSupervisorRestartsCrashedPlugin(t *testing.T) {
+	pm, clock, _ := newTestManager(t, PluginConfig{Restart: testPolicy})
+	if err := pm.StartPlugin("lang", "fake"); err != nil {
+		t.Fatal(err)
+	}
+	first := instances(pm)[0]
+	kill(t, first)
+
+	// Calls wait for the restart
+	if _, err := pm.GetGreeting(context.Background(), "lang", "fake", greetings.Request{Kind: "hello"}); err != nil {
+		t.Fatalf("GetGreeting after a crash: %v", err)
+	}
+	if running := instances(pm); len(running) != 1 || running[0] == first {
+		t.Errorf("instances after the restart: %v", running)
+	}
+	if delays := clock.Delays(); !slices.Equal(delays, []time.Duration{testPolicy.Backoff}) {
+		t.Errorf("restart delays = %v, want %v", delays, []time.Duration{testPolicy.Backoff})
+	}
+	if state := pm.breakers.Get("lang/fake"); len(state.Crashes) != 1 || state.Quarantined(clock.Now()) {
+		t.Errorf("breaker state: %+v", state)
+	}
+}
+
+func TestSupervisorNeverRestarts(t *testing.T) {
+	policy := testPolicy
+	policy.Never = true
+	pm, clock, _ := newTestManager(t, PluginConfig{Restart: policy})
+	if err := pm.StartPlugin("lang", "fake"); err != nil {
+		t.Fatal(err)
+	}
+	kill(t, instances(pm)[0])
+
+	if running := instances(pm); len(running) != 0 {
+		t.Errorf("restarted %d instances", len(running))
+	}
+	if delays := clock.Delays(); len(delays) != 0 {
+		t.Errorf("restart delays = %v, want none", delays)
+	}
+}
+
+func TestSupervisorQuarantine(t *testing.T) {
+	pm, clock, dir := newTestManager(t, PluginConfig{Restart: testPolicy})
+	if err := pm.StartPlugin("lang", "fake"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The restarts fail too, until one crash too many quarantines the plugin
+	fail := filepath.Join(dir, "fail")
+	if err := os.WriteFile(fail, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	kill(t, instances(pm)[0])
+
+	var quarantined *QuarantinedError
+	_, err := pm.GetGreeting(context.Background(), "lang", "fake", greetings.Request{Kind: "hello"})
+	if !errors.As(err, &quarantined) {
+		t.Fatalf("GetGreeting = %v, want a QuarantinedError", err)
+	}
+	if quarantined.Crashes != testPolicy.MaxRestarts+1 {
+		t.Errorf("quarantined after %d crashes, want %d", quarantined.Crashes, testPolicy.MaxRestarts+1)
+	}
+	want := []time.Duration{testPolicy.Backoff, 2 * testPolicy.Backoff}
+	if delays := clock.Delays(); !slices.Equal(delays, want) {
+		t.Errorf("restart delays = %v, want %v", delays, want)
+	}
+
+	// A quarantined plugin is not executed, even once it would start again
+	if err := os.Remove(fail); err != nil {
+		t.Fatal(err)
+	}
+	if err := pm.StartPlugin("lang", "fake"); !errors.As(err, &quarantined) {
+		t.Fatalf("StartPlugin while quarantined = %v, want a QuarantinedError", err)
+	}
+
+	// Release lifts the quarantine
+	if _, err := pm.breakers.Release("lang/fake"); err != nil {
+		t.Fatal(err)
+	}
+	if err := pm.StartPlugin("lang", "fake"); err != nil {
+		t.Fatalf("StartPlugin after Release: %v", err)
+	}
+}
+
+func TestSupervisorQuarantineExpires(t *testing.T) {
+	pm, clock, _ := newTestManager(t, PluginConfig{Restart: testPolicy})
+	for i := 0; i <= testPolicy.MaxRestarts; i++ {
+		if _, err := pm.breakers.recordCrash("lang/fake", clock.Now(), "killed", testPolicy); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var quarantined *QuarantinedError
+	if err := pm.StartPlugin("lang", "fake"); !errors.As(err, &quarantined) {
+		t.Fatalf("StartPlugin while quarantined = %v, want a QuarantinedError", err)
+	}
+	clock.Advance(testPolicy.Quarantine)
+	if err := pm.StartPlugin("lang", "fake"); err != nil {
+		t.Fatalf("StartPlugin after the quarantine: %v", err)
+	}
+}
+
+func TestCallPluginRetriesAfterCrash(t *testing.T) {
+	pm, _, _ := newTestManager(t, PluginConfig{Restart: testPolicy})
+
+	// The plugin crashes during the first call only
+	greeting, err := pm.GetGreeting(context.Background(), "lang", "fake", greetings.Request{Kind: "crash"})
+	if err != nil {
+		t.Fatalf("GetGreeting: %v", err)
+	}
+	if greeting.Text == "" {
+		t.Error("empty greeting")
+	}
+	if state := pm.breakers.Get("lang/fake"); len(state.Crashes) != 1 {
+		t.Errorf("%d crashes recorded, want 1", len(state.Crashes))
+	}
+}
@@ -0,0 +1,16 @@
+package plugin
+
+import (
+	"github.com/unsuman/greeter/pkg/plugin/limits"
+	"github.com/unsuman/greeter/pkg/plugin/sandbox"
+)
+
+// RunTrampolines executes the plugin instead of returning when the program
+// was started in place of a plugin, to limit or sandbox it. Programs
+// starting plugins call it first thing in main.
+func RunTrampolines() {
+	// A plugin both limited and sandboxed gets its rlimits first, the
+	// limits trampoline then executing the program again for the sandbox
+	limits.RunTrampoline()
+	sandbox.RunTrampoline()
+}
@@ -0,0 +1,69 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/unsuman/greeter/pkg/greetings"
+	"github.com/unsuman/greeter/pkg/plugin/limits"
+	"github.com/unsuman/greeter/pkg/plugin/sandbox"
+)
+
+func TestLimitedSandboxedPlugin(t *testing.T) {
+	pm, _, _ := newTestManager(t, PluginConfig{
+		Limits:  limits.Limits{OpenFiles: 64},
+		Sandbox: sandbox.Config{Enabled: true},
+	})
+	greeting, err := pm.GetGreeting(context.Background(), "lang", "fake", greetings.Request{Kind: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pid := instances(pm)[0].Command.Process.Pid
+	if greeting.Text != fmt.Sprintf("Hello from %d", pid) {
+		t.Errorf("greeting = %q, want one from process %d", greeting.Text, pid)
+	}
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/limits", pid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	openFiles := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "Max open files") {
+			openFiles = strings.Fields(line)[3]
+		}
+	}
+	if openFiles != "64" {
+		t.Errorf("the plugin may open %q files, want 64", openFiles)
+	}
+}
+
+func TestRunTrampolinesOrder(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell")
+	}
+
+	// The plugin manager sandboxes, then limits the command
+	cmd := exec.Command(sh, "-c", "ulimit -n")
+	if _, err := sandbox.Prepare(cmd, "sh", sandbox.Config{Enabled: true}, nil); err != nil {
+		t.Fatal(err)
+	}
+	limiter := limits.New(limits.Limits{OpenFiles: 64}, "", "sh")
+	if err := limiter.Prepare(cmd, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The sandbox would execute the plugin without its rlimits if it ran first
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "64\n" {
+		t.Errorf("the plugin may open %q files, want 64", out)
+	}
+}
@@ -0,0 +1,91 @@
+// Package statefile keeps small JSON state files shared by concurrent
+// greeter processes, such as the crash history of plugins. Updates are
+// serialized by a lock file next to the state file, and written through a
+// temporary file renamed over it, so that readers never see half of one.
+package statefile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrInvalid reports a state file that is not the JSON expected
+var ErrInvalid = errors.New("invalid state file")
+
+// Read decodes the JSON file at path into v, leaving v as is if the file
+// does not exist. A file that cannot be decoded is reported as ErrInvalid.
+func Read(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w: %v", path, ErrInvalid, err)
+	}
+	return nil
+}
+
+// Update reads the file at path into v, calls fn to change v and writes v
+// back, with the file locked against the other processes updating it. v is
+// not written if the file cannot be read or fn fails.
+func Update(path string, v any, fn func() error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	if err := lockFile(lock); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	defer unlockFile(lock)
+
+	if err := Read(path, v); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return write(path, v)
+}
+
+// write replaces the file at path with the JSON encoding of v
+func write(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(path, data, 0644)
+}
+
+// WriteFile replaces the file at path with data through a temporary file
+// of its directory renamed over it, so that readers see either the old or
+// the new content and concurrent writers never mix theirs
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), perm)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,66 @@
+// Package version holds the greeter release version
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is the greeter release, set at build time with
+// -ldflags "-X github.com/unsuman/greeter/pkg/version.Version=1.2.3"
+var Version = "1.0.0"
+
+// Compare compares two dotted versions such as "1.2" or "v1.10.0", missing
+// components counting as 0 and pre-release or build suffixes being ignored.
+// It returns -1, 0 or 1 as a is older than, the same as or newer than b.
+func Compare(a, b string) (int, error) {
+	pa, err := parse(a)
+	if err != nil {
+		return 0, err
+	}
+	pb, err := parse(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// Validate reports whether v is a dotted version Compare accepts
+func Validate(v string) error {
+	_, err := parse(v)
+	return err
+}
+
+func parse(v string) ([]int, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+
+	var parts []int
+	for _, field := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version %q", v)
+		}
+		parts = append(parts, n)
+	}
+	return parts, nil
+}
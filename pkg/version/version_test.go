@@ -0,0 +1,35 @@
+package version
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.2", "1.2.0", 0},
+		{"v1.10.0", "1.9.3", 1},
+		{"1.2.0-rc1", "1.2.0+build5", 0},
+		{"1.1.9", "1.2", -1},
+	}
+	for _, tt := range tests {
+		got, err := Compare(tt.a, tt.b)
+		if err != nil || got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, %v, want %d", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, v := range []string{"1", "1.2.3", "v2.0", "1.0.0-beta"} {
+		if err := Validate(v); err != nil {
+			t.Errorf("Validate(%q) = %v", v, err)
+		}
+	}
+	for _, v := range []string{"", "one", "1..2", "1.-2", "dev"} {
+		if err := Validate(v); err == nil {
+			t.Errorf("Validate(%q) succeeded, want an error", v)
+		}
+	}
+}
@@ -2,6 +2,78 @@ package english
 
 import "github.com/unsuman/greeter/pkg/greetings"
 
+// phrases holds the English greeting templates
+var phrases = &greetings.Phrasebook{
+	Script: "Latn",
+	Greetings: map[string]greetings.Registers{
+		greetings.KindHello: {
+			greetings.Casual: {Text: "Hi[, {name}]!", Weight: 3, Variants: []greetings.Phrase{
+				{Text: "Hey[ {name}]!", Weight: 2},
+				{Text: "Hiya[, {name}]!"},
+			}},
+			greetings.Neutral: {Text: "Hello[, {name}]!", Weight: 3, Variants: []greetings.Phrase{
+				{Text: "Hello there[, {name}]!"},
+			}},
+			greetings.Formal: {Text: "Good day[, {name}]."},
+		},
+		greetings.KindGoodMorning: {
+			greetings.Casual:  {Text: "Morning[, {name}]!"},
+			greetings.Neutral: {Text: "Good morning[, {name}]!"},
+		},
+		greetings.KindGoodAfternoon: {
+			greetings.Neutral: {Text: "Good afternoon[, {name}]!"},
+		},
+		greetings.KindGoodEvening: {
+			greetings.Neutral: {Text: "Good evening[, {name}]!"},
+		},
+		greetings.KindGoodNight: {
+			greetings.Casual:  {Text: "Night[, {name}]!"},
+			greetings.Neutral: {Text: "Good night[, {name}]!"},
+		},
+		greetings.KindGoodBye: {
+			greetings.Casual: {Text: "Bye[, {name}]!", Weight: 2, Variants: []greetings.Phrase{
+				{Text: "See you[, {name}]!", Weight: 2},
+				{Text: "Take care[, {name}]!"},
+			}},
+			greetings.Neutral: {Text: "Goodbye[, {name}]!"},
+			greetings.Formal:  {Text: "Farewell[, {name}]."},
+		},
+		greetings.KindWelcome: {
+			greetings.Neutral: {Text: "Welcome[, {name}]!"},
+			greetings.Formal:  {Text: "You are most welcome[, {name}]."},
+		},
+		greetings.KindCongratulations: {
+			greetings.Casual:  {Text: "Congrats[, {name}]!"},
+			greetings.Neutral: {Text: "Congratulations[, {name}]!"},
+		},
+		greetings.KindHappyBirthday: {
+			greetings.Neutral: {Text: "Happy birthday[, {name}]!"},
+		},
+		greetings.KindNewYear: {
+			greetings.Neutral: {Text: "Happy New Year[, {name}]!"},
+		},
+		kindEaster: {
+			greetings.Neutral: {Text: "Happy Easter[, {name}]!"},
+		},
+		kindChristmas: {
+			greetings.Neutral: {Text: "Merry Christmas[, {name}]!"},
+			greetings.Formal:  {Text: "Season's greetings[, {name}]."},
+		},
+	},
+}
+
+// Holiday greeting kinds
+const (
+	kindEaster    = "easter"
+	kindChristmas = "christmas"
+)
+
+var occasions = []greetings.Occasion{
+	{Kind: greetings.KindNewYear, Name: "New Year's Day", Rule: "01-01"},
+	{Kind: kindEaster, Name: "Easter", Rule: "easter"},
+	{Kind: kindChristmas, Name: "Christmas", Rule: "12-24", Days: 2},
+}
+
 // Greeter implements the Greeter interface in English language
 type Greeter struct{}
 
@@ -10,30 +82,33 @@ func New() greetings.Plugin {
 	return &Greeter{}
 }
 
-func (g *Greeter) Hello() string {
-	return "Hello!"
+func (g *Greeter) Greet(req greetings.Request) (greetings.Greeting, error) {
+	return phrases.Greet(g.Name(), req)
 }
 
-func (g *Greeter) GoodMorning() string {
-	return "Good morning!"
+func (g *Greeter) Variants(req greetings.Request) ([]greetings.Variant, error) {
+	return phrases.Variants(g.Name(), req)
 }
 
-func (g *Greeter) GoodAfternoon() string {
-	return "Good afternoon!"
-}
-
-func (g *Greeter) GoodNight() string {
-	return "Good night!"
-}
-
-func (g *Greeter) GoodBye() string {
-	return "Goodbye!"
+func (g *Greeter) Kinds() []string {
+	return phrases.Kinds()
 }
 
 func (g *Greeter) Name() string {
 	return "english"
 }
 
+func (g *Greeter) Info() greetings.Info {
+	return greetings.Info{
+		DisplayName: "English",
+		Version:     "1.0.0",
+		Author:      "Greeter authors",
+		Description: "English greetings",
+		Locale:      "en",
+		Occasions:   occasions,
+	}
+}
+
 func (g *Greeter) Init() error {
 	return nil
 }
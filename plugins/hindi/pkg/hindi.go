@@ -2,36 +2,176 @@ package hindi
 
 import "github.com/unsuman/greeter/pkg/greetings"
 
-type Greeter struct{}
-
-func New() greetings.Plugin {
-	return &Greeter{}
+// phrases holds the Hindi greeting templates. Honorific forms address the
+// recipient with the respectful suffix जी (ji).
+var phrases = &greetings.Phrasebook{
+	Script: "Deva",
+	Greetings: map[string]greetings.Registers{
+		greetings.KindHello: {
+			greetings.Casual: {Text: "हाय[ {name}]!", Romanization: "Hi[ {name}]!", Variants: []greetings.Phrase{
+				{Text: "हेलो[ {name}]!", Romanization: "Hello[ {name}]!"},
+			}},
+			greetings.Neutral: {
+				Text:          "नमस्ते[ {name}]!",
+				Romanization:  "Namaste[ {name}]!",
+				Pronunciation: "nəˈmə.steː",
+				Meaning:       "I bow to you",
+			},
+			greetings.Formal:    {Text: "नमस्कार[ {name}]!", Romanization: "Namaskar[ {name}]!"},
+			greetings.Honorific: {Text: "प्रणाम[ {name} जी]!", Romanization: "Pranam[ {name} ji]!"},
+		},
+		greetings.KindGoodMorning: {
+			greetings.Neutral: {
+				Text:          "शुभ प्रभात[ {name}]!",
+				Romanization:  "Shubh Prabhat[ {name}]!",
+				Pronunciation: "ʃʊbʱ prəˈbʱaːt",
+				Meaning:       "auspicious dawn",
+			},
+			greetings.Honorific: {Text: "सुप्रभात[ {name} जी]!", Romanization: "Suprabhat[ {name} ji]!"},
+		},
+		greetings.KindGoodAfternoon: {
+			greetings.Neutral: {
+				Text:          "शुभ दोपहर[ {name}]!",
+				Romanization:  "Shubh Dopahar[ {name}]!",
+				Pronunciation: "ʃʊbʱ doːˈpəɦər",
+				Meaning:       "auspicious noon",
+			},
+		},
+		greetings.KindGoodEvening: {
+			greetings.Neutral: {
+				Text:          "शुभ संध्या[ {name}]!",
+				Romanization:  "Shubh Sandhya[ {name}]!",
+				Pronunciation: "ʃʊbʱ ˈsən.d̪ʱjaː",
+				Meaning:       "auspicious dusk",
+			},
+		},
+		greetings.KindGoodNight: {
+			greetings.Neutral: {
+				Text:          "शुभ रात्रि[ {name}]!",
+				Romanization:  "Shubh Ratri[ {name}]!",
+				Pronunciation: "ʃʊbʱ ˈraː.t̪ri",
+				Meaning:       "auspicious night",
+			},
+			greetings.Honorific: {Text: "शुभ रात्रि[ {name} जी]!", Romanization: "Shubh Ratri[ {name} ji]!"},
+		},
+		greetings.KindGoodBye: {
+			greetings.Casual: {Text: "चलो, फिर मिलते हैं[ {name}]!", Romanization: "Chalo, phir milte hain[ {name}]!"},
+			greetings.Neutral: {
+				Text:          "अलविदा[ {name}]!",
+				Romanization:  "Alvida[ {name}]!",
+				Pronunciation: "əlˈʋɪ.d̪aː",
+				Meaning:       "farewell",
+			},
+			greetings.Formal:    {Text: "फिर मिलेंगे[ {name}]!", Romanization: "Phir milenge[ {name}]!"},
+			greetings.Honorific: {Text: "फिर मिलेंगे[ {name} जी]!", Romanization: "Phir milenge[ {name} ji]!"},
+		},
+		greetings.KindWelcome: {
+			greetings.Neutral: {
+				Text:          "स्वागत है[ {name}]!",
+				Romanization:  "Swagat hai[ {name}]!",
+				Pronunciation: "sʋaːˈɡət̪ ɦɛː",
+				Meaning:       "it is a welcome",
+			},
+			greetings.Honorific: {Text: "आपका स्वागत है[ {name} जी]!", Romanization: "Aapka swagat hai[ {name} ji]!"},
+		},
+		greetings.KindCongratulations: {
+			greetings.Neutral: {
+				Text:          "बधाई हो[ {name}]!",
+				Romanization:  "Badhai ho[ {name}]!",
+				Pronunciation: "bəˈd̪ʱaːiː ɦoː",
+				Meaning:       "may there be congratulations",
+			},
+		},
+		greetings.KindHappyBirthday: {
+			greetings.Neutral: {
+				Text:          "जन्मदिन मुबारक हो[ {name}]!",
+				Romanization:  "Janamdin mubarak ho[ {name}]!",
+				Pronunciation: "ˈdʒə.nəm.d̪ɪn mʊˈbaː.rək ɦoː",
+				Meaning:       "may your birthday be blessed",
+			},
+		},
+		greetings.KindNewYear: {
+			greetings.Neutral: {
+				Text:         "नया साल मुबारक हो[ {name}]!",
+				Romanization: "Naya saal mubarak ho[ {name}]!",
+				Meaning:      "may the new year be blessed",
+			},
+		},
+		kindDiwali: {
+			greetings.Neutral: {
+				Text:          "दीपावली की शुभकामनाएं[ {name}]!",
+				Romanization:  "Deepavali ki shubhkamnayein[ {name}]!",
+				Pronunciation: "d̪iː.pɑː.ʋə.liː kiː ʃʊbʱ.kɑːm.nɑː.ẽː",
+				Meaning:       "best wishes for the festival of lights",
+			},
+			greetings.Casual: {Text: "हैप्पी दिवाली[ {name}]!", Romanization: "Happy Diwali[ {name}]!"},
+		},
+		kindHoli: {
+			greetings.Neutral: {
+				Text:         "होली की शुभकामनाएं[ {name}]!",
+				Romanization: "Holi ki shubhkamnayein[ {name}]!",
+				Meaning:      "best wishes for Holi",
+			},
+			greetings.Casual: {Text: "होली मुबारक[ {name}]!", Romanization: "Holi mubarak[ {name}]!"},
+		},
+	},
 }
 
-func (g *Greeter) Hello() string {
-	return "नमस्ते! (Namaste!)"
+// Festival greeting kinds
+const (
+	kindDiwali = "diwali"
+	kindHoli   = "holi"
+)
+
+// occasions follow the amanta lunisolar calendar, observed in India. Diwali
+// is the last new moon before the sun enters sidereal Scorpio, celebrated on
+// the evening of the new moon tithi. Holi follows the full moon ending the
+// month of Phalguna, the day switching in the afternoon.
+var occasions = []greetings.Occasion{
+	{Kind: greetings.KindNewYear, Name: "New Year", Rule: "01-01"},
+	{Kind: kindHoli, Name: "Holi", Rule: "full moon before new moon before 04-14, shifted +7h30m, in Asia/Kolkata"},
+	{Kind: kindDiwali, Name: "Diwali", Rule: "new moon before 11-16, shifted -19h, in Asia/Kolkata"},
 }
 
-func (g *Greeter) GoodMorning() string {
-	return "शुभ प्रभात! (Shubh Prabhat!)"
+type Greeter struct{}
+
+func New() greetings.Plugin {
+	return &Greeter{}
 }
 
-func (g *Greeter) GoodAfternoon() string {
-	return "शुभ दोपहर! (Shubh Dophar)"
+func (g *Greeter) Greet(req greetings.Request) (greetings.Greeting, error) {
+	return phrases.Greet(g.Name(), req)
 }
 
-func (g *Greeter) GoodNight() string {
-	return "शुभ रात्रि! (Shubh Ratri!)"
+func (g *Greeter) Variants(req greetings.Request) ([]greetings.Variant, error) {
+	return phrases.Variants(g.Name(), req)
 }
 
-func (g *Greeter) GoodBye() string {
-	return "अलविदा! (Alvida!)"
+func (g *Greeter) Kinds() []string {
+	return phrases.Kinds()
 }
 
 func (g *Greeter) Name() string {
 	return "hindi"
 }
 
+func (g *Greeter) Info() greetings.Info {
+	return greetings.Info{
+		DisplayName: "Hindi (हिन्दी)",
+		Version:     "1.0.0",
+		Author:      "Greeter authors",
+		Description: "Hindi greetings in Devanagari with romanization",
+		Locale:      "hi",
+		DayParts: []greetings.DayPart{
+			{Start: greetings.At(4, 0), Kind: greetings.KindGoodMorning},
+			{Start: greetings.At(12, 0), Kind: greetings.KindGoodAfternoon},
+			{Start: greetings.At(16, 0), Kind: greetings.KindGoodEvening},
+			{Start: greetings.At(20, 0), Kind: greetings.KindGoodNight},
+		},
+		Occasions: occasions,
+	}
+}
+
 func (g *Greeter) Init() error {
 	return nil
 }
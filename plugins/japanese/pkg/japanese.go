@@ -2,36 +2,170 @@ package japanese
 
 import "github.com/unsuman/greeter/pkg/greetings"
 
-type Greeter struct{}
-
-func New() greetings.Plugin {
-	return &Greeter{}
+// phrases holds the Japanese greeting templates. Casual forms use the bare
+// name, neutral and formal ones add さん (san) and honorific ones 様 (sama).
+var phrases = &greetings.Phrasebook{
+	Script: "Jpan",
+	Greetings: map[string]greetings.Registers{
+		greetings.KindHello: {
+			greetings.Casual: {Text: "[{name}、]やあ!", Romanization: "[{name}, ]Yaa", Weight: 2, Variants: []greetings.Phrase{
+				{Text: "[{name}、]おっす!", Romanization: "[{name}, ]Ossu", Meaning: "hey (among friends)"},
+			}},
+			greetings.Neutral: {
+				Text:          "[{name}さん、]こんにちは!",
+				Romanization:  "[{name}-san, ]Konnichiwa",
+				Pronunciation: "kon.ni.tɕi.wa",
+				Meaning:       "as for today",
+			},
+			greetings.Honorific: {Text: "[{name}様、]こんにちは!", Romanization: "[{name}-sama, ]Konnichiwa"},
+		},
+		greetings.KindGoodMorning: {
+			greetings.Casual: {Text: "[{name}、]おはよう!", Romanization: "[{name}, ]Ohayou"},
+			greetings.Neutral: {
+				Text:          "[{name}さん、]おはようございます!",
+				Romanization:  "[{name}-san, ]Ohayou gozaimasu",
+				Pronunciation: "o.ha.joː ɡo.za.i.ma.sɯ",
+				Meaning:       "it is early",
+			},
+			greetings.Honorific: {Text: "[{name}様、]おはようございます!", Romanization: "[{name}-sama, ]Ohayou gozaimasu"},
+		},
+		greetings.KindGoodAfternoon: {
+			greetings.Neutral: {
+				Text:          "[{name}さん、]こんにちは!",
+				Romanization:  "[{name}-san, ]Konnichiwa",
+				Pronunciation: "kon.ni.tɕi.wa",
+				Meaning:       "as for today",
+			},
+			greetings.Honorific: {Text: "[{name}様、]こんにちは!", Romanization: "[{name}-sama, ]Konnichiwa"},
+		},
+		greetings.KindGoodEvening: {
+			greetings.Neutral: {
+				Text:          "[{name}さん、]こんばんは!",
+				Romanization:  "[{name}-san, ]Konbanwa",
+				Pronunciation: "kom.baɴ.wa",
+				Meaning:       "as for this evening",
+			},
+			greetings.Honorific: {Text: "[{name}様、]こんばんは!", Romanization: "[{name}-sama, ]Konbanwa"},
+		},
+		greetings.KindGoodNight: {
+			greetings.Casual: {Text: "[{name}、]おやすみ!", Romanization: "[{name}, ]Oyasumi"},
+			greetings.Neutral: {
+				Text:          "[{name}さん、]おやすみなさい!",
+				Romanization:  "[{name}-san, ]Oyasumi nasai",
+				Pronunciation: "o.ja.sɯ.mi na.sa.i",
+				Meaning:       "please rest",
+			},
+			greetings.Honorific: {Text: "[{name}様、]おやすみなさいませ!", Romanization: "[{name}-sama, ]Oyasumi nasaimase"},
+		},
+		greetings.KindGoodBye: {
+			greetings.Casual: {Text: "[{name}、]じゃあね!", Romanization: "[{name}, ]Jaa ne"},
+			greetings.Neutral: {
+				Text:          "[{name}さん、]さようなら!",
+				Romanization:  "[{name}-san, ]Sayounara",
+				Pronunciation: "sa.joː.na.ɾa",
+				Meaning:       "if it must be so",
+			},
+			greetings.Formal:    {Text: "[{name}さん、]失礼します!", Romanization: "[{name}-san, ]Shitsurei shimasu"},
+			greetings.Honorific: {Text: "[{name}様、]失礼いたします!", Romanization: "[{name}-sama, ]Shitsurei itashimasu"},
+		},
+		greetings.KindWelcome: {
+			greetings.Neutral: {
+				Text:          "ようこそ[、{name}さん]!",
+				Romanization:  "Youkoso[, {name}-san]",
+				Pronunciation: "joː.ko.so",
+				Meaning:       "well you have come",
+			},
+			greetings.Honorific: {Text: "いらっしゃいませ[、{name}様]!", Romanization: "Irasshaimase[, {name}-sama]"},
+		},
+		greetings.KindCongratulations: {
+			greetings.Casual: {Text: "[{name}、]おめでとう!", Romanization: "[{name}, ]Omedetou"},
+			greetings.Neutral: {
+				Text:          "[{name}さん、]おめでとうございます!",
+				Romanization:  "[{name}-san, ]Omedetou gozaimasu",
+				Pronunciation: "o.me.de.toː ɡo.za.i.ma.sɯ",
+				Meaning:       "it is auspicious",
+			},
+		},
+		greetings.KindHappyBirthday: {
+			greetings.Casual: {Text: "[{name}、]お誕生日おめでとう!", Romanization: "[{name}, ]Otanjoubi omedetou"},
+			greetings.Neutral: {
+				Text:          "[{name}さん、]お誕生日おめでとうございます!",
+				Romanization:  "[{name}-san, ]Otanjoubi omedetou gozaimasu",
+				Pronunciation: "o.taɴ.dʑoː.bi o.me.de.toː ɡo.za.i.ma.sɯ",
+				Meaning:       "the birthday is auspicious",
+			},
+		},
+		greetings.KindNewYear: {
+			greetings.Casual: {Text: "[{name}、]あけおめ!", Romanization: "[{name}, ]Akeome"},
+			greetings.Neutral: {
+				Text:          "[{name}さん、]明けましておめでとうございます!",
+				Romanization:  "[{name}-san, ]Akemashite omedetou gozaimasu",
+				Pronunciation: "a.ke.ma.ɕi.te o.me.de.toː ɡo.za.i.ma.sɯ",
+				Meaning:       "congratulations on the opening of the year",
+			},
+			greetings.Formal: {Text: "[{name}様、]謹んで新年のお慶びを申し上げます。", Romanization: "[{name}-sama, ]Tsutsushinde shinnen no oyorokobi wo moushiagemasu"},
+		},
+		kindYearEnd: {
+			greetings.Casual: {Text: "[{name}、]良いお年を!", Romanization: "[{name}, ]Yoi otoshi wo"},
+			greetings.Neutral: {
+				Text:         "[{name}さん、]良いお年をお迎えください!",
+				Romanization: "[{name}-san, ]Yoi otoshi wo omukae kudasai",
+				Meaning:      "please welcome a good year",
+			},
+		},
+	},
 }
 
-func (g *Greeter) Hello() string {
-	return "こんにちは! (Konnichiwa)"
+// kindYearEnd is the farewell of the last days of the year
+const kindYearEnd = "yearend"
+
+// occasions greets the new year during matsu no uchi, the first week of
+// January, and wishes a good year over the last days of December
+var occasions = []greetings.Occasion{
+	{Kind: greetings.KindNewYear, Name: "Shōgatsu (New Year)", Rule: "01-01", Days: 7},
+	{Kind: kindYearEnd, Name: "Year end", Rule: "12-28", Days: 4},
 }
 
-func (g *Greeter) GoodMorning() string {
-	return "おはようございます! (Ohayou gozaimasu)"
+type Greeter struct{}
+
+func New() greetings.Plugin {
+	return &Greeter{}
 }
 
-func (g *Greeter) GoodAfternoon() string {
-	return "こんにちは! (Konnichiwa)"
+func (g *Greeter) Greet(req greetings.Request) (greetings.Greeting, error) {
+	return phrases.Greet(g.Name(), req)
 }
 
-func (g *Greeter) GoodNight() string {
-	return "おやすみなさい! (Oyasumi nasai)"
+func (g *Greeter) Variants(req greetings.Request) ([]greetings.Variant, error) {
+	return phrases.Variants(g.Name(), req)
 }
 
-func (g *Greeter) GoodBye() string {
-	return "さようなら! (Sayounara)"
+func (g *Greeter) Kinds() []string {
+	return phrases.Kinds()
 }
 
 func (g *Greeter) Name() string {
 	return "japanese"
 }
 
+func (g *Greeter) Info() greetings.Info {
+	return greetings.Info{
+		DisplayName: "Japanese (日本語)",
+		Version:     "1.0.0",
+		Author:      "Greeter authors",
+		Description: "Japanese greetings in Kana with romanization",
+		Locale:      "ja",
+		// おはよう is only said early in the day, こんにちは covers most of the daytime
+		DayParts: []greetings.DayPart{
+			{Start: greetings.At(4, 0), Kind: greetings.KindGoodMorning},
+			{Start: greetings.At(10, 30), Kind: greetings.KindGoodAfternoon},
+			{Start: greetings.At(18, 0), Kind: greetings.KindGoodEvening},
+			{Start: greetings.At(23, 0), Kind: greetings.KindGoodNight},
+		},
+		Occasions: occasions,
+	}
+}
+
 func (g *Greeter) Init() error {
 	return nil
 }